@@ -31,9 +31,11 @@ type book struct {
 	rawSteps             []map[string]any
 	hostRules            hostRules
 	hostRulesFromOpts    hostRules
+	proxy                *proxy
 	debug                bool
 	ifCond               string
 	skipTest             bool
+	updateSnapshots      bool
 	funcs                map[string]any
 	stepKeys             []string
 	path                 string // runbook file path
@@ -43,6 +45,10 @@ type book struct {
 	cdpRunners           map[string]*cdpRunner
 	sshRunners           map[string]*sshRunner
 	includeRunners       map[string]*includeRunner
+	graphqlRunners       map[string]*graphqlRunner
+	wsRunners            map[string]*wsRunner
+	serverRunners        map[string]*serverRunner
+	grpcServerRunners    map[string]*grpcServerRunner
 	profile              bool
 	intervalStr          string
 	interval             time.Duration
@@ -60,6 +66,7 @@ type book struct {
 	openAPI3DocLocations []string
 	grpcNoTLS            bool
 	grpcProtos           []string
+	grpcProtosets        []string
 	grpcImportPaths      []string
 	grpcBufDirs          []string
 	grpcBufLocks         []string
@@ -80,6 +87,8 @@ type book struct {
 	beforeFuncs          []func(*RunResult) error
 	afterFuncs           []func(*RunResult) error
 	capturers            capturers
+	cassettes            *cassettes
+	grpcDescriptors      *grpcDescriptors
 	stdout               io.Writer
 	stderr               io.Writer
 	// Skip some errors for `runn list`
@@ -197,6 +206,12 @@ func (bk *book) parseRunner(k string, v any) error {
 				return err
 			}
 			bk.httpRunners[k] = hc
+		case strings.HasPrefix(vv, "wss://") || strings.HasPrefix(vv, "ws://"):
+			wc, err := newWSRunner(k, vv)
+			if err != nil {
+				return err
+			}
+			bk.wsRunners[k] = wc
 		case strings.HasPrefix(vv, "grpc://"):
 			addr := strings.TrimPrefix(vv, "grpc://")
 			gc, err := newGrpcRunner(k, addr)
@@ -232,12 +247,44 @@ func (bk *book) parseRunner(k string, v any) error {
 		}
 		detect := false
 
-		// HTTP Runner
-		detect, err = bk.parseHTTPRunnerWithDetailed(k, tmp)
+		// WebSocket Runner
+		detect, err = bk.parseWSRunnerWithDetailed(k, tmp)
 		if err != nil {
 			return err
 		}
 
+		// HTTP Runner
+		if !detect {
+			detect, err = bk.parseHTTPRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
+		// GraphQL Runner
+		if !detect {
+			detect, err = bk.parseGraphQLRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Server Runner
+		if !detect {
+			detect, err = bk.parseServerRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
+		// gRPC Server Runner
+		if !detect {
+			detect, err = bk.parseGRPCServerRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// gRPC Runner
 		if !detect {
 			detect, err = bk.parseGRPCRunnerWithDetailed(k, tmp)
@@ -246,6 +293,14 @@ func (bk *book) parseRunner(k string, v any) error {
 			}
 		}
 
+		// CDP Runner
+		if !detect {
+			detect, err = bk.parseCDPRunnerWithDetailed(k, tmp)
+			if err != nil {
+				return err
+			}
+		}
+
 		// DB Runner
 		if !detect {
 			detect, err = bk.parseDBRunnerWithDetailed(k, tmp)
@@ -306,49 +361,71 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 			return false, err
 		}
 	}
-	if c.CACert != "" {
-		p, err := fp(c.CACert, root)
+	r.cacert, r.cert, r.key, err = readTLSFiles(root, c.CACert, c.Cert, c.Key)
+	if err != nil {
+		return false, err
+	}
+	r.skipVerify = c.SkipVerify
+	if c.Timeout != "" {
+		r.client.Timeout, err = duration.Parse(c.Timeout)
+		if err != nil {
+			return false, fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
+		}
+	}
+	r.useCookie = c.UseCookie
+	r.trace = c.Trace.Enable
+	r.traceHeaderName = c.Trace.HeaderName
+	if c.Auth != nil {
+		r.auth, err = newHTTPAuth(c.Auth)
 		if err != nil {
 			return false, err
 		}
-		b, err := readFile(p)
+	}
+	if c.Proxy != "" {
+		p, err := newProxy(c.Proxy, false)
 		if err != nil {
 			return false, err
 		}
-		r.cacert = b
+		if err := r.setProxy(p); err != nil {
+			return false, err
+		}
 	}
-	if c.Cert != "" {
-		p, err := fp(c.Cert, root)
+	for _, p := range c.ImportPaths {
+		pp, err := fp(p, root)
 		if err != nil {
 			return false, err
 		}
-		b, err := readFile(p)
+		r.importPaths = append(r.importPaths, pp)
+	}
+	for _, p := range c.Protos {
+		pp, err := fp(p, root)
 		if err != nil {
 			return false, err
 		}
-		r.cert = b
+		r.protos = append(r.protos, pp)
 	}
-	if c.Key != "" {
-		p, err := fp(c.Key, root)
+	for _, p := range c.BufDirs {
+		pp, err := fp(p, root)
 		if err != nil {
 			return false, err
 		}
-		b, err := readFile(p)
+		r.bufDirs = append(r.bufDirs, pp)
+	}
+	for _, p := range c.BufLocks {
+		pp, err := fp(p, root)
 		if err != nil {
 			return false, err
 		}
-		r.key = b
+		r.bufLocks = append(r.bufLocks, pp)
 	}
-	r.skipVerify = c.SkipVerify
-	if c.Timeout != "" {
-		r.client.Timeout, err = duration.Parse(c.Timeout)
+	for _, p := range c.BufConfigs {
+		pp, err := fp(p, root)
 		if err != nil {
-			return false, fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
+			return false, err
 		}
+		r.bufConfigs = append(r.bufConfigs, pp)
 	}
-	r.useCookie = c.UseCookie
-	r.trace = c.Trace.Enable
-	r.traceHeaderName = c.Trace.HeaderName
+	r.bufModules = c.BufModules
 	hv, err := newHttpValidator(c)
 	if err != nil {
 		return false, err
@@ -357,6 +434,94 @@ func (bk *book) parseHTTPRunnerWithDetailed(name string, b []byte) (bool, error)
 	return true, nil
 }
 
+func (bk *book) parseGraphQLRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &graphqlRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if c.Endpoint == "" {
+		return false, nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return false, err
+	}
+	r, err := newGraphQLRunner(name, c.Endpoint)
+	if err != nil {
+		return false, err
+	}
+	if err := r.applyConfig(c, root); err != nil {
+		return false, err
+	}
+	bk.graphqlRunners[name] = r
+	return true, nil
+}
+
+func (bk *book) parseWSRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &wsRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if !strings.HasPrefix(c.Endpoint, "wss://") && !strings.HasPrefix(c.Endpoint, "ws://") {
+		return false, nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return false, err
+	}
+	r, err := newWSRunner(name, c.Endpoint)
+	if err != nil {
+		return false, err
+	}
+	if err := r.applyConfig(c, root); err != nil {
+		return false, err
+	}
+	bk.wsRunners[name] = r
+	return true, nil
+}
+
+func (bk *book) parseServerRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &serverRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if c.Addr == "" {
+		return false, nil
+	}
+	r, err := newServerRunner(name, c.Addr)
+	if err != nil {
+		return false, err
+	}
+	if err := r.applyConfig(c); err != nil {
+		return false, err
+	}
+	bk.serverRunners[name] = r
+	return true, nil
+}
+
+func (bk *book) parseGRPCServerRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &grpcServerRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if c.Addr == "" {
+		return false, nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return false, err
+	}
+	r, err := newGRPCServerRunner(name, c.Addr)
+	if err != nil {
+		return false, err
+	}
+	if err := r.applyConfig(c, root); err != nil {
+		return false, err
+	}
+	bk.grpcServerRunners[name] = r
+	return true, nil
+}
+
 func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error) {
 	c := &grpcRunnerConfig{}
 	if err := yaml.Unmarshal(b, c); err != nil {
@@ -373,6 +538,13 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 	if err != nil {
 		return false, err
 	}
+	if !validGRPCProtocol(c.Protocol) {
+		return false, fmt.Errorf("invalid protocol: %s", c.Protocol)
+	}
+	r.protocol = c.Protocol
+	if err := r.applyTransportConfig(c); err != nil {
+		return false, err
+	}
 	r.tls = c.TLS
 	if len(c.cacert) != 0 {
 		r.cacert = c.cacert
@@ -428,6 +600,13 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 		}
 		r.protos = append(r.protos, pp)
 	}
+	for _, p := range c.Protosets {
+		pp, err := fp(p, root)
+		if err != nil {
+			return false, err
+		}
+		r.protosets = append(r.protosets, pp)
+	}
 	for _, p := range c.BufDirs {
 		pp, err := fp(p, root)
 		if err != nil {
@@ -452,11 +631,36 @@ func (bk *book) parseGRPCRunnerWithDetailed(name string, b []byte) (bool, error)
 	r.bufModules = c.BufModules
 	r.trace = c.Trace.Enable
 	r.traceHeaderName = c.Trace.HeaderName
+	if c.Proxy != "" {
+		r.proxy, err = newProxy(c.Proxy, false)
+		if err != nil {
+			return false, err
+		}
+	}
 
 	bk.grpcRunners[name] = r
 	return true, nil
 }
 
+func (bk *book) parseCDPRunnerWithDetailed(name string, b []byte) (bool, error) {
+	c := &cdpRunnerConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return false, nil
+	}
+	if !strings.HasPrefix(c.Remote, "cdp://") && !strings.HasPrefix(c.Remote, "chrome://") {
+		return false, nil
+	}
+	r, err := newCDPRunner(name, strings.TrimPrefix(strings.TrimPrefix(c.Remote, "cdp://"), "chrome://"))
+	if err != nil {
+		return false, err
+	}
+	if err := r.applyConfig(c); err != nil {
+		return false, err
+	}
+	bk.cdpRunners[name] = r
+	return true, nil
+}
+
 func (bk *book) parseDBRunnerWithDetailed(name string, b []byte) (bool, error) {
 	c := &dbRunnerConfig{}
 	if err := yaml.Unmarshal(b, c); err != nil {
@@ -636,6 +840,18 @@ func (bk *book) merge(loaded *book) error {
 	for k, r := range loaded.includeRunners {
 		bk.includeRunners[k] = r
 	}
+	for k, r := range loaded.graphqlRunners {
+		bk.graphqlRunners[k] = r
+	}
+	for k, r := range loaded.wsRunners {
+		bk.wsRunners[k] = r
+	}
+	for k, r := range loaded.serverRunners {
+		bk.serverRunners[k] = r
+	}
+	for k, r := range loaded.grpcServerRunners {
+		bk.grpcServerRunners[k] = r
+	}
 	for k, v := range loaded.vars {
 		bk.vars[k] = v
 	}
@@ -662,6 +878,7 @@ func (bk *book) merge(loaded *book) error {
 	bk.openAPI3DocLocations = loaded.openAPI3DocLocations
 	bk.grpcNoTLS = loaded.grpcNoTLS
 	bk.grpcProtos = loaded.grpcProtos
+	bk.grpcProtosets = loaded.grpcProtosets
 	bk.grpcImportPaths = loaded.grpcImportPaths
 	bk.grpcBufDirs = loaded.grpcBufDirs
 	bk.grpcBufLocks = loaded.grpcBufLocks
@@ -698,20 +915,24 @@ func detectSSHRunner(v any) bool {
 
 func newBook() *book {
 	return &book{
-		runners:        map[string]any{},
-		vars:           map[string]any{},
-		rawSteps:       []map[string]any{},
-		funcs:          map[string]any{},
-		httpRunners:    map[string]*httpRunner{},
-		dbRunners:      map[string]*dbRunner{},
-		grpcRunners:    map[string]*grpcRunner{},
-		cdpRunners:     map[string]*cdpRunner{},
-		sshRunners:     map[string]*sshRunner{},
-		includeRunners: map[string]*includeRunner{},
-		interval:       0 * time.Second,
-		runnerErrs:     map[string]error{},
-		stdout:         os.Stdout,
-		stderr:         os.Stderr,
+		runners:           map[string]any{},
+		vars:              map[string]any{},
+		rawSteps:          []map[string]any{},
+		funcs:             map[string]any{},
+		httpRunners:       map[string]*httpRunner{},
+		dbRunners:         map[string]*dbRunner{},
+		grpcRunners:       map[string]*grpcRunner{},
+		cdpRunners:        map[string]*cdpRunner{},
+		sshRunners:        map[string]*sshRunner{},
+		includeRunners:    map[string]*includeRunner{},
+		graphqlRunners:    map[string]*graphqlRunner{},
+		wsRunners:         map[string]*wsRunner{},
+		serverRunners:     map[string]*serverRunner{},
+		grpcServerRunners: map[string]*grpcServerRunner{},
+		interval:          0 * time.Second,
+		runnerErrs:        map[string]error{},
+		stdout:            os.Stdout,
+		stderr:            os.Stderr,
 	}
 }
 
@@ -764,7 +985,7 @@ func parseBook(in io.Reader) (*book, error) {
 }
 
 func validateRunnerKey(k string) error {
-	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey || k == runnerRunnerKey {
+	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey || k == runnerRunnerKey {
 		return fmt.Errorf("runner name %q is reserved for built-in runner", k)
 	}
 	if k == ifSectionKey || k == descSectionKey || k == loopSectionKey || k == deferSectionKey || k == forceSectionKey {
@@ -784,7 +1005,7 @@ func validateStepKeys(s map[string]any) error {
 		if k == ifSectionKey || k == descSectionKey || k == loopSectionKey || k == deferSectionKey || k == forceSectionKey {
 			continue
 		}
-		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey {
+		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey {
 			subRunner += 1
 			continue
 		}
@@ -48,6 +48,19 @@ type runbook struct {
 	currentGRPCResponceIndex int
 	currentGRPCTestCond      []string
 	currentExecTestCond      []string
+	currentWSReceiveCount    int
+	currentWSTestCond        []string
+	currentCDPActionIndex    int
+	currentCDPResponses      []cdpResponse
+
+	comments yaml.CommentMap
+}
+
+// cdpResponse is a value recorded by a CDP action ( e.g. `current.text` ).
+type cdpResponse struct {
+	actionIndex int
+	key         string
+	value       any
 }
 
 type RunbookOption func(*cRunbook) error
@@ -333,16 +346,143 @@ func (c *cRunbook) CaptureGRPCEnd(name string, typ runn.GRPCType, service, metho
 }
 
 func (c *cRunbook) CaptureCDPStart(name string) {
-	// FIXME: not implemented
+	const cdpNewDsn = "chrome://new"
+	if v, ok := c.runners[name]; ok {
+		c.setRunner(name, v)
+	} else {
+		c.setRunner(name, cdpNewDsn)
+	}
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := yaml.MapSlice{
+		{Key: name, Value: yaml.MapSlice{
+			{Key: "actions", Value: []any{}},
+		}},
+	}
+	r.Steps = append(r.Steps, step)
+	r.currentCDPActionIndex = -1
+	r.currentCDPResponses = nil
 }
+
 func (c *cRunbook) CaptureCDPAction(a runn.CDPAction) {
-	// FIXME: not implemented
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	var action any
+	switch {
+	case len(a.Args) == 0:
+		action = a.Fn
+	case len(a.Args) == 1:
+		for _, v := range a.Args {
+			if s, ok := v.(string); ok {
+				action = yaml.MapSlice{{Key: a.Fn, Value: s}}
+			}
+		}
+	}
+	if action == nil {
+		var keys []string
+		for k := range a.Args {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		args := yaml.MapSlice{}
+		for _, k := range keys {
+			args = append(args, yaml.MapItem{Key: k, Value: a.Args[k]})
+		}
+		action = yaml.MapSlice{{Key: a.Fn, Value: args}}
+	}
+	step := r.latestStep()
+	cdp, ok := step[0].Value.(yaml.MapSlice)
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to get step[0].Value: %s", step[0].Value))
+		return
+	}
+	as, ok := cdp[0].Value.([]any)
+	if !ok {
+		c.errs = errors.Join(c.errs, fmt.Errorf("failed to get actions: %s", cdp[0].Value))
+		return
+	}
+	cdp[0].Value = append(as, action)
+	step[0].Value = cdp
+	r.replaceLatestStep(step)
+	r.currentCDPActionIndex++
 }
+
 func (c *cRunbook) CaptureCDPResponse(a runn.CDPAction, res map[string]any) {
-	// FIXME: not implemented
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	var keys []string
+	for k := range res {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r.currentCDPResponses = append(r.currentCDPResponses, cdpResponse{
+			actionIndex: r.currentCDPActionIndex,
+			key:         k,
+			value:       res[k],
+		})
+	}
 }
+
 func (c *cRunbook) CaptureCDPEnd(name string) {
-	// FIXME: not implemented
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	// Only the value recorded by the last action is stored as `current.<key>`, so the values recorded by the previous actions are left as comments.
+	last := map[string]int{}
+	for i, res := range r.currentCDPResponses {
+		last[res.key] = i
+	}
+	var (
+		cond     []string
+		comments []string
+	)
+	for i, res := range r.currentCDPResponses {
+		var rc string
+		switch v := res.value.(type) {
+		case string:
+			rc = fmt.Sprintf("current.%s == %#v", res.key, v)
+		case map[string]string:
+			b, err := json.Marshal(v)
+			if err != nil {
+				c.errs = errors.Join(c.errs, fmt.Errorf("failed to json.Marshal: %w", err))
+				return
+			}
+			rc = fmt.Sprintf("compare(current.%s, %s)", res.key, string(b))
+		case []byte:
+			comments = append(comments, fmt.Sprintf(" actions[%d]: current.%s is %d bytes", res.actionIndex, res.key, len(v)))
+			continue
+		default:
+			comments = append(comments, fmt.Sprintf(" actions[%d]: current.%s == %v", res.actionIndex, res.key, v))
+			continue
+		}
+		if last[res.key] != i {
+			comments = append(comments, fmt.Sprintf(" actions[%d]: %s", res.actionIndex, rc))
+			continue
+		}
+		cond = append(cond, rc)
+	}
+	step := r.latestStep()
+	if len(cond) > 0 {
+		step = append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", strings.Join(cond, "\n&& "))})
+		r.replaceLatestStep(step)
+	}
+	if len(comments) > 0 {
+		if r.comments == nil {
+			r.comments = yaml.CommentMap{}
+		}
+		p := fmt.Sprintf("$.steps[%d]", len(r.Steps)-1)
+		r.comments[p] = append(r.comments[p], yaml.HeadComment(append([]string{" Captured responses:"}, comments...)...))
+	}
+	r.currentCDPActionIndex = 0
+	r.currentCDPResponses = nil
 }
 
 func (c *cRunbook) CaptureSSHCommand(command string) {
@@ -461,6 +601,105 @@ func (c *cRunbook) CaptureExecStderr(stderr string) {
 	r.currentExecTestCond = nil
 }
 
+func (c *cRunbook) CaptureWSStart(name string) {
+	const dummyDsn = "[THIS IS WebSocket RUNNER]"
+	if v, ok := c.runners[name]; ok {
+		c.setRunner(name, v)
+	} else {
+		c.setRunner(name, dummyDsn)
+	}
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	step := yaml.MapSlice{
+		{Key: name, Value: yaml.MapSlice{}},
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+func (c *cRunbook) CaptureWSSendMessage(name string, m *runn.WSMessage) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	var send yaml.MapSlice
+	switch m.Type {
+	case runn.WSMessageBinary:
+		b := make([]any, 0, len(m.Data))
+		for _, v := range m.Data {
+			b = append(b, v)
+		}
+		send = yaml.MapSlice{{Key: "binary", Value: b}}
+	default:
+		if v, ok := wsJSONBody(m.Data); ok {
+			send = yaml.MapSlice{{Key: "json", Value: v}}
+			break
+		}
+		send = yaml.MapSlice{{Key: "text", Value: string(m.Data)}}
+	}
+	step := r.latestStep()
+	step = appendWSOp(step, yaml.MapItem{Key: "send", Value: send})
+	r.replaceLatestStep(step)
+}
+
+func (c *cRunbook) CaptureWSReceiveMessage(name string, m *runn.WSMessage) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	var cond string
+	switch m.Type {
+	case runn.WSMessageBinary:
+		cond = fmt.Sprintf("current.res.messages[%d].type == %q", r.currentWSReceiveCount, m.Type)
+	default:
+		if v, ok := wsJSONBody(m.Data); ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				c.errs = errors.Join(c.errs, fmt.Errorf("failed to json.Marshal: %w", err))
+				return
+			}
+			cond = fmt.Sprintf("compare(current.res.messages[%d].body, %s)", r.currentWSReceiveCount, string(b))
+		} else {
+			cond = fmt.Sprintf("current.res.messages[%d].data == %#v", r.currentWSReceiveCount, string(m.Data))
+		}
+	}
+	r.currentWSTestCond = append(r.currentWSTestCond, cond)
+	r.currentWSReceiveCount++
+	step := r.latestStep()
+	step = appendWSOp(step, yaml.MapItem{Key: "receive", Value: yaml.MapSlice{{Key: "count", Value: r.currentWSReceiveCount}}})
+	r.replaceLatestStep(step)
+}
+
+func (c *cRunbook) CaptureWSClose(name string, code int, reason string) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	cl := yaml.MapSlice{{Key: "code", Value: code}}
+	if reason != "" {
+		cl = append(cl, yaml.MapItem{Key: "reason", Value: reason})
+	}
+	step := r.latestStep()
+	step = appendWSOp(step, yaml.MapItem{Key: "close", Value: cl})
+	r.replaceLatestStep(step)
+}
+
+func (c *cRunbook) CaptureWSEnd(name string) {
+	r := c.currentRunbook()
+	if r == nil {
+		return
+	}
+	if len(r.currentWSTestCond) > 0 {
+		cond := fmt.Sprintf("len(current.res.messages) == %d", r.currentWSReceiveCount)
+		step := r.latestStep()
+		step = append(step, yaml.MapItem{Key: "test", Value: fmt.Sprintf("%s\n", strings.Join(append([]string{cond}, r.currentWSTestCond...), "\n&& "))})
+		r.replaceLatestStep(step)
+	}
+	r.currentWSTestCond = nil
+	r.currentWSReceiveCount = 0
+}
+
 func (c *cRunbook) SetCurrentTrails(trs runn.Trails) {
 	c.currentTrails = trs
 }
@@ -568,6 +807,9 @@ func (c *cRunbook) writeRunbook(trs runn.Trails, bookPath string) {
 		yaml.UseSingleQuote(false),
 		yaml.UseLiteralStyleIfMultiline(true),
 	}
+	if len(r.comments) > 0 {
+		encOpts = append(encOpts, yaml.WithComment(r.comments))
+	}
 	b, err := yaml.MarshalWithOptions(r, encOpts...)
 	if err != nil {
 		c.errs = errors.Join(c.errs, fmt.Errorf("failed to yaml.Marshal: %w", err))
@@ -609,6 +851,36 @@ func replaceHeadersAndMessages(step, hb yaml.MapSlice) yaml.MapSlice {
 	return step
 }
 
+// wsJSONBody returns the decoded value if the data is a JSON object or array.
+func wsJSONBody(data []byte) (any, bool) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+		return v, true
+	}
+	return nil, false
+}
+
+// appendWSOp appends the operation to the WebSocket step, or replaces the operation if it already exists.
+func appendWSOp(step yaml.MapSlice, op yaml.MapItem) yaml.MapSlice {
+	s, ok := step[0].Value.(yaml.MapSlice)
+	if !ok {
+		return step
+	}
+	for i := range s {
+		if s[i].Key == op.Key {
+			s[i].Value = op.Value
+			step[0].Value = s
+			return step
+		}
+	}
+	step[0].Value = append(s, op)
+	return step
+}
+
 // copy from net/http/httputil.
 func drainBody(b io.ReadCloser) (r1, r2 io.ReadCloser, err error) {
 	if b == nil || b == http.NoBody {
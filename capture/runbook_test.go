@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"testing"
 
@@ -24,6 +25,7 @@ func TestRunbook(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "include_main.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "ws.yml")},
 	}
 	for _, tt := range tests {
 		t.Run(filepath.Base(tt.book), func(t *testing.T) {
@@ -31,6 +33,8 @@ func TestRunbook(t *testing.T) {
 			t.Cleanup(cancel)
 			dir := t.TempDir()
 			hs := testutil.HTTPServer(t)
+			ws := testutil.WSServer(t)
+			t.Setenv("TEST_WS_ENDPOINT", strings.Replace(ws.URL, "http://", "ws://", 1))
 			gs := testutil.GRPCServer(t, false, false)
 			db, _ := testutil.SQLite(t)
 			opts := []runn.Option{
@@ -63,6 +67,55 @@ func TestRunbook(t *testing.T) {
 	}
 }
 
+func TestRunbookCDP(t *testing.T) {
+	// Capture CDP actions without Chrome
+	book := filepath.Join(testutil.Testdata(), "book", "cdp.yml")
+	dir := t.TempDir()
+	c := Runbook(dir)
+	trs := runn.Trails{{Type: runn.TrailTypeRunbook, RunbookPath: book}}
+	c.SetCurrentTrails(trs)
+	c.CaptureStart(trs, book, "")
+	actions := []struct {
+		action runn.CDPAction
+		res    map[string]any
+	}{
+		{runn.CDPAction{Fn: "navigate", Args: map[string]any{"url": "http://127.0.0.1:8080/form"}}, nil},
+		{runn.CDPAction{Fn: "sendKeys", Args: map[string]any{"sel": "input[name=username]", "value": "alice"}}, nil},
+		{runn.CDPAction{Fn: "text", Args: map[string]any{"sel": "h1"}}, map[string]any{"text": "Hello"}},
+		{runn.CDPAction{Fn: "attributes", Args: map[string]any{"sel": "h1"}}, map[string]any{"attrs": map[string]string{"class": "title"}}},
+		{runn.CDPAction{Fn: "latestTab", Args: map[string]any{}}, nil},
+		{runn.CDPAction{Fn: "text", Args: map[string]any{"sel": "h2"}}, map[string]any{"text": "World"}},
+		{runn.CDPAction{Fn: "screenshot", Args: map[string]any{}}, map[string]any{"png": []byte("dummy")}},
+	}
+	c.CaptureCDPStart("cc")
+	for _, a := range actions {
+		c.CaptureCDPAction(a.action)
+		if a.res != nil {
+			c.CaptureCDPResponse(a.action, a.res)
+		}
+	}
+	c.CaptureCDPEnd("cc")
+	c.CaptureResult(trs, &runn.RunResult{Path: book})
+	if err := c.Errs(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := golden.Txtar(t, dir)
+	f := "cdp.yml.runbook"
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		golden.Update(t, testutil.Testdata(), f, got)
+		return
+	}
+	if diff := golden.Diff(t, testutil.Testdata(), f, got); diff != "" {
+		t.Error(diff)
+	}
+
+	// The captured runbook is loadable
+	if _, err := runn.New(runn.Book(filepath.Join(dir, capturedFilename(book))), runn.Scopes(runn.ScopeAllowReadParent)); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestRunnable(t *testing.T) {
 	tests := []struct {
 		book string
@@ -72,12 +125,15 @@ func TestRunnable(t *testing.T) {
 		{filepath.Join(testutil.Testdata(), "book", "grpc.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "db.yml")},
 		{filepath.Join(testutil.Testdata(), "book", "exec.yml")},
+		{filepath.Join(testutil.Testdata(), "book", "ws.yml")},
 	}
 	for _, tt := range tests {
 		t.Run(filepath.Base(tt.book), func(t *testing.T) {
 			ctx, cancel := donegroup.WithCancel(context.Background())
 			t.Cleanup(cancel)
 			dir := t.TempDir()
+			ws := testutil.WSServer(t)
+			t.Setenv("TEST_WS_ENDPOINT", strings.Replace(ws.URL, "http://", "ws://", 1))
 			{
 				hs := testutil.HTTPServer(t)
 				gs := testutil.GRPCServer(t, false, false)
@@ -1,10 +1,12 @@
 package runn
 
 import (
+	"errors"
 	"net/http"
 
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type Capturer interface {
@@ -44,12 +46,50 @@ type Capturer interface {
 	CaptureExecStdout(stdout string)
 	CaptureExecStderr(stderr string)
 
+	CaptureWSStart(name string)
+	CaptureWSSendMessage(name string, m *WSMessage)
+	CaptureWSReceiveMessage(name string, m *WSMessage)
+	CaptureWSClose(name string, code int, reason string)
+	CaptureWSEnd(name string)
+
 	SetCurrentTrails(trs Trails)
 	Errs() error
 }
 
+// operatorCapturer is implemented by capturers that keep the state of each operator ( e.g. the current trails ).
+// Each operator captures with the Capturer returned by forOperator instead.
+type operatorCapturer interface {
+	forOperator() Capturer
+}
+
+// runNEndCapturer is implemented by capturers that output the captured results when all runbooks of the run have finished.
+type runNEndCapturer interface {
+	captureRunNEnd() error
+}
+
 type capturers []Capturer
 
+func (cs capturers) forOperator() capturers { //nostyle:recvtype
+	ocs := make(capturers, 0, len(cs))
+	for _, c := range cs {
+		if oc, ok := c.(operatorCapturer); ok {
+			c = oc.forOperator()
+		}
+		ocs = append(ocs, c)
+	}
+	return ocs
+}
+
+func (cs capturers) captureRunNEnd() error { //nostyle:recvtype
+	var err error
+	for _, c := range cs {
+		if ec, ok := c.(runNEndCapturer); ok {
+			err = errors.Join(err, ec.captureRunNEnd())
+		}
+	}
+	return err
+}
+
 func (cs capturers) captureStart(trs Trails, bookPath, desc string) { //nostyle:recvtype
 	for _, c := range cs {
 		c.CaptureStart(trs, bookPath, desc)
@@ -103,8 +143,18 @@ func (cs capturers) captureGRPCRequestMessage(m map[string]any) { //nostyle:recv
 	}
 }
 
-func (cs capturers) captureGRPCResponseStatus(s *status.Status) { //nostyle:recvtype
+// grpcStatusDetailsCapturer is implemented by capturers that decode the details of the gRPC status
+// with the message types of the gRPC runner.
+type grpcStatusDetailsCapturer interface {
+	captureGRPCResponseStatusWithResolver(s *status.Status, r *grpcDetailsResolver)
+}
+
+func (cs capturers) captureGRPCResponseStatus(s *status.Status, mds map[string]protoreflect.MethodDescriptor) { //nostyle:recvtype
 	for _, c := range cs {
+		if dc, ok := c.(grpcStatusDetailsCapturer); ok {
+			dc.captureGRPCResponseStatusWithResolver(s, newGRPCDetailsResolver(mds))
+			continue
+		}
 		c.CaptureGRPCResponseStatus(s)
 	}
 }
@@ -217,6 +267,36 @@ func (cs capturers) captureExecStderr(stderr string) { //nostyle:recvtype
 	}
 }
 
+func (cs capturers) captureWSStart(name string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWSStart(name)
+	}
+}
+
+func (cs capturers) captureWSSendMessage(name string, m *WSMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWSSendMessage(name, m)
+	}
+}
+
+func (cs capturers) captureWSReceiveMessage(name string, m *WSMessage) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWSReceiveMessage(name, m)
+	}
+}
+
+func (cs capturers) captureWSClose(name string, code int, reason string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWSClose(name, code, reason)
+	}
+}
+
+func (cs capturers) captureWSEnd(name string) { //nostyle:recvtype
+	for _, c := range cs {
+		c.CaptureWSEnd(name)
+	}
+}
+
 func (cs capturers) setCurrentTrails(trs Trails) { //nostyle:recvtype
 	for _, c := range cs {
 		c.SetCurrentTrails(trs)
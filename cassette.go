@@ -0,0 +1,667 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/maskedio"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const cassetteExt = ".cassette.json"
+
+// cassetteCredentialHeaders are request headers that are not recorded because they carry credentials.
+var cassetteCredentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Amz-Security-Token"}
+
+// cassettes records HTTP and gRPC exchanges to files or replays them from files.
+// A cassette file is created per root runbook, and exchanges in it are keyed by the trail of the step (e.g. `<runbook id>?step=1&step=0`).
+// Recorded exchanges are kept in memory and saved by flush when the root runbook finishes.
+type cassettes struct {
+	dir    string
+	replay bool
+	books  map[string]*cassette
+	mu     sync.Mutex
+}
+
+type cassette struct {
+	ID           string            `json:"id"`
+	Interactions []*interaction    `json:"interactions"`
+	Descriptors  map[string][]byte `json:"descriptors,omitempty"`
+
+	path     string
+	consumed map[*interaction]struct{}
+	dirty    bool
+}
+
+type interaction struct {
+	Key    string           `json:"key"`
+	Runner string           `json:"runner"`
+	HTTP   *httpInteraction `json:"http,omitempty"`
+	GRPC   *grpcInteraction `json:"grpc,omitempty"`
+
+	mr *maskedio.Rule // mask rule of the operator applied when the cassette is saved
+}
+
+type httpInteraction struct {
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers,omitempty"`
+	RequestBody     []byte      `json:"request_body,omitempty"`
+	Status          int         `json:"status"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    []byte      `json:"response_body,omitempty"`
+}
+
+type grpcInteraction struct {
+	Method        string            `json:"method"`
+	Requests      []json.RawMessage `json:"requests,omitempty"`
+	Headers       metadata.MD       `json:"headers,omitempty"`
+	Responses     []json.RawMessage `json:"responses,omitempty"`
+	Trailers      metadata.MD       `json:"trailers,omitempty"`
+	StatusCode    codes.Code        `json:"status_code"`
+	StatusMessage string            `json:"status_message,omitempty"`
+}
+
+type cassetteKeyCtxKey struct{}
+
+func newCassettes(dir string, replay bool) *cassettes {
+	return &cassettes{
+		dir:    dir,
+		replay: replay,
+		books:  map[string]*cassette{},
+	}
+}
+
+// withCassetteKey sets the key of exchanges for gRPC interceptors.
+func withCassetteKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, cassetteKeyCtxKey{}, key)
+}
+
+func cassetteKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(cassetteKeyCtxKey{}).(string)
+	return key, ok
+}
+
+// cassette returns the cassette of the root runbook. cs.mu must be locked.
+func (cs *cassettes) cassette(key string) (*cassette, error) {
+	id, _, _ := strings.Cut(key, "?")
+	if c, ok := cs.books[id]; ok {
+		return c, nil
+	}
+	c := &cassette{
+		ID:          id,
+		Descriptors: map[string][]byte{},
+		path:        filepath.Join(cs.dir, id+cassetteExt),
+		consumed:    map[*interaction]struct{}{},
+	}
+	if cs.replay {
+		b, err := os.ReadFile(c.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cassette: %w", err)
+		}
+		if err := json.Unmarshal(b, c); err != nil {
+			return nil, fmt.Errorf("failed to load cassette: %s: %w", c.path, err)
+		}
+	}
+	cs.books[id] = c
+	return c, nil
+}
+
+// record appends the interaction to the cassette.
+// The interaction can be changed until the cassette is flushed ( e.g. messages of streaming RPCs ).
+func (cs *cassettes) record(i *interaction) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, err := cs.cassette(i.Key)
+	if err != nil {
+		return err
+	}
+	c.Interactions = append(c.Interactions, i)
+	c.dirty = true
+	return nil
+}
+
+// flush saves the cassette of the root runbook if it has been changed.
+func (cs *cassettes) flush(key string) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	id, _, _ := strings.Cut(key, "?")
+	c, ok := cs.books[id]
+	if !ok || !c.dirty {
+		return nil
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// next returns the first interaction that has not been replayed yet.
+func (cs *cassettes) next(key, runner string, match func(*interaction) bool) (*interaction, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, err := cs.cassette(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range c.Interactions {
+		if _, ok := c.consumed[i]; ok {
+			continue
+		}
+		if i.Key != key || i.Runner != runner {
+			continue
+		}
+		if !match(i) {
+			return nil, fmt.Errorf("unmatched request for the recorded exchange on %s", key)
+		}
+		c.consumed[i] = struct{}{}
+		return i, nil
+	}
+	return nil, fmt.Errorf("no recorded exchange on %s", key)
+}
+
+func (c *cassette) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return err
+	}
+	mc := &cassette{
+		ID:          c.ID,
+		Descriptors: c.Descriptors,
+	}
+	for _, i := range c.Interactions {
+		mc.Interactions = append(mc.Interactions, i.masked())
+	}
+	b, err := json.MarshalIndent(mc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, b, 0600)
+}
+
+// masked returns the interaction whose HTTP exchange is masked by the mask rule.
+// Secrets that are known only after the exchange ( e.g. the access token of OAuth2 ) are also masked because it is applied when the cassette is saved.
+func (i *interaction) masked() *interaction {
+	if i.HTTP == nil || i.mr == nil {
+		return i
+	}
+	mask := func(h http.Header) http.Header {
+		if h == nil {
+			return nil
+		}
+		mh := http.Header{}
+		for k, v := range h {
+			for _, vv := range v {
+				mh.Add(k, i.mr.Mask(vv))
+			}
+		}
+		return mh
+	}
+	return &interaction{
+		Key:    i.Key,
+		Runner: i.Runner,
+		HTTP: &httpInteraction{
+			Method:          i.HTTP.Method,
+			URL:             i.mr.Mask(i.HTTP.URL),
+			RequestHeaders:  mask(i.HTTP.RequestHeaders),
+			RequestBody:     maskBytes(i.mr, i.HTTP.RequestBody),
+			Status:          i.HTTP.Status,
+			ResponseHeaders: mask(i.HTTP.ResponseHeaders),
+			ResponseBody:    maskBytes(i.mr, i.HTTP.ResponseBody),
+		},
+	}
+}
+
+func maskBytes(mr *maskedio.Rule, b []byte) []byte {
+	if mr == nil || b == nil {
+		return b
+	}
+	return []byte(mr.Mask(string(b)))
+}
+
+// recordDescriptors saves the file descriptors of the methods so that they can be resolved without reflection in replay mode.
+func (cs *cassettes) recordDescriptors(key, runner string, mds map[string]protoreflect.MethodDescriptor) error {
+	fds := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]struct{}{}
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if _, ok := seen[fd.Path()]; ok {
+			return
+		}
+		seen[fd.Path()] = struct{}{}
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	keys := make([]string, 0, len(mds))
+	for k := range mds {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(mds[k].ParentFile())
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(fds)
+	if err != nil {
+		return err
+	}
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	c, err := cs.cassette(key)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(c.Descriptors[runner], b) {
+		return nil
+	}
+	c.Descriptors[runner] = b
+	c.dirty = true
+	return nil
+}
+
+// methodDescriptors returns the method descriptors recorded by recordDescriptors.
+func (cs *cassettes) methodDescriptors(key, runner string) (map[string]protoreflect.MethodDescriptor, error) {
+	cs.mu.Lock()
+	c, err := cs.cassette(key)
+	cs.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	b, ok := c.Descriptors[runner]
+	if !ok {
+		return nil, fmt.Errorf("no recorded descriptors of %s", runner)
+	}
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, err
+	}
+	mds := map[string]protoreflect.MethodDescriptor{}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			svc := fd.Services().Get(i)
+			for j := 0; j < svc.Methods().Len(); j++ {
+				m := svc.Methods().Get(j)
+				mds[fmt.Sprintf("%s/%s", svc.FullName(), m.Name())] = m
+			}
+		}
+		return true
+	})
+	return mds, nil
+}
+
+// cassetteTransport records or replays HTTP exchanges of the step.
+// Credential headers are not recorded, and values matched by the mask rule are masked in the cassette file.
+type cassetteTransport struct {
+	cs     *cassettes
+	key    string
+	runner string
+	mr     *maskedio.Rule
+	next   http.RoundTripper
+}
+
+func (cs *cassettes) transport(next http.RoundTripper, key, runner string, mr *maskedio.Rule) http.RoundTripper {
+	return &cassetteTransport{
+		cs:     cs,
+		key:    key,
+		runner: runner,
+		mr:     mr,
+		next:   next,
+	}
+}
+
+func (t *cassetteTransport) mask(in string) string {
+	if t.mr == nil {
+		return in
+	}
+	return t.mr.Mask(in)
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		reqBody = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	if t.cs.replay {
+		i, err := t.cs.next(t.key, t.runner, func(i *interaction) bool {
+			if i.HTTP == nil || i.HTTP.Method != req.Method {
+				return false
+			}
+			// Compare without the host so that cassettes can be replayed against another endpoint
+			u, err := url.Parse(i.HTTP.URL)
+			if err != nil {
+				return false
+			}
+			// The recorded exchange is masked, so the request is compared after masking
+			if u.RequestURI() != t.mask(req.URL.RequestURI()) {
+				return false
+			}
+			return bytes.Equal(cassetteRequestBody(i.HTTP.RequestHeaders, i.HTTP.RequestBody), cassetteRequestBody(req.Header, maskBytes(t.mr, reqBody)))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.String(), err)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.HTTP.Status, http.StatusText(i.HTTP.Status)),
+			StatusCode:    i.HTTP.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        i.HTTP.ResponseHeaders.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(i.HTTP.ResponseBody)),
+			ContentLength: int64(len(i.HTTP.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	reqHeaders := req.Header.Clone()
+	for _, h := range cassetteCredentialHeaders {
+		reqHeaders.Del(h)
+	}
+	hi := &httpInteraction{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		RequestHeaders:  reqHeaders,
+		RequestBody:     reqBody,
+		Status:          res.StatusCode,
+		ResponseHeaders: res.Header.Clone(),
+	}
+	if err := t.cs.record(&interaction{
+		Key:    t.key,
+		Runner: t.runner,
+		HTTP:   hi,
+		mr:     t.mr,
+	}); err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	// The response body is recorded as it is read so that streaming responses ( e.g. Server-Sent Events ) are not blocked.
+	res.Body = &recordBody{ReadCloser: res.Body, cs: t.cs, hi: hi}
+	return res, nil
+}
+
+// recordBody records the response body read until it is closed.
+type recordBody struct {
+	io.ReadCloser
+	cs *cassettes
+	hi *httpInteraction
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.cs.mu.Lock()
+		b.hi.ResponseBody = append(b.hi.ResponseBody, p[:n]...)
+		b.cs.mu.Unlock()
+	}
+	return n, err
+}
+
+// cassetteRequestBody returns the request body to be compared.
+// The boundary of multipart/form-data is removed because it is generated randomly unless it is specified.
+func cassetteRequestBody(h http.Header, b []byte) []byte {
+	mt, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil || mt != MediaTypeMultipartFormData || params["boundary"] == "" {
+		return b
+	}
+	return bytes.ReplaceAll(b, []byte(params["boundary"]), nil)
+}
+
+// equalGRPCMessage returns whether the recorded message equals the message.
+func equalGRPCMessage(recorded json.RawMessage, m any) bool {
+	pm, ok := m.(proto.Message)
+	if !ok {
+		return false
+	}
+	rm := pm.ProtoReflect().New().Interface()
+	if err := protojson.Unmarshal(recorded, rm); err != nil {
+		return false
+	}
+	return proto.Equal(rm, pm)
+}
+
+// unaryInterceptor records or replays gRPC unary exchanges. Exchanges without the key (e.g. reflection) are passed through.
+func (cs *cassettes) unaryInterceptor(runner string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		key, ok := cassetteKeyFromContext(ctx)
+		if !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if cs.replay {
+			i, err := cs.next(key, runner, func(i *interaction) bool {
+				if i.GRPC == nil || i.GRPC.Method != method || len(i.GRPC.Requests) != 1 {
+					return false
+				}
+				return equalGRPCMessage(i.GRPC.Requests[0], req)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", method, err)
+			}
+			for _, opt := range opts {
+				switch o := opt.(type) {
+				case grpc.HeaderCallOption:
+					*o.HeaderAddr = i.GRPC.Headers.Copy()
+				case grpc.TrailerCallOption:
+					*o.TrailerAddr = i.GRPC.Trailers.Copy()
+				}
+			}
+			if i.GRPC.StatusCode != codes.OK {
+				return status.Error(i.GRPC.StatusCode, i.GRPC.StatusMessage)
+			}
+			if len(i.GRPC.Responses) == 0 {
+				return fmt.Errorf("%s: no recorded response on %s", method, key)
+			}
+			m, ok := reply.(proto.Message)
+			if !ok {
+				return fmt.Errorf("invalid reply: %v", reply)
+			}
+			return protojson.Unmarshal(i.GRPC.Responses[0], m)
+		}
+
+		var h, t metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&h), grpc.Trailer(&t))...)
+		stat := status.Convert(err)
+		gi := &grpcInteraction{
+			Method:        method,
+			Headers:       h,
+			Trailers:      t,
+			StatusCode:    stat.Code(),
+			StatusMessage: stat.Message(),
+		}
+		if m, ok := req.(proto.Message); ok {
+			if b, err := protojson.Marshal(m); err == nil {
+				gi.Requests = append(gi.Requests, b)
+			}
+		}
+		if m, ok := reply.(proto.Message); ok && err == nil {
+			b, merr := protojson.Marshal(m)
+			if merr != nil {
+				return merr
+			}
+			gi.Responses = append(gi.Responses, b)
+		}
+		if rerr := cs.record(&interaction{Key: key, Runner: runner, GRPC: gi}); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+}
+
+// streamInterceptor records or replays gRPC streaming exchanges. Exchanges without the key (e.g. reflection) are passed through.
+func (cs *cassettes) streamInterceptor(runner string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		key, ok := cassetteKeyFromContext(ctx)
+		if !ok {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+		if cs.replay {
+			i, err := cs.next(key, runner, func(i *interaction) bool {
+				return i.GRPC != nil && i.GRPC.Method == method
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", method, err)
+			}
+			return &replayClientStream{ctx: ctx, key: key, gi: i.GRPC}, nil
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		i := &interaction{
+			Key:    key,
+			Runner: runner,
+			GRPC: &grpcInteraction{
+				Method: method,
+			},
+		}
+		if err := cs.record(i); err != nil {
+			return nil, err
+		}
+		return &recordClientStream{ClientStream: stream, cs: cs, i: i}, nil
+	}
+}
+
+type recordClientStream struct {
+	grpc.ClientStream
+	cs   *cassettes
+	i    *interaction
+	done bool
+}
+
+func (s *recordClientStream) SendMsg(m any) error {
+	if pm, ok := m.(proto.Message); ok {
+		if b, err := protojson.Marshal(pm); err == nil {
+			s.cs.mu.Lock()
+			s.i.GRPC.Requests = append(s.i.GRPC.Requests, b)
+			s.cs.mu.Unlock()
+		}
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *recordClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	s.cs.mu.Lock()
+	if s.done {
+		s.cs.mu.Unlock()
+		return err
+	}
+	switch {
+	case err == nil:
+		if pm, ok := m.(proto.Message); ok {
+			b, merr := protojson.Marshal(pm)
+			if merr != nil {
+				s.cs.mu.Unlock()
+				return merr
+			}
+			s.i.GRPC.Responses = append(s.i.GRPC.Responses, b)
+		}
+		if s.i.GRPC.Headers == nil {
+			if h, herr := s.ClientStream.Header(); herr == nil {
+				s.i.GRPC.Headers = h
+			}
+		}
+	default:
+		s.done = true
+		if !errors.Is(err, io.EOF) {
+			stat := status.Convert(err)
+			s.i.GRPC.StatusCode = stat.Code()
+			s.i.GRPC.StatusMessage = stat.Message()
+		}
+		if h, herr := s.ClientStream.Header(); herr == nil {
+			s.i.GRPC.Headers = h
+		}
+		s.i.GRPC.Trailers = s.ClientStream.Trailer()
+	}
+	s.cs.mu.Unlock()
+	return err
+}
+
+type replayClientStream struct {
+	ctx  context.Context
+	key  string
+	gi   *grpcInteraction
+	idx  int
+	sent int
+	mu   sync.Mutex
+}
+
+func (s *replayClientStream) Header() (metadata.MD, error) {
+	return s.gi.Headers.Copy(), nil
+}
+
+func (s *replayClientStream) Trailer() metadata.MD {
+	return s.gi.Trailers.Copy()
+}
+
+func (s *replayClientStream) CloseSend() error {
+	return nil
+}
+
+func (s *replayClientStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg compares the message with the recorded request message because messages of streams are sent after the stream is created.
+func (s *replayClientStream) SendMsg(m any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent >= len(s.gi.Requests) || !equalGRPCMessage(s.gi.Requests[s.sent], m) {
+		return fmt.Errorf("%s: unmatched request message[%d] for the recorded exchange on %s", s.gi.Method, s.sent, s.key)
+	}
+	s.sent++
+	return nil
+}
+
+func (s *replayClientStream) RecvMsg(m any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idx < len(s.gi.Responses) {
+		pm, ok := m.(proto.Message)
+		if !ok {
+			return fmt.Errorf("invalid message: %v", m)
+		}
+		b := s.gi.Responses[s.idx]
+		s.idx++
+		return protojson.Unmarshal(b, pm)
+	}
+	if s.gi.StatusCode != codes.OK {
+		return status.Error(s.gi.StatusCode, s.gi.StatusMessage)
+	}
+	return io.EOF
+}
@@ -0,0 +1,234 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	book := "testdata/book/cassette.yml"
+	dir := t.TempDir()
+
+	t.Run("record", func(t *testing.T) {
+		o, err := Load(book, Scopes(ScopeAllowReadParent), Var("wantRequests", 1), Record(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.RunN(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if o.Result().HasFailure() {
+			t.Fatal("record failed")
+		}
+		got, err := filepath.Glob(filepath.Join(dir, "*"+cassetteExt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("got %v, want 1 cassette", got)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		o, err := Load(book, Scopes(ScopeAllowReadParent), Var("wantRequests", 0), Replay(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.RunN(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if o.Result().HasFailure() {
+			t.Error("replay failed")
+		}
+	})
+
+	for _, v := range []string{"username", "name"} {
+		t.Run("replay unmatched "+v, func(t *testing.T) {
+			o, err := Load(book, Scopes(ScopeAllowReadParent), Var("wantRequests", 0), Var(v, "bob"), Replay(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.RunN(ctx); err != nil {
+				t.Fatal(err)
+			}
+			r := o.Result()
+			if !r.HasFailure() {
+				t.Fatal("want failure")
+			}
+			if err := r.RunResults[0].Err; !strings.Contains(err.Error(), "unmatched request") {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+
+	t.Run("replay unmatched request", func(t *testing.T) {
+		empty := t.TempDir()
+		o, err := Load(book, Scopes(ScopeAllowReadParent), Var("wantRequests", 0), Replay(empty))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.RunN(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if !o.Result().HasFailure() {
+			t.Error("want failure")
+		}
+		got, err := filepath.Glob(filepath.Join(empty, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got %v, want no files in replay mode", got)
+		}
+	})
+}
+
+func TestCassettesFlush(t *testing.T) {
+	dir := t.TempDir()
+	cs := newCassettes(dir, false)
+	p := filepath.Join(dir, "abc"+cassetteExt)
+	for i := range 3 {
+		if err := cs.record(&interaction{Key: "abc?step=0", Runner: "req", HTTP: &httpInteraction{Method: http.MethodGet, URL: "/", Status: 200 + i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(p); err == nil {
+		t.Fatal("want the cassette not to be saved before flush")
+	}
+	if err := cs.flush("abc"); err != nil {
+		t.Fatal(err)
+	}
+	rs := newCassettes(dir, true)
+	if _, err := rs.next("abc?step=0", "req", func(*interaction) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(rs.books["abc"].Interactions); got != 3 {
+		t.Errorf("got %v\nwant %v", got, 3)
+	}
+	if err := cs.flush("unknown"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCassetteRequestBody(t *testing.T) {
+	body := func(boundary string) []byte {
+		return []byte("--" + boundary + "\r\nContent-Disposition: form-data; name=\"a\"\r\n\r\nb\r\n--" + boundary + "--\r\n")
+	}
+	header := func(ct string) http.Header {
+		return http.Header{"Content-Type": []string{ct}}
+	}
+	tests := []struct {
+		h1, h2 http.Header
+		b1, b2 []byte
+		want   bool
+	}{
+		{header("application/json"), header("application/json"), []byte(`{"a":"b"}`), []byte(`{"a":"b"}`), true},
+		{header("application/json"), header("application/json"), []byte(`{"a":"b"}`), []byte(`{"a":"c"}`), false},
+		{header("multipart/form-data; boundary=123"), header("multipart/form-data; boundary=456"), body("123"), body("456"), true},
+		{header("multipart/form-data; boundary=123"), header("multipart/form-data; boundary=456"), body("123"), bytes.Replace(body("456"), []byte("b\r\n"), []byte("c\r\n"), 1), false},
+	}
+	for _, tt := range tests {
+		got := bytes.Equal(cassetteRequestBody(tt.h1, tt.b1), cassetteRequestBody(tt.h2, tt.b2))
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestRecordWithAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *httpAuthConfig
+		secrets []string
+	}{
+		{
+			"basic",
+			&httpAuthConfig{Basic: &basicAuthConfig{Username: "alice", Password: "alicepassword"}},
+			[]string{"alicepassword", "Basic "},
+		},
+		{
+			"oauth2 with client secret in params",
+			&httpAuthConfig{OAuth2: &oauth2AuthConfig{ClientID: "client", ClientSecret: "clientsecret", AuthStyle: "params"}},
+			[]string{"clientsecret", "accesstoken", "Bearer "},
+		},
+		{
+			"oauth2 with client secret in header",
+			&httpAuthConfig{OAuth2: &oauth2AuthConfig{ClientID: "client", ClientSecret: "clientsecret", AuthStyle: "header"}},
+			[]string{"clientsecret", "accesstoken", "Basic ", "Bearer "},
+		},
+		{
+			"sigv4",
+			&httpAuthConfig{SigV4: &sigV4AuthConfig{Region: "us-east-1", Service: "execute-api", AccessKeyID: "accesskeyid", SecretAccessKey: "secretaccesskey", SessionToken: "sessiontoken"}},
+			[]string{"secretaccesskey", "sessiontoken", "Signature="},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			ts := httptest.NewServer(mux)
+			t.Cleanup(ts.Close)
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"access_token":"accesstoken","token_type":"Bearer","expires_in":3600}`))
+			})
+			mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			if tt.c.OAuth2 != nil {
+				tt.c.OAuth2.TokenURL = ts.URL + "/token"
+			}
+			dir := t.TempDir()
+			for _, replay := range []bool{false, true} {
+				o, err := New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				o.id = "auth"
+				r, err := newHTTPRunner("req", ts.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.auth, err = newHTTPAuth(tt.c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r.cassettes = newCassettes(dir, replay)
+				s := newStep(0, "step0", o, nil)
+				req := &httpRequest{path: "/users", method: http.MethodGet, headers: http.Header{"Cookie": []string{"session=sessionid"}}}
+				if err := r.run(ctx, req, s); err != nil {
+					t.Fatal(err)
+				}
+				res, ok := o.store.Latest()["res"].(map[string]any)
+				if !ok {
+					t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+				}
+				if got := res["status"]; got != http.StatusOK {
+					t.Errorf("got %v\nwant %v", got, http.StatusOK)
+				}
+				if replay {
+					continue
+				}
+				if err := r.cassettes.flush(s.runbookID()); err != nil {
+					t.Fatal(err)
+				}
+				b, err := os.ReadFile(filepath.Join(dir, "auth"+cassetteExt))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, secret := range append(tt.secrets, "sessionid") {
+					if bytes.Contains(b, []byte(secret)) {
+						t.Errorf("cassette contains %q:\n%s", secret, b)
+					}
+				}
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ type cdpRunner struct {
 	cancel        context.CancelFunc
 	store         map[string]any
 	opts          []chromedp.ExecAllocatorOption
+	proxy         *proxy
 	timeoutByStep time.Duration
 	mu            sync.Mutex
 	// operatorID - The id of the operator for which the runner is defined.
@@ -66,6 +67,26 @@ func newCDPRunner(name, remote string) (*cdpRunner, error) {
 	}, nil
 }
 
+func (rnr *cdpRunner) applyConfig(c *cdpRunnerConfig) error {
+	for n, v := range c.Flags {
+		rnr.opts = append(rnr.opts, chromedp.Flag(n, v))
+	}
+	if c.Proxy != "" {
+		p, err := newProxy(c.Proxy, false)
+		if err != nil {
+			return err
+		}
+		rnr.setProxy(p)
+	}
+	return nil
+}
+
+// setProxy sets the outbound proxy to the options to launch Chrome.
+func (rnr *cdpRunner) setProxy(p *proxy) {
+	rnr.opts = append(rnr.opts, p.chromedpOpts()...)
+	rnr.proxy = p
+}
+
 func (rnr *cdpRunner) Close() error {
 	rnr.mu.Lock()
 	defer rnr.mu.Unlock()
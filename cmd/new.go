@@ -27,8 +27,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/runn"
@@ -44,17 +46,25 @@ var newCmd = &cobra.Command{
 	Long:    `create new runbook or append step to runbook.`,
 	Aliases: []string{"append"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if flgs.FromPostman != "" {
+			if len(args) > 0 || flgs.HAR != "" || flgs.FromOpenAPI != "" {
+				return errors.New("--from-postman cannot be used with --har, --from-openapi or commands")
+			}
+			return importPostman(flgs.FromPostman)
+		}
 		var (
 			o   *os.File
 			err error
 			al  [][]string
 		)
-		if len(args) == 0 {
+		interactive := false
+		if len(args) == 0 && flgs.HAR == "" && flgs.FromOpenAPI == "" {
 			if isatty.IsTerminal(os.Stdin.Fd()) {
-				return errors.New("interactive mode is planned, but not yet implemented")
+				interactive = true
+			} else {
+				al = argsListFromStdin(os.Stdin)
 			}
-			al = argsListFromStdin(os.Stdin)
-		} else {
+		} else if len(args) > 0 {
 			al = [][]string{args}
 		}
 		ctx := context.Background()
@@ -80,6 +90,29 @@ var newCmd = &cobra.Command{
 				return err
 			}
 		}
+		if flgs.HAR != "" {
+			f, err := os.Open(filepath.Clean(flgs.HAR))
+			if err != nil {
+				return err
+			}
+			if err := rb.AppendHAR(f, runn.HARHost(flgs.HARHosts...), runn.HARContentType(flgs.HARContentTypes...), runn.HARVars(flgs.HARVars)); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+		if flgs.FromOpenAPI != "" {
+			if err := appendOpenAPI3(rb, flgs.FromOpenAPI); err != nil {
+				return err
+			}
+		}
+		if interactive {
+			if err := appendStepsInteractively(ctx, rb, ask); err != nil {
+				return err
+			}
+		}
 		if flgs.Out == "" {
 			o = os.Stdout
 		} else {
@@ -125,6 +158,103 @@ func init() {
 	newCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
+	newCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
+	newCmd.Flags().StringVarP(&flgs.HAR, "har", "", "", flgs.Usage("HAR"))
+	newCmd.Flags().StringSliceVarP(&flgs.HARHosts, "har-host", "", []string{}, flgs.Usage("HARHosts"))
+	newCmd.Flags().StringSliceVarP(&flgs.HARContentTypes, "har-content-type", "", []string{}, flgs.Usage("HARContentTypes"))
+	newCmd.Flags().BoolVarP(&flgs.HARVars, "har-vars", "", false, flgs.Usage("HARVars"))
+	newCmd.Flags().StringVarP(&flgs.FromOpenAPI, "from-openapi", "", "", flgs.Usage("FromOpenAPI"))
+	newCmd.Flags().StringVarP(&flgs.FromPostman, "from-postman", "", "", flgs.Usage("FromPostman"))
+	newCmd.Flags().StringSliceVarP(&flgs.PostmanEnvironments, "postman-environment", "", []string{}, flgs.Usage("PostmanEnvironments"))
+}
+
+// importPostman writes runbooks converted from the Postman collection.
+// When --out is set, it is the directory to write runbooks to. Otherwise runbooks are written to STDOUT.
+func importPostman(p string) error {
+	if flgs.AndRun {
+		return errors.New("--and-run cannot be used with --from-postman")
+	}
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return err
+	}
+	rbs, err := runn.ParsePostmanCollection(f, runn.PostmanEnvironment(flgs.PostmanEnvironments...))
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	paths := slices.Sorted(maps.Keys(rbs))
+	for i, rp := range paths {
+		rb := rbs[rp]
+		if flgs.Desc != "" {
+			rb.Desc = fmt.Sprintf("%s %s", flgs.Desc, rb.Desc)
+		}
+		if flgs.Out == "" {
+			if i > 0 {
+				_, _ = fmt.Fprintln(os.Stdout, "---")
+			}
+			if err := rb.Encode(os.Stdout); err != nil {
+				return err
+			}
+			continue
+		}
+		op := filepath.Join(flgs.Out, filepath.FromSlash(rp))
+		if err := os.MkdirAll(filepath.Dir(op), 0755); err != nil { //nolint:gosec
+			return err
+		}
+		o, err := os.Create(filepath.Clean(op))
+		if err != nil {
+			return err
+		}
+		if err := rb.Encode(o); err != nil {
+			_ = o.Close()
+			return err
+		}
+		if err := o.Close(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", op)
+	}
+	return nil
+}
+
+// appendOpenAPI3 appends steps generated from the OpenAPI v3 document.
+// The path of the document in the runbook is relative to the output runbook.
+func appendOpenAPI3(rb interface {
+	AppendOpenAPI3(in io.Reader, spec string) error
+}, p string) error {
+	spec := p
+	switch {
+	case flgs.Out != "":
+		ap, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		ao, err := filepath.Abs(flgs.Out)
+		if err != nil {
+			return err
+		}
+		if rp, err := filepath.Rel(filepath.Dir(ao), ap); err == nil {
+			spec = rp
+		} else {
+			spec = ap
+		}
+	case flgs.AndRun:
+		// The runbook is run in a temporary directory
+		ap, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		spec = ap
+	}
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return rb.AppendOpenAPI3(f, spec)
 }
 
 func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) error {
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elk-language/go-prompt"
+	pstrings "github.com/elk-language/go-prompt/strings"
+	"github.com/goccy/go-yaml"
+	"github.com/jhump/protoreflect/v2/grpcreflect"
+	"github.com/k1LoW/runn"
+	"github.com/mattn/go-shellwords"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	interactiveRunnerHTTP = "http"
+	interactiveRunnerGRPC = "grpc"
+	interactiveRunnerExec = "exec"
+)
+
+var httpMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodHead,
+	http.MethodOptions,
+}
+
+// askFunc prompts with the message and returns the input or the default value.
+type askFunc func(msg, defaultValue string, suggests []prompt.Suggest) string
+
+// appendStepsInteractively prompts for steps using ask, runs them if requested and appends them to the runbook.
+func appendStepsInteractively(ctx context.Context, rb interface{ AppendStep(in ...string) error }, ask askFunc) error {
+	ops, err := openAPI3Operations(ctx, flgs.HTTPOpenApi3s)
+	if err != nil {
+		return err
+	}
+	var (
+		endpoint string
+		addr     string
+	)
+	for {
+		typ := ask("Runner type (empty to finish)", "", []prompt.Suggest{
+			{Text: interactiveRunnerHTTP, Description: "HTTP request"},
+			{Text: interactiveRunnerGRPC, Description: "gRPC request"},
+			{Text: interactiveRunnerExec, Description: "command execution"},
+		})
+		var args []string
+		switch typ {
+		case "":
+			return nil
+		case interactiveRunnerHTTP:
+			endpoint = ask("Endpoint", endpoint, nil)
+			method := strings.ToUpper(ask("Method", http.MethodGet, toSuggests(httpMethods)))
+			p := ask("Path", "/", pathSuggests(ops, method))
+			var body string
+			if method != http.MethodGet && method != http.MethodHead {
+				body = ask("Request body (JSON)", "", nil)
+			}
+			args = httpStepArgs(endpoint, method, p, body)
+		case interactiveRunnerGRPC:
+			addr = ask("Address", addr, nil)
+			methods, err := grpcMethods(ctx, addr)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to list methods using reflection: %s\n", err)
+			}
+			method := ask("Method", "", toSuggests(methods))
+			msg := ask("Message (JSON)", "{}", nil)
+			args = grpcStepArgs(addr, method, msg)
+		case interactiveRunnerExec:
+			args, err = shellwords.Parse(ask("Command", "", nil))
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+				continue
+			}
+			if len(args) == 0 {
+				continue
+			}
+		default:
+			_, _ = fmt.Fprintf(os.Stderr, "invalid runner type: %s\n", typ)
+			continue
+		}
+		if isYes(ask("Run the step now? (y/n)", "y", nil)) {
+			if err := runStep(ctx, args); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+			}
+		}
+		if !isYes(ask("Append the step to the runbook? (y/n)", "y", nil)) {
+			continue
+		}
+		if err := rb.AppendStep(args...); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		}
+	}
+}
+
+// ask prompts with the message and returns the input or the default value.
+func ask(msg, defaultValue string, suggests []prompt.Suggest) string {
+	prefix := fmt.Sprintf("%s: ", msg)
+	if defaultValue != "" {
+		prefix = fmt.Sprintf("%s [%s]: ", msg, defaultValue)
+	}
+	opts := []prompt.Option{
+		prompt.WithPrefix(prefix),
+	}
+	if len(suggests) > 0 {
+		opts = append(opts, prompt.WithCompleter(func(d prompt.Document) ([]prompt.Suggest, pstrings.RuneNumber, pstrings.RuneNumber) {
+			endIndex := d.CurrentRuneIndex()
+			w := d.TextBeforeCursor()
+			return prompt.FilterHasPrefix(suggests, w, true), 0, endIndex
+		}))
+	}
+	in := strings.TrimSpace(prompt.Input(opts...))
+	if in == "" {
+		return defaultValue
+	}
+	return in
+}
+
+// isYes returns whether the answer is yes ( `y` or `yes`, case-insensitive ).
+func isYes(in string) bool {
+	switch strings.ToLower(strings.TrimSpace(in)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// pathSuggests returns the paths of the operations of the method.
+func pathSuggests(ops []*openAPI3Operation, method string) []prompt.Suggest {
+	var paths []prompt.Suggest
+	for _, op := range ops {
+		if op.method == method {
+			paths = append(paths, prompt.Suggest{Text: op.path, Description: op.summary})
+		}
+	}
+	return paths
+}
+
+// httpStepArgs returns the curl command to create the HTTP step. The body is sent as JSON.
+func httpStepArgs(endpoint, method, path, body string) []string {
+	args := []string{"curl", "-X", method, strings.TrimSuffix(endpoint, "/") + path}
+	if body != "" {
+		args = append(args, "-H", "Content-Type: application/json", "-d", body)
+	}
+	return args
+}
+
+// grpcStepArgs returns the grpcurl command to create the gRPC step.
+func grpcStepArgs(addr, method, msg string) []string {
+	return []string{"grpcurl", "-d", msg, addr, method}
+}
+
+func toSuggests(texts []string) []prompt.Suggest {
+	var s []prompt.Suggest
+	for _, t := range texts {
+		s = append(s, prompt.Suggest{Text: t})
+	}
+	return s
+}
+
+// runStep runs the step created from the args and shows the request and response.
+func runStep(ctx context.Context, args []string) error {
+	const newf = "step.yml"
+	rb := runn.NewRunbook("")
+	if err := rb.AppendStep(args...); err != nil {
+		return err
+	}
+	td, err := os.MkdirTemp("", "runn")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(td)
+	b, err := yaml.Marshal(rb)
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(td, newf)
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		return err
+	}
+	opts := []runn.Option{
+		runn.Book(p),
+		runn.Capture(runn.NewDebugger(os.Stderr)),
+		runn.GRPCNoTLS(flgs.GRPCNoTLS),
+		runn.GRPCProtos(flgs.GRPCProtos),
+		runn.GRPCImportPaths(flgs.GRPCImportPaths),
+		runn.HTTPOpenApi3s(flgs.HTTPOpenApi3s),
+		runn.Scopes(runn.ScopeAllowReadParent, runn.ScopeAllowRunExec),
+	}
+	o, err := runn.New(opts...)
+	if err != nil {
+		return err
+	}
+	return o.Run(ctx)
+}
+
+type openAPI3Operation struct {
+	method  string
+	path    string
+	summary string
+}
+
+// openAPI3Operations returns operations of OpenAPI v3 documents ("path/to/spec.yml" or "key:path/to/spec.yml").
+func openAPI3Operations(ctx context.Context, specs []string) ([]*openAPI3Operation, error) {
+	var ops []*openAPI3Operation
+	for _, s := range specs {
+		p := specPath(s)
+		b, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return nil, err
+		}
+		doc, err := libopenapi.NewDocument(b)
+		if err != nil {
+			return nil, err
+		}
+		m, errs := doc.BuildV3Model()
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to build OpenAPI v3 model: %s: %v", p, errs)
+		}
+		if m.Model.Paths == nil {
+			continue
+		}
+		for pi := range orderedmap.Iterate(ctx, m.Model.Paths.PathItems) {
+			for op := range orderedmap.Iterate(ctx, pi.Value().GetOperations()) {
+				ops = append(ops, &openAPI3Operation{
+					method:  strings.ToUpper(op.Key()),
+					path:    pi.Key(),
+					summary: op.Value().Summary,
+				})
+			}
+		}
+	}
+	return ops, nil
+}
+
+// specPath returns the path of `key:path` or `path`.
+// Paths with a scheme ( e.g. `https://` ) or a volume name ( e.g. `C:\path\to\spec.yml` ) are returned as they are.
+func specPath(s string) string {
+	_, p, ok := strings.Cut(s, ":")
+	if !ok || strings.HasPrefix(p, "//") || filepath.VolumeName(s) != "" {
+		return s
+	}
+	return p
+}
+
+// grpcMethods returns methods ( `package.Service/Method` ) of the gRPC server using reflection.
+func grpcMethods(ctx context.Context, addr string) ([]string, error) {
+	const timeout = 10 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	creds := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if flgs.GRPCNoTLS {
+		creds = insecure.NewCredentials()
+	}
+	cc, err := grpc.NewClient(fmt.Sprintf("passthrough:%s", addr), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cc.Close()
+	}()
+	refc := grpcreflect.NewClientAuto(ctx, cc)
+	defer refc.Reset()
+	svcs, err := refc.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	var methods []string
+	for _, svc := range svcs {
+		fd, err := refc.FileContainingSymbol(svc)
+		if err != nil {
+			return nil, err
+		}
+		sd := fd.Services().ByName(svc.Name())
+		if sd == nil {
+			continue
+		}
+		mds := sd.Methods()
+		for i := 0; i < mds.Len(); i++ {
+			methods = append(methods, fmt.Sprintf("%s/%s", svc, mds.Get(i).Name()))
+		}
+	}
+	sort.Strings(methods)
+	return methods, nil
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elk-language/go-prompt"
+	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn"
+)
+
+func TestAppendStepsInteractively(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+string(b))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	tests := []struct {
+		name       string
+		answers    []string // empty answers accept the default values
+		wantReqs   []string
+		wantInBook []string
+		wantSteps  bool
+	}{
+		{
+			"run and append HTTP step",
+			[]string{"http", ts.URL, "post", "/users", `{"name":"alice"}`, "Y", "yes", ""},
+			[]string{`POST /users {"name":"alice"}`},
+			[]string{"/users", "post", "alice"},
+			true,
+		},
+		{
+			"append exec step without running",
+			[]string{"exec", "echo hello", "n", "", ""},
+			nil,
+			[]string{"echo hello"},
+			true,
+		},
+		{
+			"run but do not append",
+			[]string{"http", ts.URL, "", "/ping", "y", "N", ""},
+			[]string{"GET /ping "},
+			nil,
+			false,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			bodies = nil
+			mu.Unlock()
+			answers := tt.answers
+			ask := func(msg, defaultValue string, _ []prompt.Suggest) string {
+				if len(answers) == 0 {
+					t.Fatalf("unexpected prompt: %s", msg)
+				}
+				a := answers[0]
+				answers = answers[1:]
+				if a == "" {
+					return defaultValue
+				}
+				return a
+			}
+			rb := runn.NewRunbook("interactive")
+			if err := appendStepsInteractively(ctx, rb, ask); err != nil {
+				t.Fatal(err)
+			}
+			if len(answers) != 0 {
+				t.Errorf("unanswered: %v", answers)
+			}
+			mu.Lock()
+			if diff := cmp.Diff(bodies, tt.wantReqs); diff != "" {
+				t.Error(diff)
+			}
+			mu.Unlock()
+			b, err := yaml.Marshal(rb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(b)
+			if strings.Contains(got, "steps: []") == tt.wantSteps {
+				t.Errorf("got %s\nwant steps: %v", got, tt.wantSteps)
+			}
+			for _, w := range tt.wantInBook {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %s\nwant to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPI3Operations(t *testing.T) {
+	spec := filepath.Join("..", "testdata", "openapi3.yml")
+	tests := []struct {
+		specs  []string
+		method string
+		want   []string
+	}{
+		{[]string{spec}, http.MethodGet, []string{"/users", "/users/{id}", "/notfound", "/private", "/redirect", "/ping"}},
+		{[]string{spec}, http.MethodPost, []string{"/users", "/help", "/upload"}},
+		{[]string{"req:" + spec}, http.MethodPost, []string{"/users", "/help", "/upload"}},
+		{[]string{spec}, http.MethodPatch, nil},
+		{nil, http.MethodGet, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			ops, err := openAPI3Operations(context.Background(), tt.specs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range pathSuggests(ops, tt.method) {
+				got = append(got, s.Text)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHTTPStepArgs(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		method   string
+		path     string
+		body     string
+		want     []string
+	}{
+		{"http://localhost:8080", http.MethodGet, "/users", "", []string{"curl", "-X", "GET", "http://localhost:8080/users"}},
+		{"http://localhost:8080/", http.MethodGet, "/users", "", []string{"curl", "-X", "GET", "http://localhost:8080/users"}},
+		{"http://localhost:8080", http.MethodPost, "/users", `{"name":"alice"}`, []string{"curl", "-X", "POST", "http://localhost:8080/users", "-H", "Content-Type: application/json", "-d", `{"name":"alice"}`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			got := httpStepArgs(tt.endpoint, tt.method, tt.path, tt.body)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGRPCStepArgs(t *testing.T) {
+	got := grpcStepArgs("localhost:8080", "grpctest.GrpcTestService/Hello", `{"name":"alice"}`)
+	want := []string{"grpcurl", "-d", `{"name":"alice"}`, "localhost:8080", "grpctest.GrpcTestService/Hello"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestIsYes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"y", true},
+		{"Y", true},
+		{"yes", true},
+		{"YES", true},
+		{" Yes ", true},
+		{"n", false},
+		{"no", false},
+		{"", false},
+		{"yeah", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := isYes(tt.in); got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"path/to/spec.yml", "path/to/spec.yml"},
+		{"req:path/to/spec.yml", "path/to/spec.yml"},
+		{"https://example.com/spec.yml", "https://example.com/spec.yml"},
+		{"req:https://example.com/spec.yml", "https://example.com/spec.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := specPath(tt.in); got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
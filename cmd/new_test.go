@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewFromPostmanWithOtherSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		har         string
+		fromOpenAPI string
+	}{
+		{"commands", []string{"curl", "https://example.com"}, "", ""},
+		{"har", nil, "example.har", ""},
+		{"openapi", nil, "", "openapi3.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := *flgs
+			t.Cleanup(func() {
+				*flgs = orig
+			})
+			flgs.FromPostman = "example.postman_collection.json"
+			flgs.HAR = tt.har
+			flgs.FromOpenAPI = tt.fromOpenAPI
+			err := newCmd.RunE(newCmd, tt.args)
+			if err == nil || !strings.Contains(err.Error(), "cannot be used with") {
+				t.Errorf("got %v\nwant error that --from-postman cannot be used with other sources", err)
+			}
+		})
+	}
+}
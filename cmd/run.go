@@ -25,9 +25,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/k1LoW/donegroup"
@@ -74,21 +77,28 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if flgs.Watch {
+			sctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			var files []string
+			files = append(files, flgs.Overlays...)
+			files = append(files, flgs.Underlays...)
+			return o.Watch(sctx, files, func(err error) error {
+				if err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+				} else if err := writeResult(o.Result()); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintln(os.Stderr, "Watching for file changes...")
+				return nil
+			})
+		}
 		if err := o.RunN(ctx); err != nil {
 			return err
 		}
 		r := o.Result()
-		switch flgs.Format {
-		case "json":
-			if err := r.OutJSON(os.Stdout); err != nil {
-				return err
-			}
-		case "none":
-		default:
-			// If --verbose == true, leave it to cmdout to display results
-			if err := r.Out(os.Stdout); err != nil {
-				return err
-			}
+		if err := writeResult(r); err != nil {
+			return err
 		}
 
 		if flgs.Profile {
@@ -114,22 +124,70 @@ var runCmd = &cobra.Command{
 	},
 }
 
+type result interface {
+	Out(out io.Writer) error
+	OutJSON(out io.Writer) error
+	OutJUnit(out io.Writer) error
+	OutTAP(out io.Writer) error
+}
+
+// writeResult outputs the result according to --format and --report-out.
+func writeResult(r result) error {
+	if flgs.ReportOut == "" {
+		return outResult(r, flgs.Format, os.Stdout)
+	}
+	// Keep the console output and write the result in the format to the file
+	if err := r.Out(os.Stdout); err != nil {
+		return err
+	}
+	f, err := os.Create(filepath.Clean(flgs.ReportOut))
+	if err != nil {
+		return err
+	}
+	if err := outResult(r, flgs.Format, f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func outResult(r result, format string, out io.Writer) error {
+	switch format {
+	case "json":
+		return r.OutJSON(out)
+	case "junit":
+		return r.OutJUnit(out)
+	case "tap":
+		return r.OutTAP(out)
+	case "none":
+		return nil
+	default:
+		// If --verbose == true, leave it to cmdout to display results
+		return r.Out(out)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().BoolVarP(&flgs.Debug, "debug", "", false, flgs.Usage("Debug"))
 	runCmd.Flags().BoolVarP(&flgs.FailFast, "fail-fast", "", false, flgs.Usage("FailFast"))
 	runCmd.Flags().BoolVarP(&flgs.SkipTest, "skip-test", "", false, flgs.Usage("SkipTest"))
+	runCmd.Flags().BoolVarP(&flgs.UpdateSnapshots, "update-snapshots", "", false, flgs.Usage("UpdateSnapshots"))
 	runCmd.Flags().BoolVarP(&flgs.SkipIncluded, "skip-included", "", false, flgs.Usage("SkipIncluded"))
 	runCmd.Flags().StringSliceVarP(&flgs.HostRules, "host-rules", "", []string{}, flgs.Usage("HostRules"))
+	runCmd.Flags().StringVarP(&flgs.Proxy, "proxy", "", "", flgs.Usage("Proxy"))
 	runCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
 	runCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
+	runCmd.Flags().StringSliceVarP(&flgs.GRPCProtosets, "grpc-protoset", "", []string{}, flgs.Usage("GRPCProtosets"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufDirs, "grpc-buf-dir", "", []string{}, flgs.Usage("GRPCBufDirs"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufLocks, "grpc-buf-lock", "", []string{}, flgs.Usage("GRPCBufLocks"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufConfigs, "grpc-buf-config", "", []string{}, flgs.Usage("GRPCBufConfigs"))
 	runCmd.Flags().StringSliceVarP(&flgs.GRPCBufModules, "grpc-buf-module", "", []string{}, flgs.Usage("GRPCBufModules"))
 	runCmd.Flags().StringVarP(&flgs.CaptureDir, "capture", "", "", flgs.Usage("CaptureDir"))
+	runCmd.Flags().StringVarP(&flgs.RecordDir, "record", "", "", flgs.Usage("RecordDir"))
+	runCmd.Flags().StringVarP(&flgs.ReplayDir, "replay", "", "", flgs.Usage("ReplayDir"))
 	runCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
 	runCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
 	runCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
@@ -144,6 +202,9 @@ func init() {
 	runCmd.Flags().IntVarP(&flgs.ShardN, "shard-n", "", 0, flgs.Usage("ShardN"))
 	runCmd.Flags().IntVarP(&flgs.Random, "random", "", 0, flgs.Usage("Random"))
 	runCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+	runCmd.Flags().StringVarP(&flgs.ReportOut, "report-out", "", "", flgs.Usage("ReportOut"))
+	runCmd.Flags().StringVarP(&flgs.ReportHTML, "report-html", "", "", flgs.Usage("ReportHTML"))
+	runCmd.Flags().BoolVarP(&flgs.Watch, "watch", "", false, flgs.Usage("Watch"))
 	runCmd.Flags().BoolVarP(&flgs.Profile, "profile", "", false, flgs.Usage("Profile"))
 	runCmd.Flags().StringVarP(&flgs.ProfileOut, "profile-out", "", "runn.prof", flgs.Usage("ProfileOut"))
 	runCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
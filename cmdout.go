@@ -79,6 +79,11 @@ func (d *cmdOut) CaptureExecCommand(command, shell string, background bool)
 func (d *cmdOut) CaptureExecStdin(stdin string)                                      {}
 func (d *cmdOut) CaptureExecStdout(stdout string)                                    {}
 func (d *cmdOut) CaptureExecStderr(stderr string)                                    {}
+func (d *cmdOut) CaptureWSStart(name string)                                         {}
+func (d *cmdOut) CaptureWSSendMessage(name string, m *WSMessage)                     {}
+func (d *cmdOut) CaptureWSReceiveMessage(name string, m *WSMessage)                  {}
+func (d *cmdOut) CaptureWSClose(name string, code int, reason string)                {}
+func (d *cmdOut) CaptureWSEnd(name string)                                           {}
 func (d *cmdOut) SetCurrentTrails(trs Trails)                                        {}
 func (d *cmdOut) Errs() error {
 	return d.errs
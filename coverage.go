@@ -136,5 +136,44 @@ func (o *operator) collectCoverage(ctx context.Context) (*Coverage, error) {
 			}
 		}
 	}
+
+	// Collect coverage for GraphQL schema
+	for name, r := range o.graphqlRunners {
+		if r.schema == nil {
+			o.Debugf("%s does not have graphql schema (%s)\n", name, o.bookPath)
+			continue
+		}
+		key := r.endpoint
+		scov, ok := lo.Find(cov.Specs, func(scov *SpecCoverage) bool {
+			return scov.Key == key
+		})
+		if !ok {
+			scov = &SpecCoverage{
+				Key:       key,
+				Coverages: map[string]int{},
+			}
+			cov.Specs = append(cov.Specs, scov)
+		}
+		for _, k := range graphqlCoverageKeys(r.schema) {
+			scov.Coverages[k] += 0
+		}
+		for _, s := range o.steps {
+			if s.graphqlRunner != r {
+				continue
+			}
+			q, ok := s.graphqlRequest["query"].(string)
+			if !ok {
+				continue
+			}
+			doc, err := r.validateQuery(q)
+			if err != nil {
+				o.Debugf("%s was not matched in %s: %s (%s)\n", name, key, err, o.bookPath)
+				continue
+			}
+			for _, k := range graphqlSelectedFields(doc) {
+				scov.Coverages[k]++
+			}
+		}
+	}
 	return cov, nil
 }
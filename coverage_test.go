@@ -18,6 +18,7 @@ func TestCoverage(t *testing.T) {
 	}{
 		{"testdata/book/httpbin.yml"},
 		{"testdata/book/grpc.yml"},
+		{"testdata/book/graphql.yml"},
 	}
 	t.Setenv("DEBUG", "false")
 	ctx := context.Background()
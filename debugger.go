@@ -1,6 +1,7 @@
 package runn
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -57,10 +58,18 @@ func (d *debugger) CaptureGRPCRequestMessage(m map[string]any) {
 }
 
 func (d *debugger) CaptureGRPCResponseStatus(s *status.Status) {
+	d.captureGRPCResponseStatusWithResolver(s, newGRPCDetailsResolver(nil))
+}
+
+func (d *debugger) captureGRPCResponseStatusWithResolver(s *status.Status, r *grpcDetailsResolver) {
 	c := s.Code()
 	m := fmt.Sprintf("%s (%d)", c.String(), int(c))
 	if c != codes.OK {
 		m = fmt.Sprintf("%s (%d): %s", c.String(), int(c), s.Message())
+		for _, dt := range grpcStatusDetails(s, r) {
+			b, _ := json.Marshal(dt)
+			m += fmt.Sprintf("\n%s", string(b))
+		}
 	}
 	_, _ = fmt.Fprintf(d.out, "-----START gRPC RESPONSE STATUS-----\n%s\n-----END gRPC RESPONSE STATUS-----\n", m)
 }
@@ -158,6 +167,26 @@ func (d *debugger) CaptureExecStderr(stderr string) {
 	_, _ = fmt.Fprintf(d.out, "-----START STDERR-----\n%s\n-----END STDERR-----\n", stderr)
 }
 
+func (d *debugger) CaptureWSStart(name string) {
+	_, _ = fmt.Fprint(d.out, ">>>>>START WebSocket>>>>>\n")
+}
+
+func (d *debugger) CaptureWSSendMessage(name string, m *WSMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket SEND MESSAGE-----\n%s\n-----END WebSocket SEND MESSAGE-----\n", dumpWSMessage(m))
+}
+
+func (d *debugger) CaptureWSReceiveMessage(name string, m *WSMessage) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket RECEIVE MESSAGE-----\n%s\n-----END WebSocket RECEIVE MESSAGE-----\n", dumpWSMessage(m))
+}
+
+func (d *debugger) CaptureWSClose(name string, code int, reason string) {
+	_, _ = fmt.Fprintf(d.out, "-----START WebSocket CLOSE-----\ncode: %d\nreason: %s\n-----END WebSocket CLOSE-----\n", code, reason)
+}
+
+func (d *debugger) CaptureWSEnd(name string) {
+	_, _ = fmt.Fprint(d.out, "<<<<<END WebSocket<<<<<\n")
+}
+
 func (d *debugger) SetCurrentTrails(trs Trails) {
 	d.currentTrails = trs
 }
@@ -207,3 +236,10 @@ func dumpGRPCMetadata(m map[string][]string) string {
 	}
 	return strings.Join(d, "\n")
 }
+
+func dumpWSMessage(m *WSMessage) string {
+	if m.Type == WSMessageBinary {
+		return fmt.Sprintf("type: %s\ndata: %s", m.Type, hex.EncodeToString(m.Data))
+	}
+	return fmt.Sprintf("type: %s\ndata: %s", m.Type, string(m.Data))
+}
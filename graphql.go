@@ -0,0 +1,408 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/duration"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	graphqlStoreStatusKey     = "status"
+	graphqlStoreDataKey       = "data"
+	graphqlStoreErrorsKey     = "errors"
+	graphqlStoreExtensionsKey = "extensions"
+	graphqlStoreRawBodyKey    = "rawBody"
+	graphqlStoreHeaderKey     = "headers"
+	graphqlStoreResponseKey   = "res"
+)
+
+type graphqlRunner struct {
+	name                string
+	endpoint            string
+	httpRunner          *httpRunner
+	schema              *ast.Schema
+	skipValidateRequest bool
+}
+
+type graphqlRequest struct {
+	query         string
+	variables     map[string]any
+	operationName string
+	headers       http.Header
+	trace         *bool
+}
+
+type graphqlResponse struct {
+	Data       any   `json:"data"`
+	Errors     []any `json:"errors,omitempty"`
+	Extensions any   `json:"extensions,omitempty"`
+}
+
+func newGraphQLRunner(name, endpoint string) (*graphqlRunner, error) {
+	hr, err := newHTTPRunner(name, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &graphqlRunner{
+		name:       name,
+		endpoint:   endpoint,
+		httpRunner: hr,
+	}, nil
+}
+
+func (rnr *graphqlRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.graphqlRequest, s)
+	if err != nil {
+		return err
+	}
+	r, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid graphql request: %v", e)
+	}
+	req, err := parseGraphQLRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *graphqlRunner) run(ctx context.Context, r *graphqlRequest, s *step) error {
+	o := s.parent
+	if rnr.schema != nil && !rnr.skipValidateRequest {
+		if _, err := rnr.validateQuery(r.query); err != nil {
+			return err
+		}
+	}
+	body := map[string]any{
+		"query": r.query,
+	}
+	if r.variables != nil {
+		body["variables"] = r.variables
+	}
+	if r.operationName != "" {
+		body["operationName"] = r.operationName
+	}
+	hreq := &httpRequest{
+		path:      "/",
+		method:    http.MethodPost,
+		headers:   r.headers,
+		mediaType: MediaTypeApplicationJSON,
+		body:      body,
+		trace:     r.trace,
+	}
+	res, resBody, err := rnr.httpRunner.roundTrip(ctx, hreq, s)
+	if err != nil {
+		return err
+	}
+
+	d := map[string]any{}
+	d[graphqlStoreStatusKey] = res.StatusCode
+	d[graphqlStoreDataKey] = nil
+	d[graphqlStoreErrorsKey] = []any{}
+	d[graphqlStoreExtensionsKey] = nil
+	if strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0 {
+		gr := &graphqlResponse{}
+		if err := json.Unmarshal(resBody, gr); err != nil {
+			return err
+		}
+		d[graphqlStoreDataKey] = gr.Data
+		if gr.Errors != nil {
+			d[graphqlStoreErrorsKey] = gr.Errors
+		}
+		d[graphqlStoreExtensionsKey] = gr.Extensions
+	}
+	d[graphqlStoreRawBodyKey] = string(resBody)
+	d[graphqlStoreHeaderKey] = res.Header
+
+	o.record(s.idx, map[string]any{
+		string(graphqlStoreResponseKey): d,
+	})
+
+	return nil
+}
+
+// validateQuery validates the query against the schema.
+func (rnr *graphqlRunner) validateQuery(query string) (*ast.QueryDocument, error) {
+	doc, errs := gqlparser.LoadQuery(rnr.schema, query)
+	if len(errs) > 0 {
+		var err error
+		for _, e := range errs {
+			err = errors.Join(err, e)
+		}
+		return nil, fmt.Errorf("invalid graphql query: %w", err)
+	}
+	return doc, nil
+}
+
+// loadGraphQLSchema loads the schema from SDL or the result of the introspection query.
+func loadGraphQLSchema(p string) (*ast.Schema, error) {
+	lp, err := fetchPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graphql schema %s: %w", p, err)
+	}
+	b, err := readFile(lp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graphql schema %s: %w", p, err)
+	}
+	sdl := string(b)
+	if filepath.Ext(lp) == ".json" || bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		sdl, err = introspectionToSDL(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load graphql schema %s: %w", p, err)
+		}
+	}
+	schema, gerr := gqlparser.LoadSchema(&ast.Source{Name: filepath.Base(lp), Input: sdl})
+	if gerr != nil {
+		return nil, fmt.Errorf("failed to load graphql schema %s: %w", p, gerr)
+	}
+	return schema, nil
+}
+
+type introspectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   *string               `json:"name"`
+	OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionInputValue struct {
+	Name         string               `json:"name"`
+	Type         introspectionTypeRef `json:"type"`
+	DefaultValue *string              `json:"defaultValue"`
+}
+
+type introspectionType struct {
+	Kind          string                    `json:"kind"`
+	Name          string                    `json:"name"`
+	Fields        []introspectionField      `json:"fields"`
+	InputFields   []introspectionInputValue `json:"inputFields"`
+	Interfaces    []introspectionTypeRef    `json:"interfaces"`
+	EnumValues    []struct{ Name string }   `json:"enumValues"`
+	PossibleTypes []introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionField struct {
+	Name string                    `json:"name"`
+	Args []introspectionInputValue `json:"args"`
+	Type introspectionTypeRef      `json:"type"`
+}
+
+type introspectionSchema struct {
+	QueryType        *struct{ Name string } `json:"queryType"`
+	MutationType     *struct{ Name string } `json:"mutationType"`
+	SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+	Types            []introspectionType    `json:"types"`
+}
+
+// introspectionToSDL converts the result of the introspection query to SDL.
+func introspectionToSDL(b []byte) (string, error) {
+	var v struct {
+		Data *struct {
+			Schema *introspectionSchema `json:"__schema"`
+		} `json:"data"`
+		Schema *introspectionSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", err
+	}
+	is := v.Schema
+	if is == nil && v.Data != nil {
+		is = v.Data.Schema
+	}
+	if is == nil {
+		return "", errors.New("__schema not found")
+	}
+	builtinScalars := map[string]struct{}{"String": {}, "Int": {}, "Float": {}, "Boolean": {}, "ID": {}}
+	buf := new(strings.Builder)
+	_, _ = buf.WriteString("schema {\n")
+	if is.QueryType != nil {
+		_, _ = fmt.Fprintf(buf, "  query: %s\n", is.QueryType.Name)
+	}
+	if is.MutationType != nil {
+		_, _ = fmt.Fprintf(buf, "  mutation: %s\n", is.MutationType.Name)
+	}
+	if is.SubscriptionType != nil {
+		_, _ = fmt.Fprintf(buf, "  subscription: %s\n", is.SubscriptionType.Name)
+	}
+	_, _ = buf.WriteString("}\n")
+	for _, t := range is.Types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		switch t.Kind {
+		case "SCALAR":
+			if _, ok := builtinScalars[t.Name]; ok {
+				continue
+			}
+			_, _ = fmt.Fprintf(buf, "scalar %s\n", t.Name)
+		case "OBJECT", "INTERFACE":
+			kw := "type"
+			if t.Kind == "INTERFACE" {
+				kw = "interface"
+			}
+			_, _ = fmt.Fprintf(buf, "%s %s", kw, t.Name)
+			if len(t.Interfaces) > 0 {
+				var names []string
+				for _, i := range t.Interfaces {
+					names = append(names, i.String())
+				}
+				_, _ = fmt.Fprintf(buf, " implements %s", strings.Join(names, " & "))
+			}
+			_, _ = buf.WriteString(" {\n")
+			for _, f := range t.Fields {
+				_, _ = fmt.Fprintf(buf, "  %s%s: %s\n", f.Name, inputValuesToSDL(f.Args), f.Type.String())
+			}
+			_, _ = buf.WriteString("}\n")
+		case "UNION":
+			var names []string
+			for _, p := range t.PossibleTypes {
+				names = append(names, p.String())
+			}
+			_, _ = fmt.Fprintf(buf, "union %s = %s\n", t.Name, strings.Join(names, " | "))
+		case "ENUM":
+			_, _ = fmt.Fprintf(buf, "enum %s {\n", t.Name)
+			for _, e := range t.EnumValues {
+				_, _ = fmt.Fprintf(buf, "  %s\n", e.Name)
+			}
+			_, _ = buf.WriteString("}\n")
+		case "INPUT_OBJECT":
+			_, _ = fmt.Fprintf(buf, "input %s {\n", t.Name)
+			for _, f := range t.InputFields {
+				_, _ = fmt.Fprintf(buf, "  %s\n", f.String())
+			}
+			_, _ = buf.WriteString("}\n")
+		default:
+			return "", fmt.Errorf("unsupported kind: %s", t.Kind)
+		}
+	}
+	return buf.String(), nil
+}
+
+func (t introspectionTypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType == nil {
+			return ""
+		}
+		return t.OfType.String() + "!"
+	case "LIST":
+		if t.OfType == nil {
+			return ""
+		}
+		return "[" + t.OfType.String() + "]"
+	default:
+		if t.Name == nil {
+			return ""
+		}
+		return *t.Name
+	}
+}
+
+func (v introspectionInputValue) String() string {
+	s := fmt.Sprintf("%s: %s", v.Name, v.Type.String())
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func inputValuesToSDL(vs []introspectionInputValue) string {
+	if len(vs) == 0 {
+		return ""
+	}
+	var args []string
+	for _, v := range vs {
+		args = append(args, v.String())
+	}
+	return "(" + strings.Join(args, ", ") + ")"
+}
+
+// graphqlCoverageKeys returns the keys of all fields of the object types in the schema.
+func graphqlCoverageKeys(schema *ast.Schema) []string {
+	var keys []string
+	for name, def := range schema.Types {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		if def.Kind != ast.Object && def.Kind != ast.Interface {
+			continue
+		}
+		for _, f := range def.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				continue
+			}
+			keys = append(keys, fmt.Sprintf("%s.%s", name, f.Name))
+		}
+	}
+	return keys
+}
+
+// graphqlSelectedFields returns the keys of the fields selected by the query.
+func graphqlSelectedFields(doc *ast.QueryDocument) []string {
+	var keys []string
+	var walk func(set ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch v := sel.(type) {
+			case *ast.Field:
+				if v.ObjectDefinition != nil && !strings.HasPrefix(v.Name, "__") {
+					keys = append(keys, fmt.Sprintf("%s.%s", v.ObjectDefinition.Name, v.Name))
+				}
+				walk(v.SelectionSet)
+			case *ast.InlineFragment:
+				walk(v.SelectionSet)
+			case *ast.FragmentSpread:
+				if v.Definition != nil {
+					walk(v.Definition.SelectionSet)
+				}
+			}
+		}
+	}
+	for _, op := range doc.Operations {
+		walk(op.SelectionSet)
+	}
+	return keys
+}
+
+// applyConfig applies graphqlRunnerConfig to the runner.
+func (rnr *graphqlRunner) applyConfig(c *graphqlRunnerConfig, root string) error {
+	r := rnr.httpRunner
+	var err error
+	r.cacert, r.cert, r.key, err = readTLSFiles(root, c.CACert, c.Cert, c.Key)
+	if err != nil {
+		return err
+	}
+	r.skipVerify = c.SkipVerify
+	if c.Timeout != "" {
+		r.client.Timeout, err = duration.Parse(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout in GraphQLRunnerConfig is invalid: %w", err)
+		}
+	}
+	r.useCookie = c.UseCookie
+	rnr.skipValidateRequest = c.SkipValidateRequest
+	if c.Schema != "" {
+		p, err := fp(c.Schema, root)
+		if err != nil {
+			return err
+		}
+		schema, err := loadGraphQLSchema(p)
+		if err != nil {
+			return err
+		}
+		rnr.schema = schema
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package runn
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestGraphQLRunner(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/graphql.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			ts := testutil.HTTPServer(t)
+			t.Setenv("TEST_HTTP_ENDPOINT", ts.URL)
+			o, err := New(Book(tt.book), Scopes(ScopeAllowReadParent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGraphQLRunnerValidateQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{`{ user(id: "1") { id name } }`, false},
+		{`query ($id: ID!) { user(id: $id) { ... on Node { id } } }`, false},
+		{`mutation { createUser(input: {name: "alice"}) { id } }`, false},
+		{`{ user(id: "1") { id password } }`, true},
+		{`{ user { id } }`, true},
+		{`{ users(role: OWNER) { id } }`, true},
+		{`{ user(id: "1") { id `, true},
+	}
+	schema, err := loadGraphQLSchema("testdata/graphql/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &graphqlRunner{schema: schema}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := r.validateQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphQLSchemaFromIntrospection(t *testing.T) {
+	sdl, err := loadGraphQLSchema("testdata/graphql/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspection, err := loadGraphQLSchema("testdata/graphql/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := graphqlCoverageKeys(sdl)
+	got := graphqlCoverageKeys(introspection)
+	sort.Strings(want)
+	sort.Strings(got)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+	if introspection.Query == nil || introspection.Query.Name != "Query" {
+		t.Errorf("got %v, want Query", introspection.Query)
+	}
+	if introspection.Mutation == nil || introspection.Mutation.Name != "Mutation" {
+		t.Errorf("got %v, want Mutation", introspection.Mutation)
+	}
+}
+
+func TestGraphQLSelectedFields(t *testing.T) {
+	schema, err := loadGraphQLSchema("testdata/graphql/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &graphqlRunner{schema: schema}
+	doc, err := r.validateQuery(`query { user(id: "1") { ...f ... on Node { id } } } fragment f on User { name }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := graphqlSelectedFields(doc)
+	sort.Strings(got)
+	want := []string{"Node.id", "Query.user", "User.name"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
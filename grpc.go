@@ -7,31 +7,45 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
+	"net"
+	"net/http"
 	"os"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"connectrpc.com/connect"
 	"github.com/bufbuild/protocompile"
 	"github.com/bufbuild/protocompile/linker"
+	"github.com/dustin/go-humanize"
 	"github.com/goccy/go-json"
 	"github.com/jhump/protoreflect/v2/grpcreflect"
 	"github.com/k1LoW/bufresolv"
 	"github.com/k1LoW/donegroup"
 	"github.com/k1LoW/protoresolv"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/exprtrace"
+	"github.com/k1LoW/runn/internal/store"
 	"github.com/k1LoW/runn/version"
 	"github.com/mitchellh/copystructure"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
@@ -50,6 +64,7 @@ const (
 	GRPCOpMessage GRPCOp = "message"
 	GRPCOpReceive GRPCOp = "receive"
 	GRPCOpClose   GRPCOp = "close"
+	GRPCOpTest    GRPCOp = "test"
 )
 
 const (
@@ -58,12 +73,14 @@ const (
 	grpcStoreTrailerKey  = "trailers"
 	grpcStoreMessageKey  = "message"
 	grpcStoreMessagesKey = "messages"
+	grpcStoreDetailsKey  = "details"
 	grpcStoreResponseKey = "res"
 )
 
 type grpcRunner struct {
 	name            string
 	target          string
+	protocol        string
 	tls             *bool
 	cacert          []byte
 	cert            []byte
@@ -71,16 +88,26 @@ type grpcRunner struct {
 	skipVerify      bool
 	importPaths     []string
 	protos          []string
+	protosets       []string
 	bufDirs         []string
 	bufLocks        []string
 	bufConfigs      []string
 	bufModules      []string
 	cc              *grpc.ClientConn
+	hc              *connectConn
 	refc            *grpcreflect.Client
 	mds             map[string]protoreflect.MethodDescriptor
 	hostRules       hostRules
+	proxy           *proxy
 	trace           *bool
 	traceHeaderName string
+	compression     string
+	maxSendMsgSize  int
+	maxRecvMsgSize  int
+	keepalive       *keepalive.ClientParameters
+	timeout         time.Duration
+	cassettes       *cassettes
+	descriptors     *grpcDescriptors
 	mu              sync.Mutex
 	// operatorID - The id of the operator for which the runner is defined.
 	operatorID string
@@ -89,8 +116,16 @@ type grpcRunner struct {
 type grpcMessage struct {
 	op     GRPCOp
 	params map[string]any
+	// until - condition to stop receiving messages ( for GRPCOpReceive )
+	until string
+	// timeout - timeout for the whole receive op ( for GRPCOpReceive )
+	timeout time.Duration
+	// cond - condition to test ( for GRPCOpTest )
+	cond string
 }
 
+var errGRPCReceiveTimeout = errors.New("timeout waiting for message")
+
 type grpcRequest struct {
 	service  string
 	method   string
@@ -111,7 +146,7 @@ func newGrpcRunner(name, target string) (*grpcRunner, error) {
 }
 
 func (rnr *grpcRunner) Renew() error {
-	if rnr.cc != nil && rnr.target == "" {
+	if (rnr.cc != nil || rnr.hc != nil) && rnr.target == "" {
 		return errors.New("gRPC runners created with the runn.GrpcRunner option cannot be renewed")
 	}
 	if err := rnr.Close(); err != nil {
@@ -121,12 +156,18 @@ func (rnr *grpcRunner) Renew() error {
 }
 
 func (rnr *grpcRunner) Close() error {
+	if rnr.hc != nil {
+		rnr.hc.Close()
+		rnr.hc = nil
+	}
 	if rnr.cc == nil {
 		rnr.refc = nil
 		return nil
 	}
 	rnr.refc = nil
-	return rnr.cc.Close()
+	err := rnr.cc.Close()
+	rnr.cc = nil
+	return err
 }
 
 func (rnr *grpcRunner) Run(ctx context.Context, s *step) error {
@@ -143,14 +184,21 @@ func (rnr *grpcRunner) Run(ctx context.Context, s *step) error {
 
 func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	o := s.parent
-	if err := rnr.connectAndResolve(ctx, o); err != nil {
+	if err := rnr.connectAndResolve(ctx, s); err != nil {
 		return err
 	}
+	if rnr.cassettes != nil {
+		// Set the key after resolving methods so that reflection is not recorded
+		ctx = withCassetteKey(ctx, s.runbookID())
+	}
 	key := strings.Join([]string{r.service, r.method}, "/")
 	md, ok := rnr.mds[key]
 	if !ok {
 		return fmt.Errorf("cannot find method: %s", key)
 	}
+	if rnr.usesHTTP() && md.IsStreamingClient() {
+		return fmt.Errorf("client streaming and bidirectional streaming RPCs are not supported with protocol %s: %s", rnr.protocol, key)
+	}
 	// Override trace
 	rnr.mu.Lock()
 	r.mu.Lock()
@@ -160,6 +208,10 @@ func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	case r.trace == nil && rnr.trace != nil:
 		r.trace = rnr.trace
 	}
+	// Override timeout
+	if r.timeout == 0 {
+		r.timeout = rnr.timeout
+	}
 	r.mu.Unlock()
 	rnr.mu.Unlock()
 	if err := r.setTraceHeader(s); err != nil {
@@ -187,51 +239,54 @@ func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	}
 }
 
-func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error {
-	if rnr.cc == nil {
+func (rnr *grpcRunner) connectAndResolve(ctx context.Context, s *step) error {
+	o := s.parent
+	switch {
+	case rnr.usesHTTP():
+		if rnr.hc == nil {
+			hc, err := rnr.newConnectConn()
+			if err != nil {
+				return err
+			}
+			rnr.hc = hc
+			o.Debugf("Connect to %s with gRPC runner %q (%s)\n", rnr.target, rnr.name, rnr.settings())
+			if err := rnr.registerCleanup(ctx, o); err != nil {
+				return err
+			}
+		}
+	case rnr.cc == nil:
 		opts := []grpc.DialOption{
 			grpc.WithUserAgent(fmt.Sprintf("runn/%s", version.Version)),
 		}
-		if len(rnr.hostRules) > 0 {
+		_, isUnix := unixSocketAddr(rnr.target)
+		switch {
+		case isUnix:
+			// Unix domain sockets are dialed by grpc-go without host rules and proxy
+		case rnr.proxy != nil:
+			opts = append(opts, grpc.WithContextDialer(rnr.proxy.contextDialerFunc(rnr.hostRules.contextDialerFunc())))
+		case len(rnr.hostRules) > 0:
 			opts = append(opts, grpc.WithContextDialer(rnr.hostRules.contextDialerFunc()))
 		}
-		useTLS := true
-		if strings.HasSuffix(rnr.target, ":80") {
-			useTLS = false
+		if rnr.keepalive != nil {
+			opts = append(opts, grpc.WithKeepaliveParams(*rnr.keepalive))
 		}
-		if rnr.tls != nil {
-			useTLS = *rnr.tls
+		if rnr.cassettes != nil {
+			opts = append(opts,
+				grpc.WithChainUnaryInterceptor(rnr.cassettes.unaryInterceptor(rnr.name)),
+				grpc.WithChainStreamInterceptor(rnr.cassettes.streamInterceptor(rnr.name)),
+			)
 		}
-		if useTLS {
-			tlsc := tls.Config{MinVersion: tls.VersionTLS12}
-			if len(rnr.cert) != 0 {
-				certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
-				if err != nil {
-					return err
-				}
-				tlsc.Certificates = []tls.Certificate{certificate}
-			}
-			if rnr.skipVerify {
-				//#nosec G402
-				tlsc.InsecureSkipVerify = true
-			} else if len(rnr.cacert) != 0 {
-				certpool, err := x509.SystemCertPool()
-				if err != nil {
-					// FIXME for Windows
-					// ref: https://github.com/golang/go/issues/18609
-					certpool = x509.NewCertPool()
-				}
-				if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
-					return errors.New("failed to append cacert")
-				}
-				tlsc.RootCAs = certpool
+		if rnr.useTLS() {
+			tlsc, err := rnr.tlsConfig()
+			if err != nil {
+				return err
 			}
-			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(&tlsc)))
+			opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsc)))
 		} else {
 			opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 		}
 		target := rnr.target
-		if strings.Count(target, ":") < 2 {
+		if strings.Count(target, ":") < 2 && !isUnix {
 			target = fmt.Sprintf("passthrough:%s", target)
 		}
 		cc, err := grpc.NewClient(target, opts...)
@@ -239,24 +294,36 @@ func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error
 			return err
 		}
 		rnr.cc = cc
-		if rnr.target != "" {
-			if err := donegroup.Cleanup(ctx, func() error {
-				// In the case of Reused runners, leave the cleanup to the main cleanup
-				if o.id != rnr.operatorID {
-					return nil
-				}
-				return rnr.Renew()
-			}); err != nil {
+		o.Debugf("Connect to %s with gRPC runner %q (%s)\n", rnr.target, rnr.name, rnr.settings())
+		if err := rnr.registerCleanup(ctx, o); err != nil {
+			return err
+		}
+	}
+	if rnr.cassettes != nil && rnr.cassettes.replay {
+		// Resolve methods using the descriptors in the cassette instead of the server
+		if len(rnr.mds) == 0 {
+			mds, err := rnr.cassettes.methodDescriptors(s.runbookID(), rnr.name)
+			if err != nil {
 				return err
 			}
+			rnr.mds = mds
+		}
+		return nil
+	}
+	if len(rnr.mds) == 0 && rnr.descriptors != nil {
+		if mds, ok := rnr.descriptors.load(rnr.descriptorsKey()); ok {
+			rnr.mds = mds
 		}
 	}
-	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0 {
+	if len(rnr.mds) == 0 && (len(rnr.protosets) > 0 || len(rnr.importPaths) > 0 || len(rnr.protos) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0) {
 		if err := rnr.resolveAllMethodsUsingProtos(ctx); err != nil {
 			return err
 		}
 	}
 	if len(rnr.mds) == 0 {
+		if rnr.usesHTTP() {
+			return fmt.Errorf("server reflection is not available with protocol %s. use protos:, protosets: or buf settings to resolve methods", rnr.protocol)
+		}
 		// Fallback to reflection
 		if rnr.refc == nil {
 			rnr.refc = grpcreflect.NewClientAuto(ctx, rnr.cc)
@@ -265,9 +332,143 @@ func (rnr *grpcRunner) connectAndResolve(ctx context.Context, o *operator) error
 			return err
 		}
 	}
+	if rnr.descriptors != nil {
+		rnr.descriptors.store(rnr.descriptorsKey(), rnr.mds)
+	}
+	if rnr.cassettes != nil {
+		if err := rnr.cassettes.recordDescriptors(s.runbookID(), rnr.name, rnr.mds); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// registerCleanup registers the cleanup of the connection with the operator.
+func (rnr *grpcRunner) registerCleanup(ctx context.Context, o *operator) error {
+	if rnr.target == "" {
+		return nil
+	}
+	return donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Renew()
+	})
+}
+
+// usesHTTP reports whether the runner invokes RPCs using the gRPC-Web or Connect protocol instead of native gRPC.
+func (rnr *grpcRunner) usesHTTP() bool {
+	return rnr.protocol == GRPCProtocolGRPCWeb || rnr.protocol == GRPCProtocolConnect
+}
+
+// conn returns the connection to invoke RPCs.
+func (rnr *grpcRunner) conn() grpc.ClientConnInterface {
+	if rnr.hc != nil {
+		return rnr.hc
+	}
+	return rnr.cc
+}
+
+func (rnr *grpcRunner) useTLS() bool {
+	useTLS := true
+	if strings.HasSuffix(rnr.target, ":80") {
+		useTLS = false
+	}
+	if _, ok := unixSocketAddr(rnr.target); ok {
+		useTLS = false
+	}
+	if rnr.tls != nil {
+		useTLS = *rnr.tls
+	}
+	return useTLS
+}
+
+func (rnr *grpcRunner) tlsConfig() (*tls.Config, error) {
+	tlsc := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(rnr.cert) != 0 {
+		certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+		if err != nil {
+			return nil, err
+		}
+		tlsc.Certificates = []tls.Certificate{certificate}
+	}
+	if rnr.skipVerify {
+		//#nosec G402
+		tlsc.InsecureSkipVerify = true
+	} else if len(rnr.cacert) != 0 {
+		certpool, err := x509.SystemCertPool()
+		if err != nil {
+			// FIXME for Windows
+			// ref: https://github.com/golang/go/issues/18609
+			certpool = x509.NewCertPool()
+		}
+		if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
+			return nil, errors.New("failed to append cacert")
+		}
+		tlsc.RootCAs = certpool
+	}
+	return tlsc, nil
+}
+
+// newConnectConn returns the connection that invokes RPCs using the gRPC-Web or Connect protocol.
+func (rnr *grpcRunner) newConnectConn() (*connectConn, error) {
+	tp, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast: %v", http.DefaultTransport)
+	}
+	tp = tp.Clone()
+	scheme := "http"
+	if rnr.useTLS() {
+		scheme = "https"
+		tlsc, err := rnr.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		tp.TLSClientConfig = tlsc
+	}
+	if len(rnr.hostRules) > 0 {
+		tp.DialContext = rnr.hostRules.dialContextFunc()
+	}
+	if rnr.proxy != nil {
+		tp.Proxy = rnr.proxy.httpProxyFunc()
+	}
+	host := rnr.target
+	if addr, ok := unixSocketAddr(rnr.target); ok {
+		host = "localhost"
+		tp.Proxy = nil
+		tp.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}
+	}
+	var copts []connect.ClientOption
+	if rnr.compression == gzip.Name {
+		copts = append(copts, connect.WithSendGzip())
+	}
+	if rnr.maxSendMsgSize > 0 {
+		copts = append(copts, connect.WithSendMaxBytes(rnr.maxSendMsgSize))
+	}
+	if rnr.maxRecvMsgSize > 0 {
+		copts = append(copts, connect.WithReadMaxBytes(rnr.maxRecvMsgSize))
+	}
+	hc := &connectConn{
+		protocol: rnr.protocol,
+		baseURL:  fmt.Sprintf("%s://%s", scheme, host),
+		client:   &http.Client{Transport: tp},
+		opts:     copts,
+		findMethod: func(method string) (protoreflect.MethodDescriptor, bool) {
+			md, ok := rnr.mds[method]
+			return md, ok
+		},
+	}
+	if rnr.cassettes != nil {
+		hc.unaryInterceptor = rnr.cassettes.unaryInterceptor(rnr.name)
+		hc.streamInterceptor = rnr.cassettes.streamInterceptor(rnr.name)
+	}
+	return hc, nil
+}
+
 func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDescriptor, r *grpcRequest, s *step) error {
 	o := s.parent
 	if len(r.messages) != 1 {
@@ -293,7 +494,7 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		resTrailers metadata.MD
 	)
 	res := dynamicpb.NewMessage(md.Output())
-	err := rnr.cc.Invoke(ctx, toEndpoint(md.FullName()), req, res, grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))
+	err := rnr.conn().Invoke(ctx, toEndpoint(md.FullName()), req, res, append(rnr.callOptions(), grpc.Header(&resHeaders), grpc.Trailer(&resTrailers))...)
 	stat, ok := status.FromError(err)
 	if !ok {
 		return err
@@ -304,9 +505,10 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		string(grpcStoreHeaderKey):  resHeaders,
 		string(grpcStoreTrailerKey): resTrailers,
 		string(grpcStoreMessageKey): nil,
+		string(grpcStoreDetailsKey): []any{},
 	}
 
-	o.capturers.captureGRPCResponseStatus(stat)
+	o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 	o.capturers.captureGRPCResponseHeaders(resHeaders)
 	o.capturers.captureGRPCResponseTrailers(resTrailers)
 
@@ -328,6 +530,7 @@ func (rnr *grpcRunner) invokeUnary(ctx context.Context, md protoreflect.MethodDe
 		d[grpcStoreMessagesKey] = messages
 	} else {
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
 	}
 
 	o.record(s.idx, map[string]any{
@@ -362,7 +565,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		ClientStreams: md.IsStreamingClient(),
 	}
 
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	stream, err := rnr.conn().NewStream(ctx, streamDesc, toEndpoint(md.FullName()), rnr.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -374,6 +577,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		string(grpcStoreHeaderKey):  metadata.MD{},
 		string(grpcStoreTrailerKey): metadata.MD{},
 		string(grpcStoreMessageKey): nil,
+		string(grpcStoreDetailsKey): []any{},
 	}
 	var messages []map[string]any
 
@@ -394,7 +598,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 		}
 		d[grpcStoreStatusKey] = int64(stat.Code())
 
-		o.capturers.captureGRPCResponseStatus(stat)
+		o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 
 		if stat.Code() == codes.OK {
 			b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(res)
@@ -412,6 +616,7 @@ func (rnr *grpcRunner) invokeServerStreaming(ctx context.Context, md protoreflec
 			messages = append(messages, msg)
 		} else {
 			d[grpcStoreMessageKey] = stat.Message()
+			d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
 		}
 	}
 	d[grpcStoreMessagesKey] = messages
@@ -449,7 +654,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		ServerStreams: md.IsStreamingServer(),
 		ClientStreams: md.IsStreamingClient(),
 	}
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	stream, err := rnr.conn().NewStream(ctx, streamDesc, toEndpoint(md.FullName()), rnr.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -457,6 +662,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		string(grpcStoreHeaderKey):  metadata.MD{},
 		string(grpcStoreTrailerKey): metadata.MD{},
 		string(grpcStoreMessageKey): nil,
+		string(grpcStoreDetailsKey): []any{},
 	}
 	var messages []map[string]any
 	for _, m := range r.messages {
@@ -491,7 +697,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 
 	d[grpcStoreStatusKey] = int64(stat.Code())
 
-	o.capturers.captureGRPCResponseStatus(stat)
+	o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 
 	if stat.Code() == codes.OK {
 		b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(res)
@@ -509,6 +715,7 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 		messages = append(messages, msg)
 	} else {
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
 	}
 
 	d[grpcStoreMessagesKey] = messages
@@ -531,10 +738,6 @@ func (rnr *grpcRunner) invokeClientStreaming(ctx context.Context, md protoreflec
 
 func (rnr *grpcRunner) invokeBidiStreaming(ctx context.Context, md protoreflect.MethodDescriptor, r *grpcRequest, s *step) error {
 	o := s.parent
-	if r.timeout > 0 {
-		return errors.New("unsupported timeout: for bidirectional streaming RPC")
-	}
-
 	ctx = setHeaders(ctx, r.headers)
 	o.capturers.captureGRPCRequestHeaders(r.headers)
 
@@ -544,7 +747,16 @@ func (rnr *grpcRunner) invokeBidiStreaming(ctx context.Context, md protoreflect.
 		ClientStreams: md.IsStreamingClient(),
 	}
 
-	stream, err := rnr.cc.NewStream(ctx, streamDesc, toEndpoint(md.FullName()))
+	// Cancel the stream when returning before the end of the stream ( e.g. timeout or test failure )
+	var cancel context.CancelFunc
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	stream, err := rnr.conn().NewStream(ctx, streamDesc, toEndpoint(md.FullName()), rnr.callOptions()...)
 	if err != nil {
 		return err
 	}
@@ -553,15 +765,23 @@ func (rnr *grpcRunner) invokeBidiStreaming(ctx context.Context, md protoreflect.
 		string(grpcStoreHeaderKey):  metadata.MD{},
 		string(grpcStoreTrailerKey): metadata.MD{},
 		string(grpcStoreMessageKey): nil,
+		string(grpcStoreDetailsKey): []any{},
 	}
 	var messages []map[string]any
+	// current returns the result in progress so that later ops can reference earlier received messages.
+	current := func() map[string]any {
+		d[grpcStoreMessagesKey] = messages
+		return map[string]any{
+			grpcStoreResponseKey: d,
+		}
+	}
 	clientClose := false
 L:
-	for _, m := range r.messages {
+	for i, m := range r.messages {
 		switch m.op {
 		case GRPCOpMessage:
 			req := dynamicpb.NewMessage(md.Input())
-			if err := rnr.setMessage(req, m.params, s); err != nil {
+			if err := rnr.setMessageWithCurrent(req, m.params, current(), s); err != nil {
 				return err
 			}
 			err = stream.SendMsg(req)
@@ -574,28 +794,44 @@ L:
 
 			req.Reset()
 		case GRPCOpReceive:
-			res := dynamicpb.NewMessage(md.Output())
-			err := stream.RecvMsg(res)
-			if errors.Is(err, context.Canceled) {
-				break L
+			// The timeout is for the whole receive op, not for each message
+			var deadline time.Time
+			if m.timeout > 0 {
+				deadline = time.Now().Add(m.timeout)
 			}
-			if errors.Is(err, io.EOF) {
-				break L
-			}
-			stat, ok := status.FromError(err)
-			if !ok {
-				return err
-			}
-			d[grpcStoreStatusKey] = int64(stat.Code())
+			for {
+				res := dynamicpb.NewMessage(md.Output())
+				err := recvMsgWithDeadline(stream, res, deadline)
+				if errors.Is(err, context.Canceled) {
+					break L
+				}
+				if errors.Is(err, io.EOF) {
+					break L
+				}
+				if errors.Is(err, errGRPCReceiveTimeout) {
+					if m.until != "" {
+						return fmt.Errorf("timeout waiting for message matching %q", m.until)
+					}
+					return err
+				}
+				stat, ok := status.FromError(err)
+				if !ok {
+					return err
+				}
+				d[grpcStoreStatusKey] = int64(stat.Code())
 
-			o.capturers.captureGRPCResponseStatus(stat)
+				o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 
-			if h, err := stream.Header(); err == nil {
-				d[grpcStoreHeaderKey] = h
+				if h, err := stream.Header(); err == nil {
+					d[grpcStoreHeaderKey] = h
 
-				o.capturers.captureGRPCResponseHeaders(h)
-			}
-			if stat.Code() == codes.OK {
+					o.capturers.captureGRPCResponseHeaders(h)
+				}
+				if stat.Code() != codes.OK {
+					d[grpcStoreMessageKey] = stat.Message()
+					d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
+					break
+				}
 				b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(res)
 				if err != nil {
 					return err
@@ -609,8 +845,35 @@ L:
 				o.capturers.captureGRPCResponseMessage(msg)
 
 				messages = append(messages, msg)
-			} else {
-				d[grpcStoreMessageKey] = stat.Message()
+				if m.until == "" {
+					break
+				}
+				sm := o.envBeforeRecord(s)
+				sm[store.RootKeyCurrent] = current()
+				tf, err := expr.EvalCond(m.until, sm)
+				if err != nil {
+					return err
+				}
+				if tf {
+					break
+				}
+			}
+		case GRPCOpTest:
+			if o.skipTest {
+				continue
+			}
+			sm := o.envBeforeRecord(s)
+			sm[store.RootKeyCurrent] = current()
+			tf, err := expr.EvalWithTrace(m.cond, exprtrace.EvalEnv(sm))
+			if err != nil {
+				return err
+			}
+			if !tf.OutputAsBool() {
+				t, err := tf.FormatTraceTree()
+				if err != nil {
+					return err
+				}
+				return fmt.Errorf("test failed on messages[%d]: %w", i, newCondFalseError(m.cond, t))
 			}
 		case GRPCOpClose:
 			clientClose = true
@@ -628,8 +891,9 @@ L:
 	if stat.Code() != codes.OK {
 		d[grpcStoreStatusKey] = int64(stat.Code())
 		d[grpcStoreMessageKey] = stat.Message()
+		d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
 
-		o.capturers.captureGRPCResponseStatus(stat)
+		o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 	}
 
 	if clientClose {
@@ -642,7 +906,17 @@ L:
 				if errors.Is(err, io.EOF) {
 					break
 				}
-				return err
+				stat, ok := status.FromError(err)
+				if !ok {
+					return err
+				}
+				// e.g. DeadlineExceeded by timeout
+				d[grpcStoreStatusKey] = int64(stat.Code())
+				d[grpcStoreMessageKey] = stat.Message()
+				d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
+
+				o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
+				break
 			} else {
 				if err := stream.CloseSend(); err != nil {
 					return err
@@ -666,7 +940,7 @@ L:
 				}
 				d[grpcStoreStatusKey] = int64(stat.Code())
 
-				o.capturers.captureGRPCResponseStatus(stat)
+				o.capturers.captureGRPCResponseStatus(stat, rnr.mds)
 				if stat.Code() == codes.OK {
 					b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(res)
 					if err != nil {
@@ -683,6 +957,7 @@ L:
 					messages = append(messages, msg)
 				} else {
 					d[grpcStoreMessageKey] = stat.Message()
+					d[grpcStoreDetailsKey] = rnr.statusDetails(stat)
 				}
 			}
 		}
@@ -718,6 +993,149 @@ L:
 	return nil
 }
 
+// applyTransportConfig sets the transport settings ( compression, message size limits, keepalive and timeout ) of the config.
+func (rnr *grpcRunner) applyTransportConfig(c *grpcRunnerConfig) error {
+	if c.Compression != "" && c.Compression != "identity" {
+		if encoding.GetCompressor(c.Compression) == nil {
+			return fmt.Errorf("unsupported compression: %s", c.Compression)
+		}
+		rnr.compression = c.Compression
+	}
+	if c.MaxSendMsgSize != "" {
+		size, err := parseMsgSize(c.MaxSendMsgSize)
+		if err != nil {
+			return fmt.Errorf("invalid maxSendMsgSize: %w", err)
+		}
+		rnr.maxSendMsgSize = size
+	}
+	if c.MaxRecvMsgSize != "" {
+		size, err := parseMsgSize(c.MaxRecvMsgSize)
+		if err != nil {
+			return fmt.Errorf("invalid maxRecvMsgSize: %w", err)
+		}
+		rnr.maxRecvMsgSize = size
+	}
+	if c.Keepalive != nil {
+		kp := &keepalive.ClientParameters{
+			PermitWithoutStream: c.Keepalive.PermitWithoutStream,
+		}
+		if c.Keepalive.Time != "" {
+			d, err := parseDuration(c.Keepalive.Time)
+			if err != nil {
+				return fmt.Errorf("invalid keepalive time: %w", err)
+			}
+			kp.Time = d
+		}
+		if c.Keepalive.Timeout != "" {
+			d, err := parseDuration(c.Keepalive.Timeout)
+			if err != nil {
+				return fmt.Errorf("invalid keepalive timeout: %w", err)
+			}
+			kp.Timeout = d
+		}
+		rnr.keepalive = kp
+	}
+	if c.Timeout != "" {
+		d, err := parseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %w", err)
+		}
+		rnr.timeout = d
+	}
+	return nil
+}
+
+// callOptions returns the call options of RPCs. They are not applied to server reflection.
+func (rnr *grpcRunner) callOptions() []grpc.CallOption {
+	var opts []grpc.CallOption
+	if rnr.compression != "" {
+		opts = append(opts, grpc.UseCompressor(rnr.compression))
+	}
+	if rnr.maxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxCallSendMsgSize(rnr.maxSendMsgSize))
+	}
+	if rnr.maxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxCallRecvMsgSize(rnr.maxRecvMsgSize))
+	}
+	return opts
+}
+
+// settings returns the effective settings of the runner for debug output.
+func (rnr *grpcRunner) settings() string {
+	protocol := rnr.protocol
+	if protocol == "" {
+		protocol = GRPCProtocolGRPC
+	}
+	compression := rnr.compression
+	if compression == "" {
+		compression = "identity"
+	}
+	size := func(s int) string {
+		if s <= 0 {
+			return "default"
+		}
+		return humanize.IBytes(uint64(s)) //nolint:gosec
+	}
+	ka := "default"
+	if rnr.keepalive != nil {
+		ka = fmt.Sprintf("time=%s timeout=%s permitWithoutStream=%t", rnr.keepalive.Time, rnr.keepalive.Timeout, rnr.keepalive.PermitWithoutStream)
+	}
+	timeout := "none"
+	if rnr.timeout > 0 {
+		timeout = rnr.timeout.String()
+	}
+	return fmt.Sprintf("protocol: %s, tls: %t, compression: %s, maxSendMsgSize: %s, maxRecvMsgSize: %s, keepalive: %s, timeout: %s",
+		protocol, rnr.useTLS(), compression, size(rnr.maxSendMsgSize), size(rnr.maxRecvMsgSize), ka, timeout)
+}
+
+// parseMsgSize parses the size of messages ( e.g. 1024, 4MB or 16MiB ).
+func parseMsgSize(v string) (int, error) {
+	size, err := humanize.ParseBytes(v)
+	if err != nil {
+		return 0, err
+	}
+	if size > math.MaxInt32 {
+		return 0, fmt.Errorf("too large size: %s", v)
+	}
+	return int(size), nil
+}
+
+// unixSocketAddr returns the address of the Unix domain socket if the target is unix:path, unix:///path or unix-abstract:name.
+func unixSocketAddr(target string) (string, bool) {
+	switch {
+	case strings.HasPrefix(target, "unix-abstract:"):
+		return "@" + strings.TrimPrefix(target, "unix-abstract:"), true
+	case strings.HasPrefix(target, "unix://"):
+		return strings.TrimPrefix(target, "unix://"), true
+	case strings.HasPrefix(target, "unix:"):
+		return strings.TrimPrefix(target, "unix:"), true
+	default:
+		return "", false
+	}
+}
+
+// recvMsgWithDeadline receives a message from the stream. It returns errGRPCReceiveTimeout if no message is received by the deadline.
+// The zero deadline means no deadline.
+func recvMsgWithDeadline(stream grpc.ClientStream, m any, deadline time.Time) error {
+	if deadline.IsZero() {
+		return stream.RecvMsg(m)
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return errGRPCReceiveTimeout
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- stream.RecvMsg(m)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-time.After(timeout):
+		return errGRPCReceiveTimeout
+	}
+}
+
 func setHeaders(ctx context.Context, h metadata.MD) context.Context {
 	var kv []string
 	for k, v := range h {
@@ -730,9 +1148,18 @@ func setHeaders(ctx context.Context, h metadata.MD) context.Context {
 }
 
 func (rnr *grpcRunner) setMessage(req proto.Message, message map[string]any, s *step) error {
+	return rnr.setMessageWithCurrent(req, message, nil, s)
+}
+
+// setMessageWithCurrent sets the message expanded with the result in progress as `current`.
+func (rnr *grpcRunner) setMessageWithCurrent(req proto.Message, message, current map[string]any, s *step) error {
 	o := s.parent
 	// Lazy expand due to the possibility of computing variables between multiple messages.
-	e, err := o.expandBeforeRecord(message, s)
+	sm := o.envBeforeRecord(s)
+	if current != nil {
+		sm[store.RootKeyCurrent] = current
+	}
+	e, err := expr.EvalExpand(message, sm)
 	if err != nil {
 		return err
 	}
@@ -790,29 +1217,129 @@ func (rnr *grpcRunner) findDescripter(svc protoreflect.FullName) (protoreflect.D
 	return protoregistry.GlobalFiles.FindDescriptorByName(svc)
 }
 
+// descriptorsKey returns the key of the method descriptors in the cache.
+// Runners created with the runn.GrpcRunner option are not cached because their targets are unknown.
+func (rnr *grpcRunner) descriptorsKey() string {
+	if rnr.target == "" {
+		return ""
+	}
+	return strings.Join([]string{
+		rnr.target,
+		strings.Join(rnr.importPaths, ","),
+		strings.Join(rnr.protos, ","),
+		strings.Join(rnr.protosets, ","),
+		strings.Join(rnr.bufDirs, ","),
+		strings.Join(rnr.bufLocks, ","),
+		strings.Join(rnr.bufConfigs, ","),
+		strings.Join(rnr.bufModules, ","),
+	}, "|")
+}
+
+// grpcDescriptors is the cache of method descriptors resolved by gRPC runners, shared across runs ( e.g. `runn run --watch` ).
+type grpcDescriptors struct {
+	mds map[string]map[string]protoreflect.MethodDescriptor
+	mu  sync.Mutex
+}
+
+func newGRPCDescriptors() *grpcDescriptors {
+	return &grpcDescriptors{
+		mds: map[string]map[string]protoreflect.MethodDescriptor{},
+	}
+}
+
+func (d *grpcDescriptors) load(key string) (map[string]protoreflect.MethodDescriptor, bool) {
+	if key == "" {
+		return nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	mds, ok := d.mds[key]
+	if !ok {
+		return nil, false
+	}
+	return maps.Clone(mds), true
+}
+
+func (d *grpcDescriptors) store(key string, mds map[string]protoreflect.MethodDescriptor) {
+	if key == "" || len(mds) == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mds[key] = maps.Clone(mds)
+}
+
 func (rnr *grpcRunner) resolveAllMethodsUsingProtos(ctx context.Context) error {
-	protos, err := fetchPaths(strings.Join(rnr.protos, string(os.PathListSeparator)))
+	if len(rnr.protosets) > 0 {
+		fds, err := loadProtosets(rnr.protosets)
+		if err != nil {
+			return err
+		}
+		maps.Copy(rnr.mds, methodDescriptors(fds))
+		if len(rnr.importPaths) == 0 && len(rnr.protos) == 0 && len(rnr.bufDirs) == 0 && len(rnr.bufLocks) == 0 && len(rnr.bufConfigs) == 0 && len(rnr.bufModules) == 0 {
+			// No need to compile
+			return nil
+		}
+	}
+	mds, err := resolveMethodsUsingProtos(ctx, rnr.importPaths, rnr.protos, rnr.bufDirs, rnr.bufLocks, rnr.bufConfigs, rnr.bufModules)
 	if err != nil {
 		return err
 	}
-	pr, err := protoresolv.New(rnr.importPaths, protoresolv.Proto(protos...))
+	for k, md := range mds {
+		rnr.mds[k] = md
+	}
+	return nil
+}
+
+// resolveMethodsUsingProtos compiles proto files and returns method descriptors keyed by `package.Service/Method`.
+func resolveMethodsUsingProtos(ctx context.Context, importPaths, protoPaths, bufDirs, bufLocks, bufConfigs, bufModules []string) (map[string]protoreflect.MethodDescriptor, error) {
+	fds, err := compileProtos(ctx, importPaths, protoPaths, bufDirs, bufLocks, bufConfigs, bufModules)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return methodDescriptors(fds), nil
+}
+
+// methodDescriptors returns method descriptors in the files keyed by `package.Service/Method`.
+func methodDescriptors(fds linker.Files) map[string]protoreflect.MethodDescriptor {
+	mds := map[string]protoreflect.MethodDescriptor{}
+	for _, fd := range fds {
+		for i := 0; i < fd.Services().Len(); i++ {
+			svc := fd.Services().Get(i)
+			for j := 0; j < svc.Methods().Len(); j++ {
+				m := svc.Methods().Get(j)
+				key := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
+				mds[key] = m
+			}
+		}
+	}
+	return mds
+}
+
+// compileProtos compiles proto files ( and buf modules ) and registers them to protoregistry.GlobalFiles.
+func compileProtos(ctx context.Context, importPaths, protoPaths, bufDirs, bufLocks, bufConfigs, bufModules []string) (linker.Files, error) {
+	protos, err := fetchPaths(strings.Join(protoPaths, string(os.PathListSeparator)))
+	if err != nil {
+		return nil, err
+	}
+	pr, err := protoresolv.New(importPaths, protoresolv.Proto(protos...))
+	if err != nil {
+		return nil, err
 	}
 	var bufresolvOpts []bufresolv.Option
-	for _, d := range rnr.bufDirs {
+	for _, d := range bufDirs {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufDir(d))
 	}
-	for _, c := range rnr.bufConfigs {
+	for _, c := range bufConfigs {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufConfig(c))
 	}
-	for _, l := range rnr.bufLocks {
+	for _, l := range bufLocks {
 		bufresolvOpts = append(bufresolvOpts, bufresolv.BufLock(l))
 	}
-	bufresolvOpts = append(bufresolvOpts, bufresolv.BufModule(rnr.bufModules...))
+	bufresolvOpts = append(bufresolvOpts, bufresolv.BufModule(bufModules...))
 	br, err := bufresolv.New(bufresolvOpts...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	comp := protocompile.Compiler{
 		Resolver: protocompile.WithStandardImports(protocompile.CompositeResolver([]protocompile.Resolver{
@@ -822,22 +1349,103 @@ func (rnr *grpcRunner) resolveAllMethodsUsingProtos(ctx context.Context) error {
 	protos = unique(slices.Concat(pr.Paths(), br.Paths()))
 	fds, err := comp.Compile(ctx, protos...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if err := registerFiles(fds); err != nil {
-		return err
+		return nil, err
 	}
-	for _, fd := range fds {
-		for i := 0; i < fd.Services().Len(); i++ {
-			svc := fd.Services().Get(i)
-			for j := 0; j < svc.Methods().Len(); j++ {
-				m := svc.Methods().Get(j)
-				key := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
-				rnr.mds[key] = m
+	return fds, nil
+}
+
+// loadProtosets reads protoset files ( serialized FileDescriptorSet ) without compiling and registers them to protoregistry.GlobalFiles.
+// Dependencies not included in the protoset files ( e.g. well-known types ) are resolved from protoregistry.GlobalFiles.
+func loadProtosets(protosetPaths []string) (linker.Files, error) {
+	paths, err := fetchPaths(strings.Join(protosetPaths, string(os.PathListSeparator)))
+	if err != nil {
+		return nil, err
+	}
+	fdps := map[string]*descriptorpb.FileDescriptorProto{}
+	var names []string
+	for _, p := range paths {
+		b, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(b, set); err != nil {
+			return nil, fmt.Errorf("invalid protoset %s: %w", p, err)
+		}
+		for _, fdp := range set.GetFile() {
+			if _, ok := fdps[fdp.GetName()]; ok {
+				continue
 			}
+			fdps[fdp.GetName()] = fdp
+			names = append(names, fdp.GetName())
 		}
 	}
-	return nil
+	files := &protoregistry.Files{}
+	r := &protosetResolver{files: files}
+	var build func(name string, stack []string) (protoreflect.FileDescriptor, error)
+	build = func(name string, stack []string) (protoreflect.FileDescriptor, error) {
+		if fd, err := files.FindFileByPath(name); err == nil {
+			return fd, nil
+		}
+		fdp, ok := fdps[name]
+		if !ok {
+			return protoregistry.GlobalFiles.FindFileByPath(name)
+		}
+		if slices.Contains(stack, name) {
+			return nil, fmt.Errorf("import cycle in protoset: %s", strings.Join(append(stack, name), " -> "))
+		}
+		for _, dep := range fdp.GetDependency() {
+			if _, err := build(dep, append(stack, name)); err != nil {
+				return nil, fmt.Errorf("failed to resolve %s imported by %s: %w", dep, name, err)
+			}
+		}
+		fd, err := protodesc.NewFile(fdp, r)
+		if err != nil {
+			return nil, err
+		}
+		if err := files.RegisterFile(fd); err != nil {
+			return nil, err
+		}
+		return fd, nil
+	}
+	var lfds linker.Files
+	for _, name := range names {
+		fd, err := build(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		lfd, err := linker.NewFileRecursive(fd)
+		if err != nil {
+			return nil, err
+		}
+		lfds = append(lfds, lfd)
+	}
+	if err := registerFiles(lfds); err != nil {
+		return nil, err
+	}
+	return lfds, nil
+}
+
+// protosetResolver resolves descriptors in the protoset files first, then in protoregistry.GlobalFiles.
+type protosetResolver struct {
+	files *protoregistry.Files
+}
+
+func (r *protosetResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *protosetResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
 }
 
 func (r *grpcRequest) setTraceHeader(s *step) error {
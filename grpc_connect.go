@@ -0,0 +1,286 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/k1LoW/runn/version"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	GRPCProtocolGRPC    = "grpc"
+	GRPCProtocolGRPCWeb = "grpcweb"
+	GRPCProtocolConnect = "connect"
+)
+
+const grpcDetailTypeURLPrefix = "type.googleapis.com/"
+
+func validGRPCProtocol(p string) bool {
+	switch p {
+	case "", GRPCProtocolGRPC, GRPCProtocolGRPCWeb, GRPCProtocolConnect:
+		return true
+	default:
+		return false
+	}
+}
+
+// connectConn is a grpc.ClientConnInterface that invokes RPCs using the gRPC-Web or Connect protocol over net/http.
+// Only unary and server streaming RPCs are supported.
+type connectConn struct {
+	protocol          string
+	baseURL           string
+	client            *http.Client
+	findMethod        func(method string) (protoreflect.MethodDescriptor, bool)
+	unaryInterceptor  grpc.UnaryClientInterceptor
+	streamInterceptor grpc.StreamClientInterceptor
+	opts              []connect.ClientOption
+}
+
+var _ grpc.ClientConnInterface = (*connectConn)(nil)
+
+func (c *connectConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	if c.unaryInterceptor != nil {
+		return c.unaryInterceptor(ctx, method, args, reply, nil, c.invoke, opts...)
+	}
+	return c.invoke(ctx, method, args, reply, nil, opts...)
+}
+
+func (c *connectConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if c.streamInterceptor != nil {
+		return c.streamInterceptor(ctx, desc, nil, method, c.newStream, opts...)
+	}
+	return c.newStream(ctx, desc, nil, method, opts...)
+}
+
+func (c *connectConn) Close() {
+	c.client.CloseIdleConnections()
+}
+
+func (c *connectConn) invoke(ctx context.Context, method string, args, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+	client, err := c.newClient(method)
+	if err != nil {
+		return err
+	}
+	req, ok := args.(*dynamicpb.Message)
+	if !ok {
+		return fmt.Errorf("invalid request: %v", args)
+	}
+	creq := connect.NewRequest(req)
+	setConnectRequestHeader(ctx, creq.Header())
+	res, err := client.CallUnary(ctx, creq)
+	if err != nil {
+		var cerr *connect.Error
+		if errors.As(err, &cerr) {
+			// The headers and trailers of the error response are merged into the metadata of the error
+			setConnectCallOptions(opts, cerr.Meta(), nil)
+		}
+		return connectErrorToStatus(err)
+	}
+	setConnectCallOptions(opts, res.Header(), res.Trailer())
+	m, ok := reply.(proto.Message)
+	if !ok {
+		return fmt.Errorf("invalid reply: %v", reply)
+	}
+	proto.Merge(m, res.Msg)
+	return nil
+}
+
+func (c *connectConn) newStream(ctx context.Context, desc *grpc.StreamDesc, _ *grpc.ClientConn, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	if desc.ClientStreams {
+		return nil, status.Errorf(codes.Unimplemented, "client streaming and bidirectional streaming RPCs are not supported with protocol %s: %s", c.protocol, method)
+	}
+	client, err := c.newClient(method)
+	if err != nil {
+		return nil, err
+	}
+	return &connectClientStream{ctx: ctx, client: client}, nil
+}
+
+func (c *connectConn) newClient(method string) (*connect.Client[dynamicpb.Message, dynamicpb.Message], error) {
+	md, ok := c.findMethod(strings.TrimPrefix(method, "/"))
+	if !ok {
+		return nil, fmt.Errorf("cannot find method: %s", method)
+	}
+	opts := []connect.ClientOption{
+		connect.WithClientOptions(c.opts...),
+		connect.WithSchema(md),
+		connect.WithResponseInitializer(func(_ connect.Spec, msg any) error {
+			m, ok := msg.(*dynamicpb.Message)
+			if !ok {
+				return fmt.Errorf("invalid response: %v", msg)
+			}
+			*m = *dynamicpb.NewMessage(md.Output())
+			return nil
+		}),
+	}
+	switch c.protocol {
+	case GRPCProtocolGRPCWeb:
+		opts = append(opts, connect.WithGRPCWeb())
+	case GRPCProtocolConnect:
+		opts = append(opts, connect.WithProtoJSON())
+	}
+	return connect.NewClient[dynamicpb.Message, dynamicpb.Message](c.client, c.baseURL+method, opts...), nil
+}
+
+// connectClientStream is a grpc.ClientStream for server streaming RPCs.
+// The request is sent when the first response is received.
+type connectClientStream struct {
+	ctx    context.Context
+	client *connect.Client[dynamicpb.Message, dynamicpb.Message]
+	req    *dynamicpb.Message
+	stream *connect.ServerStreamForClient[dynamicpb.Message]
+	header http.Header
+}
+
+func (s *connectClientStream) Header() (metadata.MD, error) {
+	if s.header != nil {
+		return connectHeaderToMetadata(s.header), nil
+	}
+	if s.stream == nil {
+		return metadata.MD{}, nil
+	}
+	return connectHeaderToMetadata(s.stream.ResponseHeader()), nil
+}
+
+func (s *connectClientStream) Trailer() metadata.MD {
+	if s.stream == nil {
+		return metadata.MD{}
+	}
+	return connectHeaderToMetadata(s.stream.ResponseTrailer())
+}
+
+func (s *connectClientStream) CloseSend() error {
+	return nil
+}
+
+func (s *connectClientStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *connectClientStream) SendMsg(m any) error {
+	if s.req != nil {
+		return errors.New("server streaming RPC message should be 1")
+	}
+	req, ok := m.(*dynamicpb.Message)
+	if !ok {
+		return fmt.Errorf("invalid request: %v", m)
+	}
+	s.req = req
+	return nil
+}
+
+func (s *connectClientStream) RecvMsg(m any) error {
+	if s.stream == nil {
+		if s.req == nil {
+			return errors.New("no request message has been sent")
+		}
+		creq := connect.NewRequest(s.req)
+		setConnectRequestHeader(s.ctx, creq.Header())
+		stream, err := s.client.CallServerStream(s.ctx, creq)
+		if err != nil {
+			var cerr *connect.Error
+			if errors.As(err, &cerr) {
+				s.header = cerr.Meta()
+			}
+			return connectErrorToStatus(err)
+		}
+		s.stream = stream
+	}
+	if s.stream.Receive() {
+		pm, ok := m.(proto.Message)
+		if !ok {
+			return fmt.Errorf("invalid message: %v", m)
+		}
+		proto.Merge(pm, s.stream.Msg())
+		return nil
+	}
+	err := s.stream.Err()
+	var cerr *connect.Error
+	if errors.As(err, &cerr) && len(s.stream.ResponseHeader()) == 0 {
+		// An error without response ( trailers-only ) has the headers in the metadata of the error
+		s.header = cerr.Meta()
+	}
+	_ = s.stream.Close()
+	if err != nil {
+		return connectErrorToStatus(err)
+	}
+	return io.EOF
+}
+
+func setConnectRequestHeader(ctx context.Context, h http.Header) {
+	h.Set("User-Agent", fmt.Sprintf("runn/%s connect-go/%s", version.Version, connect.Version))
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return
+	}
+	for k, vs := range md {
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				v = connect.EncodeBinaryHeader([]byte(v))
+			}
+			h.Add(k, v)
+		}
+	}
+}
+
+func setConnectCallOptions(opts []grpc.CallOption, header, trailer http.Header) {
+	for _, opt := range opts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = connectHeaderToMetadata(header)
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = connectHeaderToMetadata(trailer)
+		}
+	}
+}
+
+func connectHeaderToMetadata(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, vs := range h {
+		k = strings.ToLower(k)
+		for _, v := range vs {
+			if strings.HasSuffix(k, "-bin") {
+				b, err := connect.DecodeBinaryHeader(v)
+				if err != nil {
+					continue
+				}
+				v = string(b)
+			}
+			md.Append(k, v)
+		}
+	}
+	return md
+}
+
+// connectErrorToStatus converts *connect.Error to the error of *status.Status so that it is stored in the same way as gRPC.
+func connectErrorToStatus(err error) error {
+	var cerr *connect.Error
+	if !errors.As(err, &cerr) {
+		return err
+	}
+	sp := &spb.Status{
+		Code:    int32(cerr.Code()), //nolint:gosec
+		Message: cerr.Message(),
+	}
+	for _, d := range cerr.Details() {
+		sp.Details = append(sp.Details, &anypb.Any{
+			TypeUrl: grpcDetailTypeURLPrefix + d.Type(),
+			Value:   d.Bytes(),
+		})
+	}
+	return status.FromProto(sp).Err()
+}
@@ -0,0 +1,230 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"github.com/spf13/cast"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func newConnectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	fd, err := protodesc.NewFile(grpcserverFileDescriptorProto(t), protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := fd.Services().ByName("GreeterService")
+	hello := svc.Methods().ByName("Hello")
+	listHello := svc.Methods().ByName("ListHello")
+	opts := func(md protoreflect.MethodDescriptor) []connect.HandlerOption {
+		return []connect.HandlerOption{
+			connect.WithSchema(md),
+			connect.WithRequestInitializer(func(_ connect.Spec, msg any) error {
+				m, ok := msg.(*dynamicpb.Message)
+				if !ok {
+					return fmt.Errorf("invalid request: %v", msg)
+				}
+				*m = *dynamicpb.NewMessage(md.Input())
+				return nil
+			}),
+		}
+	}
+	newResponse := func(md protoreflect.MethodDescriptor, message string, num int32) *dynamicpb.Message {
+		res := dynamicpb.NewMessage(md.Output())
+		res.Set(md.Output().Fields().ByName("message"), protoreflect.ValueOfString(message))
+		res.Set(md.Output().Fields().ByName("num"), protoreflect.ValueOfInt32(num))
+		return res
+	}
+	invalidArgument := func() error {
+		err := connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+		d, derr := connect.NewErrorDetail(&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "name", Description: "name is required"},
+			},
+		})
+		if derr != nil {
+			return derr
+		}
+		err.AddDetail(d)
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/grpcserver.GreeterService/Hello", connect.NewUnaryHandler(
+		"/grpcserver.GreeterService/Hello",
+		func(_ context.Context, req *connect.Request[dynamicpb.Message]) (*connect.Response[dynamicpb.Message], error) {
+			name := req.Msg.Get(hello.Input().Fields().ByName("name")).String()
+			if name == "" {
+				return nil, invalidArgument()
+			}
+			res := connect.NewResponse(newResponse(hello, fmt.Sprintf("hello %s", name), 1))
+			res.Header().Set("x-request-id", req.Header().Get("x-request-id"))
+			res.Trailer().Set("x-trailer", "bye")
+			return res, nil
+		},
+		opts(hello)...,
+	))
+	mux.Handle("/grpcserver.GreeterService/ListHello", connect.NewServerStreamHandler(
+		"/grpcserver.GreeterService/ListHello",
+		func(_ context.Context, req *connect.Request[dynamicpb.Message], stream *connect.ServerStream[dynamicpb.Message]) error {
+			name := req.Msg.Get(listHello.Input().Fields().ByName("name")).String()
+			if name == "" {
+				return invalidArgument()
+			}
+			stream.ResponseHeader().Set("x-request-id", req.Header().Get("x-request-id"))
+			for i := range 2 {
+				if err := stream.Send(newResponse(listHello, fmt.Sprintf("hello %s", name), int32(i+1))); err != nil { //nolint:gosec
+					return err
+				}
+			}
+			stream.ResponseTrailer().Set("x-trailer", "bye")
+			return nil
+		},
+		opts(listHello)...,
+	))
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestGrpcRunnerWithConnectProtocols(t *testing.T) {
+	ts := newConnectServer(t)
+	protoset := writeProtoset(t, grpcserverFileDescriptorProto(t))
+
+	tests := []struct {
+		method      string
+		name        string
+		wantStatus  int
+		wantMessage any
+		wantCount   int
+		wantErr     bool
+	}{
+		{"Hello", "alice", 0, map[string]any{"message": "hello alice", "num": float64(1), "create_time": nil}, 0, false},
+		{"Hello", "", 3, "name is required", 0, false},
+		{"ListHello", "alice", 0, map[string]any{"message": "hello alice", "num": float64(2), "create_time": nil}, 2, false},
+		{"ListHello", "", 3, "name is required", 0, false},
+		{"MultiHello", "alice", 0, nil, 0, true},
+	}
+	for _, protocol := range []string{GRPCProtocolGRPCWeb, GRPCProtocolConnect} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s %s %q", protocol, tt.method, tt.name), func(t *testing.T) {
+				ctx, cancel := donegroup.WithCancel(context.Background())
+				t.Cleanup(cancel)
+				o, err := New(Scopes(ScopeAllowReadParent))
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := newGrpcRunner("greq", strings.TrimPrefix(ts.URL, "http://"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() {
+					_ = r.Close()
+				})
+				useTLS := false
+				r.tls = &useTLS
+				r.protocol = protocol
+				r.protosets = []string{protoset}
+				if err := r.applyTransportConfig(&grpcRunnerConfig{Compression: "gzip", MaxRecvMsgSize: "1MB"}); err != nil {
+					t.Fatal(err)
+				}
+				req := &grpcRequest{
+					service: "grpcserver.GreeterService",
+					method:  tt.method,
+					headers: metadata.MD{"x-request-id": []string{"1234"}},
+					messages: []*grpcMessage{
+						{
+							op:     GRPCOpMessage,
+							params: map[string]any{"name": tt.name},
+						},
+					},
+				}
+				s := newStep(0, "stepKey", o, nil)
+				if err := r.run(ctx, req, s); err != nil {
+					if !tt.wantErr {
+						t.Errorf("got error: %v", err)
+					}
+					return
+				}
+				if tt.wantErr {
+					t.Fatal("want error")
+				}
+				res, ok := o.store.Latest()["res"].(map[string]any)
+				if !ok {
+					t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+				}
+				if diff := cmp.Diff(cast.ToInt(res["status"]), tt.wantStatus); diff != "" {
+					t.Error(diff)
+				}
+				if diff := cmp.Diff(res["message"], tt.wantMessage); diff != "" {
+					t.Error(diff)
+				}
+				if tt.wantStatus != 0 {
+					details, ok := res["details"].([]any)
+					if !ok || len(details) != 1 {
+						t.Fatalf("invalid details: %#v", res["details"])
+					}
+					if got := details[0].(map[string]any)["@type"]; got != "type.googleapis.com/google.rpc.BadRequest" {
+						t.Errorf("got %v", got)
+					}
+					return
+				}
+				h, ok := res["headers"].(metadata.MD)
+				if !ok {
+					t.Fatalf("invalid headers: %#v", res["headers"])
+				}
+				if diff := cmp.Diff(h.Get("x-request-id"), []string{"1234"}); diff != "" {
+					t.Error(diff)
+				}
+				tr, ok := res["trailers"].(metadata.MD)
+				if !ok {
+					t.Fatalf("invalid trailers: %#v", res["trailers"])
+				}
+				if diff := cmp.Diff(tr.Get("x-trailer"), []string{"bye"}); diff != "" {
+					t.Error(diff)
+				}
+				if tt.wantCount > 0 {
+					messages, ok := res["messages"].([]map[string]any)
+					if !ok {
+						t.Fatalf("invalid messages: %#v", res["messages"])
+					}
+					if len(messages) != tt.wantCount {
+						t.Errorf("got %v\nwant %v", len(messages), tt.wantCount)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGrpcRunnerWithConnectProtocolWithoutProtos(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", "localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.protocol = GRPCProtocolConnect
+	s := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, &grpcRequest{service: "grpcserver.GreeterService", method: "Hello"}, s); err == nil {
+		t.Error("want error")
+	}
+}
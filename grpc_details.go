@@ -0,0 +1,134 @@
+package runn
+
+import (
+	"strings"
+
+	"github.com/goccy/go-json"
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // register the well-known error details ( BadRequest, ErrorInfo, RetryInfo, ... )
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	grpcDetailTypeKey  = "@type"
+	grpcDetailValueKey = "value"
+)
+
+// grpcDetailsResolver resolves the message types of google.rpc.Status details.
+// The message types are looked up in the registered Go types ( including errdetails ), the registered proto files
+// and the proto files of the runner's method descriptors, in that order.
+type grpcDetailsResolver struct {
+	files []protoreflect.FileDescriptor
+}
+
+func newGRPCDetailsResolver(mds map[string]protoreflect.MethodDescriptor) *grpcDetailsResolver {
+	r := &grpcDetailsResolver{}
+	seen := map[string]struct{}{}
+	for _, md := range mds {
+		fd := md.ParentFile()
+		if _, ok := seen[fd.Path()]; ok {
+			continue
+		}
+		seen[fd.Path()] = struct{}{}
+		r.files = append(r.files, fd)
+	}
+	return r
+}
+
+func (r *grpcDetailsResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	if d, err := protoregistry.GlobalFiles.FindDescriptorByName(name); err == nil {
+		if md, ok := d.(protoreflect.MessageDescriptor); ok {
+			return dynamicpb.NewMessageType(md), nil
+		}
+	}
+	seen := map[string]struct{}{}
+	for _, fd := range r.files {
+		if md := findMessageInFile(fd, name, seen); md != nil {
+			return dynamicpb.NewMessageType(md), nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (r *grpcDetailsResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (r *grpcDetailsResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (r *grpcDetailsResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// findMessageInFile finds the message descriptor in the file and its imports.
+func findMessageInFile(fd protoreflect.FileDescriptor, name protoreflect.FullName, seen map[string]struct{}) protoreflect.MessageDescriptor {
+	if _, ok := seen[fd.Path()]; ok {
+		return nil
+	}
+	seen[fd.Path()] = struct{}{}
+	if md := findMessageInMessages(fd.Messages(), name); md != nil {
+		return md
+	}
+	imports := fd.Imports()
+	for i := range imports.Len() {
+		if md := findMessageInFile(imports.Get(i).FileDescriptor, name, seen); md != nil {
+			return md
+		}
+	}
+	return nil
+}
+
+func findMessageInMessages(mds protoreflect.MessageDescriptors, name protoreflect.FullName) protoreflect.MessageDescriptor {
+	for i := range mds.Len() {
+		md := mds.Get(i)
+		if md.FullName() == name {
+			return md
+		}
+		if strings.HasPrefix(string(name), string(md.FullName())+".") {
+			if nmd := findMessageInMessages(md.Messages(), name); nmd != nil {
+				return nmd
+			}
+		}
+	}
+	return nil
+}
+
+// statusDetails returns the details of the status as maps.
+// Each detail has the type URL in `@type`. A detail whose message type cannot be resolved has the raw bytes in `value`.
+func (rnr *grpcRunner) statusDetails(stat *status.Status) []any {
+	return grpcStatusDetails(stat, newGRPCDetailsResolver(rnr.mds))
+}
+
+func grpcStatusDetails(stat *status.Status, r *grpcDetailsResolver) []any {
+	details := []any{}
+	for _, a := range stat.Proto().GetDetails() {
+		unresolved := map[string]any{
+			grpcDetailTypeKey:  a.GetTypeUrl(),
+			grpcDetailValueKey: a.GetValue(),
+		}
+		b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true, Resolver: r}.Marshal(a)
+		if err != nil {
+			details = append(details, unresolved)
+			continue
+		}
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			details = append(details, unresolved)
+			continue
+		}
+		details = append(details, m)
+	}
+	return details
+}
@@ -0,0 +1,134 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/grpcstub"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func testStatusWithDetails(t *testing.T) *status.Status {
+	t.Helper()
+	br, err := anypb.New(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "name", Description: "name is required"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// grpcserver.HelloResponse{message: "custom"} is not registered as the Go type
+	var custom []byte
+	custom = protowire.AppendTag(custom, 1, protowire.BytesType)
+	custom = protowire.AppendString(custom, "custom")
+	return status.FromProto(&spb.Status{
+		Code:    int32(codes.InvalidArgument),
+		Message: "invalid argument",
+		Details: []*anypb.Any{
+			br,
+			{TypeUrl: "type.googleapis.com/grpcserver.HelloResponse", Value: custom},
+			{TypeUrl: "type.googleapis.com/unknown.Detail", Value: []byte{0x08, 0x01}},
+		},
+	})
+}
+
+func TestGrpcRunnerWithStatusDetails(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ts := grpcstub.NewServer(t, filepath.Join(testutil.Testdata(), "grpcserver.proto"))
+	t.Cleanup(ts.Close)
+	ts.Method("grpcserver.GreeterService/Hello").Status(testStatusWithDetails(t))
+
+	out := new(bytes.Buffer)
+	o, err := New(Capture(NewDebugger(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", ts.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	useTLS := false
+	r.tls = &useTLS
+	req := &grpcRequest{
+		service: "grpcserver.GreeterService",
+		method:  "Hello",
+		messages: []*grpcMessage{
+			{
+				op:     GRPCOpMessage,
+				params: map[string]any{"name": "alice"},
+			},
+		},
+	}
+	s := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	want := []any{
+		map[string]any{
+			"@type": "type.googleapis.com/google.rpc.BadRequest",
+			"field_violations": []any{
+				map[string]any{
+					"field":             "name",
+					"description":       "name is required",
+					"reason":            "",
+					"localized_message": nil,
+				},
+			},
+		},
+		map[string]any{
+			"@type":       "type.googleapis.com/grpcserver.HelloResponse",
+			"message":     "custom",
+			"num":         float64(0),
+			"create_time": nil,
+		},
+		map[string]any{
+			"@type": "type.googleapis.com/unknown.Detail",
+			"value": []byte{0x08, 0x01},
+		},
+	}
+	if diff := cmp.Diff(res["details"], any(want)); diff != "" {
+		t.Error(diff)
+	}
+	tf, err := EvalCond(`res.details[0].field_violations[0].field == "name"`, map[string]any{"res": res})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tf {
+		t.Error("want true")
+	}
+	// the debugger also resolves the message types of the runner
+	wantOut := `{"@type":"type.googleapis.com/grpcserver.HelloResponse","create_time":null,"message":"custom","num":0}`
+	if !strings.Contains(out.String(), wantOut) {
+		t.Errorf("got %v\nwant to contain %v", out.String(), wantOut)
+	}
+}
+
+func TestDebuggerGRPCStatusDetails(t *testing.T) {
+	out := new(bytes.Buffer)
+	d := NewDebugger(out)
+	d.CaptureGRPCResponseStatus(testStatusWithDetails(t))
+	want := `{"@type":"type.googleapis.com/google.rpc.BadRequest","field_violations":[{"description":"name is required","field":"name","localized_message":null,"reason":""}]}`
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("got %v\nwant to contain %v", out.String(), want)
+	}
+}
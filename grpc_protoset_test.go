@@ -0,0 +1,164 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/grpcstub"
+	"github.com/k1LoW/runn/testutil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func writeProtoset(t *testing.T, fdps ...*descriptorpb.FileDescriptorProto) string {
+	t.Helper()
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: fdps})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "test.protoset")
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func grpcserverFileDescriptorProto(t *testing.T) *descriptorpb.FileDescriptorProto {
+	t.Helper()
+	comp := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{testutil.Testdata()},
+		}),
+	}
+	fds, err := comp.Compile(context.Background(), "grpcserver.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return protodesc.ToFileDescriptorProto(fds[0])
+}
+
+func TestLoadProtosets(t *testing.T) {
+	if _, err := New(Scopes(ScopeAllowReadParent)); err != nil {
+		t.Fatal(err)
+	}
+	missing := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("missing_dep.proto"),
+		Package:    proto.String("missingdep"),
+		Dependency: []string{"not/exist.proto"},
+		Syntax:     proto.String("proto3"),
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.protoset")
+	if err := os.WriteFile(invalid, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			"google/protobuf/timestamp.proto is resolved from the registered files",
+			writeProtoset(t, grpcserverFileDescriptorProto(t)),
+			[]string{
+				"grpcserver.GreeterService/Hello",
+				"grpcserver.GreeterService/HelloChat",
+				"grpcserver.GreeterService/ListHello",
+				"grpcserver.GreeterService/MultiHello",
+			},
+			false,
+		},
+		{
+			"missing dependency",
+			writeProtoset(t, missing),
+			nil,
+			true,
+		},
+		{
+			"invalid protoset",
+			invalid,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fds, err := loadProtosets([]string{tt.path})
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+				return
+			}
+			var got []string
+			for k := range methodDescriptors(fds) {
+				got = append(got, k)
+			}
+			slices.Sort(got)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerWithProtosets(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ts := grpcstub.NewServer(t, filepath.Join(testutil.Testdata(), "grpcserver.proto"), grpcstub.DisableReflection())
+	t.Cleanup(ts.Close)
+	ts.Method("grpcserver.GreeterService/Hello").ResponseString(`{"message":"hello", "num":3}`)
+
+	o, err := New(Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", ts.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	useTLS := false
+	r.tls = &useTLS
+	r.protosets = []string{writeProtoset(t, grpcserverFileDescriptorProto(t))}
+	req := &grpcRequest{
+		service: "grpcserver.GreeterService",
+		method:  "Hello",
+		messages: []*grpcMessage{
+			{
+				op:     GRPCOpMessage,
+				params: map[string]any{"name": "alice"},
+			},
+		},
+	}
+	s := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	want := map[string]any{
+		"message":     "hello",
+		"num":         float64(3),
+		"create_time": nil,
+	}
+	if diff := cmp.Diff(res["message"], any(want)); diff != "" {
+		t.Error(diff)
+	}
+}
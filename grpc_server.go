@@ -0,0 +1,552 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const grpcServerStoreAddrKey = "addr"
+
+const (
+	grpcServerRequestStoreMethodKey   = "method"
+	grpcServerRequestStoreHeaderKey   = "headers"
+	grpcServerRequestStoreMessageKey  = "message"
+	grpcServerRequestStoreMessagesKey = "messages"
+)
+
+const (
+	grpcServerResponseMatchKey    = "match"
+	grpcServerResponseHeaderKey   = "headers"
+	grpcServerResponseTrailerKey  = "trailers"
+	grpcServerResponseStatusKey   = "status"
+	grpcServerResponseMessageKey  = "message"
+	grpcServerResponseMessagesKey = "messages"
+)
+
+type grpcServerRunner struct {
+	name        string
+	addr        string
+	cert        []byte
+	key         []byte
+	reflection  bool
+	importPaths []string
+	protos      []string
+	bufDirs     []string
+	bufLocks    []string
+	bufConfigs  []string
+	bufModules  []string
+	methods     map[string][]*grpcServerResponse
+	server      *grpc.Server
+	listenAddr  string
+	mds         map[string]protoreflect.MethodDescriptor
+	requests    []map[string]any
+	env         map[string]any
+	received    chan struct{}
+	operatorID  string
+	mu          sync.Mutex
+}
+
+type grpcServerResponse struct {
+	match    string
+	response map[string]any
+}
+
+func newGRPCServerRunner(name, addr string) (*grpcServerRunner, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid gRPC server address: %s: %w", addr, err)
+	}
+	return &grpcServerRunner{
+		name:       name,
+		addr:       addr,
+		reflection: true,
+		methods:    map[string][]*grpcServerResponse{},
+	}, nil
+}
+
+func (rnr *grpcServerRunner) Renew() error {
+	return rnr.Close()
+}
+
+func (rnr *grpcServerRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server == nil {
+		return nil
+	}
+	rnr.server.Stop()
+	rnr.server = nil
+	rnr.listenAddr = ""
+	return nil
+}
+
+func (rnr *grpcServerRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.grpcServerRequest, s)
+	if err != nil {
+		return err
+	}
+	r, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid gRPC server request: %v", e)
+	}
+	req, err := parseServerRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *grpcServerRunner) run(ctx context.Context, r *serverRequest, s *step) error {
+	o := s.parent
+	if err := rnr.start(ctx, o); err != nil {
+		return err
+	}
+	if r.wait != nil {
+		if err := waitRequests(ctx, r.wait, o, rnr.snapshot); err != nil {
+			return err
+		}
+	}
+	rnr.mu.Lock()
+	d := rnr.toMap()
+	if r.clear {
+		rnr.requests = nil
+	}
+	rnr.mu.Unlock()
+	o.record(s.idx, d)
+	return nil
+}
+
+// start resolves the methods and starts listening if the server is not running yet.
+func (rnr *grpcServerRunner) start(ctx context.Context, o *operator) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server != nil {
+		return nil
+	}
+	if rnr.mds == nil {
+		mds, err := resolveMethodsUsingProtos(ctx, rnr.importPaths, rnr.protos, rnr.bufDirs, rnr.bufLocks, rnr.bufConfigs, rnr.bufModules)
+		if err != nil {
+			return err
+		}
+		for m := range rnr.methods {
+			if _, ok := mds[m]; !ok {
+				return fmt.Errorf("cannot find method: %s", m)
+			}
+		}
+		rnr.mds = mds
+	}
+	var opts []grpc.ServerOption
+	if len(rnr.cert) != 0 && len(rnr.key) != 0 {
+		certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			MinVersion:   tls.VersionTLS12,
+		})))
+	}
+	srv := grpc.NewServer(opts...)
+	for _, sd := range rnr.serviceDescs() {
+		srv.RegisterService(sd, rnr)
+	}
+	if rnr.reflection {
+		reflection.Register(srv)
+	}
+	ln, err := net.Listen("tcp", rnr.addr)
+	if err != nil {
+		return err
+	}
+	rnr.server = srv
+	rnr.listenAddr = ln.Addr().String()
+	rnr.requests = nil
+	rnr.received = make(chan struct{}, 1)
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	if err := donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Close()
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// serviceDescs returns the service descriptions that route all resolved methods to the runner.
+// Unary methods are also served as streams because they cannot be distinguished on the wire.
+func (rnr *grpcServerRunner) serviceDescs() []*grpc.ServiceDesc {
+	sds := map[string]*grpc.ServiceDesc{}
+	for _, md := range rnr.mds {
+		svc := string(md.Parent().FullName())
+		sd, ok := sds[svc]
+		if !ok {
+			sd = &grpc.ServiceDesc{
+				ServiceName: svc,
+				HandlerType: (*any)(nil),
+				Metadata:    md.ParentFile().Path(),
+			}
+			sds[svc] = sd
+		}
+		sd.Streams = append(sd.Streams, grpc.StreamDesc{
+			StreamName:    string(md.Name()),
+			Handler:       rnr.handle,
+			ServerStreams: md.IsStreamingServer(),
+			ClientStreams: md.IsStreamingClient(),
+		})
+	}
+	var descs []*grpc.ServiceDesc
+	for _, sd := range sds {
+		descs = append(descs, sd)
+	}
+	return descs
+}
+
+func (rnr *grpcServerRunner) handle(_ any, stream grpc.ServerStream) error {
+	fm, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "failed to get method")
+	}
+	key := strings.TrimPrefix(fm, "/")
+	rnr.mu.Lock()
+	md, ok := rnr.mds[key]
+	rnr.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown method: %s", key)
+	}
+	h, _ := metadata.FromIncomingContext(stream.Context())
+	messages := []any{}
+	var last any
+	for {
+		in := dynamicpb.NewMessage(md.Input())
+		if err := stream.RecvMsg(in); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		m, err := protoMessageToMap(in)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		messages = append(messages, m)
+		last = m
+		if !md.IsStreamingClient() {
+			break
+		}
+	}
+	rm := map[string]any{
+		grpcServerRequestStoreMethodKey:   key,
+		grpcServerRequestStoreHeaderKey:   h,
+		grpcServerRequestStoreMessageKey:  last,
+		grpcServerRequestStoreMessagesKey: messages,
+	}
+	rnr.mu.Lock()
+	rnr.requests = append(rnr.requests, rm)
+	env := map[string]any{}
+	for k, v := range rnr.env {
+		env[k] = v
+	}
+	responses := rnr.methods[key]
+	select {
+	case rnr.received <- struct{}{}:
+	default:
+	}
+	rnr.mu.Unlock()
+	env[serverEnvRequestKey] = rm
+
+	for _, res := range responses {
+		if res.match != "" {
+			tf, err := expr.EvalCond(res.match, env)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			if !tf {
+				continue
+			}
+		}
+		return res.respond(stream, md, env)
+	}
+	return status.Errorf(codes.Unimplemented, "no response matched: %s", key)
+}
+
+// snapshot returns the recorded values and the channel notified when a request is received.
+func (rnr *grpcServerRunner) snapshot() (map[string]any, <-chan struct{}) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	return rnr.toMap(), rnr.received
+}
+
+// setStore sets the values used for response matching and templating.
+func (rnr *grpcServerRunner) setStore(sm map[string]any) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	rnr.env = sm
+}
+
+func (rnr *grpcServerRunner) toMap() map[string]any {
+	requests := make([]any, 0, len(rnr.requests))
+	for _, r := range rnr.requests {
+		requests = append(requests, r)
+	}
+	return map[string]any{
+		serverStoreRequestsKey: requests,
+		grpcServerStoreAddrKey: rnr.listenAddr,
+	}
+}
+
+func (rnr *grpcServerRunner) applyConfig(c *grpcServerRunnerConfig, root string) error {
+	var err error
+	_, rnr.cert, rnr.key, err = readTLSFiles(root, "", c.Cert, c.Key)
+	if err != nil {
+		return err
+	}
+	if c.Reflection != nil {
+		rnr.reflection = *c.Reflection
+	}
+	for _, p := range c.ImportPaths {
+		pp, err := fp(p, root)
+		if err != nil {
+			return err
+		}
+		rnr.importPaths = append(rnr.importPaths, pp)
+	}
+	for _, p := range c.Protos {
+		pp, err := fp(p, root)
+		if err != nil {
+			return err
+		}
+		rnr.protos = append(rnr.protos, pp)
+	}
+	for _, p := range c.BufDirs {
+		pp, err := fp(p, root)
+		if err != nil {
+			return err
+		}
+		rnr.bufDirs = append(rnr.bufDirs, pp)
+	}
+	for _, p := range c.BufLocks {
+		pp, err := fp(p, root)
+		if err != nil {
+			return err
+		}
+		rnr.bufLocks = append(rnr.bufLocks, pp)
+	}
+	for _, p := range c.BufConfigs {
+		pp, err := fp(p, root)
+		if err != nil {
+			return err
+		}
+		rnr.bufConfigs = append(rnr.bufConfigs, pp)
+	}
+	rnr.bufModules = c.BufModules
+	for m, v := range c.Methods {
+		if strings.Count(m, "/") != 1 {
+			return fmt.Errorf("invalid method: %s", m)
+		}
+		var rs []any
+		switch vv := v.(type) {
+		case []any:
+			rs = vv
+		default:
+			rs = []any{vv}
+		}
+		for _, r := range rs {
+			res, err := newGRPCServerResponse(r)
+			if err != nil {
+				return fmt.Errorf("invalid response of %s: %w", m, err)
+			}
+			rnr.methods[m] = append(rnr.methods[m], res)
+		}
+	}
+	return nil
+}
+
+func newGRPCServerResponse(v any) (*grpcServerResponse, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid response: %v", v)
+	}
+	res := &grpcServerResponse{
+		response: map[string]any{},
+	}
+	for k, vv := range m {
+		switch k {
+		case grpcServerResponseMatchKey:
+			s, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid match: %v", vv)
+			}
+			res.match = s
+		case grpcServerResponseHeaderKey, grpcServerResponseTrailerKey, grpcServerResponseStatusKey, grpcServerResponseMessageKey, grpcServerResponseMessagesKey:
+			res.response[k] = vv
+		default:
+			return nil, fmt.Errorf("invalid key: %s", k)
+		}
+	}
+	return res, nil
+}
+
+func (res *grpcServerResponse) respond(stream grpc.ServerStream, md protoreflect.MethodDescriptor, env map[string]any) error {
+	e, err := expr.EvalExpand(res.response, env)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	m, ok := e.(map[string]any)
+	if !ok {
+		return status.Errorf(codes.Internal, "invalid response: %v", e)
+	}
+	if v, ok := m[grpcServerResponseHeaderKey]; ok {
+		h, err := toMD(v)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.SetHeader(h); err != nil {
+			return err
+		}
+	}
+	if v, ok := m[grpcServerResponseTrailerKey]; ok {
+		t, err := toMD(v)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		stream.SetTrailer(t)
+	}
+	code := codes.OK
+	var message string
+	if v, ok := m[grpcServerResponseStatusKey]; ok {
+		code, message, err = parseGRPCStatus(v)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+	var messages []any
+	if v, ok := m[grpcServerResponseMessageKey]; ok {
+		messages = append(messages, v)
+	}
+	if v, ok := m[grpcServerResponseMessagesKey]; ok {
+		l, ok := v.([]any)
+		if !ok {
+			return status.Errorf(codes.Internal, "invalid messages: %v", v)
+		}
+		messages = append(messages, l...)
+	}
+	if !md.IsStreamingServer() && code == codes.OK && len(messages) != 1 {
+		return status.Errorf(codes.Internal, "unary response message should be 1: %s", md.FullName())
+	}
+	if !md.IsStreamingServer() && code != codes.OK {
+		messages = nil
+	}
+	for _, msg := range messages {
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		out := dynamicpb.NewMessage(md.Output())
+		if err := protojson.Unmarshal(b, out); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if err := stream.SendMsg(out); err != nil {
+			return err
+		}
+	}
+	if code != codes.OK {
+		return status.Error(code, message)
+	}
+	return nil
+}
+
+func toMD(v any) (metadata.MD, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid metadata: %v", v)
+	}
+	md := metadata.MD{}
+	for k, vv := range m {
+		switch vvv := vv.(type) {
+		case []any:
+			for _, s := range vvv {
+				md.Append(k, cast.ToString(s))
+			}
+		default:
+			md.Append(k, cast.ToString(vvv))
+		}
+	}
+	return md, nil
+}
+
+// parseGRPCStatus parses `code:` (number or name such as `NotFound` or `NOT_FOUND`) and `message:`.
+func parseGRPCStatus(v any) (codes.Code, string, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return 0, "", fmt.Errorf("invalid status: %v", v)
+	}
+	var message string
+	if msg, ok := m["message"]; ok {
+		message = cast.ToString(msg)
+	}
+	switch c := m["code"].(type) {
+	case nil:
+		return codes.OK, message, nil
+	case string:
+		// Canonical names ( e.g. `CANCELLED`, `INVALID_ARGUMENT` )
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(c)))); err == nil {
+			return code, message, nil
+		}
+		// Go names ( e.g. `Canceled`, `InvalidArgument` )
+		name := strings.ReplaceAll(c, "_", "")
+		for i := codes.OK; i <= codes.Unauthenticated; i++ {
+			if strings.EqualFold(i.String(), name) {
+				return i, message, nil
+			}
+		}
+		return 0, "", fmt.Errorf("invalid status code: %s", c)
+	default:
+		i, err := cast.ToUint32E(c)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid status code: %v", c)
+		}
+		return codes.Code(i), message, nil
+	}
+}
+
+func protoMessageToMap(msg proto.Message) (map[string]any, error) {
+	b, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,68 @@
+package runn
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestGRPCServerRunner(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/grpc_server.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book), Scopes(ScopeAllowReadParent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+			r, ok := o.grpcServerRunners["mock"]
+			if !ok {
+				t.Fatal("gRPC server runner not found")
+			}
+			if r.server != nil {
+				t.Error("gRPC server runner should be closed")
+			}
+		})
+	}
+}
+
+func TestParseGRPCStatus(t *testing.T) {
+	tests := []struct {
+		in          any
+		wantCode    codes.Code
+		wantMessage string
+		wantErr     bool
+	}{
+		{map[string]any{"code": 5, "message": "not found"}, codes.NotFound, "not found", false},
+		{map[string]any{"code": "NotFound"}, codes.NotFound, "", false},
+		{map[string]any{"code": "PERMISSION_DENIED"}, codes.PermissionDenied, "", false},
+		{map[string]any{"code": "CANCELLED"}, codes.Canceled, "", false},
+		{map[string]any{"code": "cancelled"}, codes.Canceled, "", false},
+		{map[string]any{"code": "Canceled"}, codes.Canceled, "", false},
+		{map[string]any{"code": "DEADLINE_EXCEEDED"}, codes.DeadlineExceeded, "", false},
+		{map[string]any{"message": "ok"}, codes.OK, "ok", false},
+		{map[string]any{"code": "Unknown status"}, 0, "", true},
+		{"NotFound", 0, "", true},
+	}
+	for _, tt := range tests {
+		code, message, err := parseGRPCStatus(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v, want error %v", err, tt.wantErr)
+			continue
+		}
+		if code != tt.wantCode {
+			t.Errorf("got %v, want %v", code, tt.wantCode)
+		}
+		if message != tt.wantMessage {
+			t.Errorf("got %v, want %v", message, tt.wantMessage)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package runn
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,8 +15,13 @@ import (
 	"github.com/k1LoW/grpcstub"
 	"github.com/k1LoW/runn/testutil"
 	"github.com/k1LoW/runn/version"
+	"github.com/spf13/cast"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 )
 
 func TestGrpcRunner(t *testing.T) {
@@ -602,3 +610,336 @@ func TestGrpcTraceHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestGrpcRunnerWithStreamScript(t *testing.T) {
+	ts := grpcstub.NewServer(t, filepath.Join(testutil.Testdata(), "grpcserver.proto"))
+	t.Cleanup(ts.Close)
+	ts.Method("grpcserver.GreeterService/HelloChat").Handler(func(r *grpcstub.Request) *grpcstub.Response {
+		switch r.Message["name"] {
+		case "slow":
+			time.Sleep(500 * time.Millisecond)
+		case "lazy":
+			time.Sleep(100 * time.Millisecond)
+		}
+		res := grpcstub.NewResponse()
+		num, _ := r.Message["num"].(float64)
+		res.Messages = append(res.Messages, grpcstub.Message{
+			"message": fmt.Sprintf("hello %s", r.Message["name"]),
+			"num":     num + 1,
+		})
+		return res
+	})
+
+	tests := []struct {
+		name         string
+		messages     []*grpcMessage
+		wantMessages []string
+		wantErr      string
+	}{
+		{
+			"send a message computed from the received message",
+			[]*grpcMessage{
+				{op: GRPCOpMessage, params: map[string]any{"name": "alice", "num": 1}},
+				{op: GRPCOpReceive, until: "current.res.message.num == 2", timeout: 3 * time.Second},
+				{op: GRPCOpTest, cond: `current.res.message.message == "hello alice"`},
+				{op: GRPCOpMessage, params: map[string]any{"name": "{{ current.res.message.message }}", "num": "{{ current.res.message.num }}"}},
+				{op: GRPCOpMessage, params: map[string]any{"name": "bob", "num": 5}},
+				{op: GRPCOpReceive, until: "current.res.message.num == 6", timeout: 3 * time.Second},
+				{op: GRPCOpTest, cond: "len(current.res.messages) == 3"},
+				{op: GRPCOpClose},
+			},
+			[]string{"hello alice", "hello hello alice", "hello bob"},
+			"",
+		},
+		{
+			"test failed",
+			[]*grpcMessage{
+				{op: GRPCOpMessage, params: map[string]any{"name": "alice", "num": 1}},
+				{op: GRPCOpReceive},
+				{op: GRPCOpTest, cond: `current.res.message.message == "hello bob"`},
+				{op: GRPCOpClose},
+			},
+			nil,
+			"test failed on messages[2]",
+		},
+		{
+			"timeout",
+			[]*grpcMessage{
+				{op: GRPCOpMessage, params: map[string]any{"name": "slow", "num": 1}},
+				{op: GRPCOpReceive, until: "current.res.message.num == 2", timeout: 50 * time.Millisecond},
+				{op: GRPCOpClose},
+			},
+			nil,
+			"timeout waiting for message matching",
+		},
+		{
+			"timeout for the whole receive",
+			[]*grpcMessage{
+				{op: GRPCOpMessage, params: map[string]any{"name": "lazy", "num": 1}},
+				{op: GRPCOpMessage, params: map[string]any{"name": "lazy", "num": 2}},
+				{op: GRPCOpMessage, params: map[string]any{"name": "lazy", "num": 3}},
+				{op: GRPCOpReceive, until: "current.res.message.num == 4", timeout: 250 * time.Millisecond},
+				{op: GRPCOpClose},
+			},
+			nil,
+			"timeout waiting for message matching",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", ts.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			useTLS := false
+			r.tls = &useTLS
+			req := &grpcRequest{
+				service:  "grpcserver.GreeterService",
+				method:   "HelloChat",
+				headers:  metadata.MD{},
+				messages: tt.messages,
+			}
+			s := newStep(0, "stepKey", o, nil)
+			if err := r.run(ctx, req, s); err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("want error: %s", tt.wantErr)
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			messages, ok := res["messages"].([]map[string]any)
+			if !ok {
+				t.Fatalf("invalid messages: %#v", res["messages"])
+			}
+			var got []string
+			for _, m := range messages {
+				got = append(got, m["message"].(string))
+			}
+			if diff := cmp.Diff(got, tt.wantMessages); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerApplyTransportConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       *grpcRunnerConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			"default",
+			&grpcRunnerConfig{},
+			"protocol: grpc, tls: true, compression: identity, maxSendMsgSize: default, maxRecvMsgSize: default, keepalive: default, timeout: none",
+			false,
+		},
+		{
+			"all",
+			&grpcRunnerConfig{
+				Compression:    "gzip",
+				MaxSendMsgSize: "1024",
+				MaxRecvMsgSize: "16MiB",
+				Keepalive:      &grpcKeepaliveConfig{Time: "30sec", Timeout: "10", PermitWithoutStream: true},
+				Timeout:        "3sec",
+			},
+			"protocol: grpc, tls: true, compression: gzip, maxSendMsgSize: 1.0 KiB, maxRecvMsgSize: 16 MiB, keepalive: time=30s timeout=10s permitWithoutStream=true, timeout: 3s",
+			false,
+		},
+		{
+			"unsupported compression",
+			&grpcRunnerConfig{Compression: "br"},
+			"",
+			true,
+		},
+		{
+			"invalid size",
+			&grpcRunnerConfig{MaxRecvMsgSize: "large"},
+			"",
+			true,
+		},
+		{
+			"too large size",
+			&grpcRunnerConfig{MaxSendMsgSize: "3GiB"},
+			"",
+			true,
+		},
+		{
+			"invalid timeout",
+			&grpcRunnerConfig{Keepalive: &grpcKeepaliveConfig{Timeout: "soon"}},
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newGrpcRunner("greq", "grpc.example.com:443")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.applyTransportConfig(tt.c); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("want error")
+			}
+			if got := r.settings(); got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerWithTransportOptions(t *testing.T) {
+	ts := grpcstub.NewServer(t, filepath.Join(testutil.Testdata(), "grpcserver.proto"))
+	t.Cleanup(ts.Close)
+	ts.Method("grpcserver.GreeterService/Hello").ResponseString(`{"message":"hello, this message is longer than 32 bytes", "num":3}`)
+	ts.Method("grpcserver.GreeterService/HelloChat").Handler(func(r *grpcstub.Request) *grpcstub.Response {
+		time.Sleep(500 * time.Millisecond)
+		res := grpcstub.NewResponse()
+		res.Messages = append(res.Messages, grpcstub.Message{"message": "hello"})
+		return res
+	})
+
+	tests := []struct {
+		name       string
+		c          *grpcRunnerConfig
+		method     string
+		messages   []*grpcMessage
+		wantStatus int
+	}{
+		{
+			"gzip",
+			&grpcRunnerConfig{Compression: "gzip", MaxSendMsgSize: "1MB"},
+			"Hello",
+			[]*grpcMessage{{op: GRPCOpMessage, params: map[string]any{"name": "alice"}}},
+			0,
+		},
+		{
+			"exceed maxRecvMsgSize",
+			&grpcRunnerConfig{MaxRecvMsgSize: "32"},
+			"Hello",
+			[]*grpcMessage{{op: GRPCOpMessage, params: map[string]any{"name": "alice"}}},
+			int(codes.ResourceExhausted),
+		},
+		{
+			"timeout of bidirectional streaming RPC",
+			&grpcRunnerConfig{Timeout: "100ms"},
+			"HelloChat",
+			[]*grpcMessage{
+				{op: GRPCOpMessage, params: map[string]any{"name": "alice"}},
+				{op: GRPCOpClose},
+			},
+			int(codes.DeadlineExceeded),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			stderr := new(bytes.Buffer)
+			o, err := New(Debug(true), Stderr(stderr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newGrpcRunner("greq", ts.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			useTLS := false
+			r.tls = &useTLS
+			if err := r.applyTransportConfig(tt.c); err != nil {
+				t.Fatal(err)
+			}
+			req := &grpcRequest{
+				service:  "grpcserver.GreeterService",
+				method:   tt.method,
+				headers:  metadata.MD{},
+				messages: tt.messages,
+			}
+			s := newStep(0, "stepKey", o, nil)
+			if err := r.run(ctx, req, s); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			if got := cast.ToInt(res["status"]); got != tt.wantStatus {
+				t.Errorf("got %v\nwant %v", got, tt.wantStatus)
+			}
+			if want := r.settings(); !strings.Contains(stderr.String(), want) {
+				t.Errorf("got %v\nwant to contain %v", stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestGrpcRunnerWithUnixSocket(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(srv.Stop)
+
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newGrpcRunner("greq", fmt.Sprintf("unix://%s", sock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	req := &grpcRequest{
+		service:  "grpc.health.v1.Health",
+		method:   "Check",
+		headers:  metadata.MD{},
+		messages: []*grpcMessage{{op: GRPCOpMessage, params: map[string]any{}}},
+	}
+	s := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	want := map[string]any{"status": float64(healthpb.HealthCheckResponse_SERVING)}
+	if diff := cmp.Diff(res["message"], any(want)); diff != "" {
+		t.Error(diff)
+	}
+}
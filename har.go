@@ -0,0 +1,188 @@
+package runn
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// harHeadersToBeSkipped are request headers in HAR that are not converted to step headers.
+var harHeadersToBeSkipped = []string{"Content-Length", "Connection", "Accept-Encoding", "Host"}
+
+type harConfig struct {
+	hosts        []string
+	contentTypes []string
+	vars         bool
+}
+
+type harOption func(*harConfig) error
+
+// HARHost - Convert only entries of HAR with the hosts.
+func HARHost(hosts ...string) harOption {
+	return func(c *harConfig) error {
+		c.hosts = append(c.hosts, hosts...)
+		return nil
+	}
+}
+
+// HARContentType - Convert only entries of HAR whose response has the content types ( e.g. "application/json" ).
+func HARContentType(contentTypes ...string) harOption {
+	return func(c *harConfig) error {
+		c.contentTypes = append(c.contentTypes, contentTypes...)
+		return nil
+	}
+}
+
+// HARVars - Turn cookies and authorization headers of HAR into vars.
+func HARVars(enable bool) harOption {
+	return func(c *harConfig) error {
+		c.vars = enable
+		return nil
+	}
+}
+
+type harLog struct {
+	Log struct {
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request  harRequest  `json:"request"`
+	Response harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Headers  []*harNameValue `json:"headers"`
+	PostData *harPostData    `json:"postData,omitempty"`
+}
+
+type harResponse struct {
+	Content struct {
+		MimeType string `json:"mimeType"`
+	} `json:"content"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// AppendHAR appends HTTP steps converted from entries of HAR ( HTTP Archive ).
+func (rb *runbook) AppendHAR(in io.Reader, opts ...harOption) error {
+	c := &harConfig{}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+	var h harLog
+	if err := json.NewDecoder(in).Decode(&h); err != nil {
+		return fmt.Errorf("failed to decode HAR: %w", err)
+	}
+	for _, e := range h.Log.Entries {
+		req, err := e.toRequest()
+		if err != nil {
+			return err
+		}
+		// Skip entries that are not HTTP requests ( e.g. data:, ws:, blob:, chrome-extension: )
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			continue
+		}
+		if len(c.hosts) > 0 && !slices.Contains(c.hosts, req.URL.Host) && !slices.Contains(c.hosts, req.URL.Hostname()) {
+			continue
+		}
+		if len(c.contentTypes) > 0 && !slices.ContainsFunc(c.contentTypes, func(ct string) bool {
+			return strings.HasPrefix(e.Response.Content.MimeType, ct)
+		}) {
+			continue
+		}
+		if c.vars {
+			rb.harHeadersToVars(req)
+		}
+		if rb.useMap {
+			key := fmt.Sprintf("har%d", len(rb.stepKeys))
+			rb.stepKeys = append(rb.stepKeys, key)
+		}
+		dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+		key := rb.setRunner(dsn)
+		step, err := CreateHTTPStepMapSlice(key, req)
+		if err != nil {
+			return err
+		}
+		rb.Steps = append(rb.Steps, step)
+	}
+	return nil
+}
+
+func (e *harEntry) toRequest() (*http.Request, error) {
+	var body io.Reader
+	if e.Request.PostData != nil && e.Request.PostData.Text != "" {
+		body = bytes.NewBufferString(e.Request.PostData.Text)
+	}
+	req, err := http.NewRequest(e.Request.Method, e.Request.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HAR entry: %w", err)
+	}
+	for _, h := range e.Request.Headers {
+		// Skip HTTP/2 pseudo-headers ( e.g. ":authority" )
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		k := http.CanonicalHeaderKey(h.Name)
+		if slices.Contains(harHeadersToBeSkipped, k) {
+			continue
+		}
+		req.Header.Add(k, h.Value)
+	}
+	if e.Request.PostData != nil && req.Header.Get("Content-Type") == "" && e.Request.PostData.MimeType != "" {
+		req.Header.Set("Content-Type", e.Request.PostData.MimeType)
+	}
+	return req, nil
+}
+
+// harHeadersToVars replaces values of Cookie and Authorization headers with vars.
+func (rb *runbook) harHeadersToVars(req *http.Request) {
+	for _, k := range []string{"Cookie", "Authorization"} {
+		v := req.Header.Get(k)
+		if v == "" {
+			continue
+		}
+		req.Header.Set(k, fmt.Sprintf("{{ vars.%s }}", rb.setVar(strings.ToLower(k), v)))
+	}
+}
+
+// setVar sets the value to vars and returns the key. The key of the same value is reused.
+func (rb *runbook) setVar(prefix, value string) string {
+	if rb.Vars == nil {
+		rb.Vars = map[string]any{}
+	}
+	i := 1
+	for {
+		key := prefix
+		if i > 1 {
+			key = fmt.Sprintf("%s%d", prefix, i)
+		}
+		v, ok := rb.Vars[key]
+		if !ok {
+			rb.Vars[key] = value
+			return key
+		}
+		if v == value {
+			return key
+		}
+		i++
+	}
+}
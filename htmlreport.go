@@ -0,0 +1,267 @@
+package runn
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ Capturer         = (*htmlReport)(nil)
+	_ operatorCapturer = (*htmlReport)(nil)
+	_ runNEndCapturer  = (*htmlReport)(nil)
+)
+
+// htmlReport writes a self-contained HTML report of runbook runs.
+// Payloads of runners are captured in the same format as the debugger and are bound to the step being run.
+// Each operator captures with its own htmlReport sharing the results and payloads, so that the payloads of operators running concurrently are not mixed.
+type htmlReport struct {
+	*debugger
+	currentTrails Trails
+	out           *htmlReportOut
+}
+
+// htmlReportOut holds the results and payloads written to the HTML report.
+type htmlReportOut struct {
+	path     string
+	results  []*RunResult
+	payloads map[string]*bytes.Buffer // key is the trail of the step ( e.g. `<runbook id>?step=1&step=0` )
+	errs     error
+	mu       sync.Mutex
+}
+
+type htmlReportView struct {
+	Total   int
+	Success int
+	Failure int
+	Skipped int
+	Runs    []*htmlRunView
+}
+
+type htmlRunView struct {
+	ID      string
+	Desc    string
+	Path    string
+	Result  result
+	Elapsed string
+	Err     string
+	Steps   []*htmlStepView
+}
+
+type htmlStepView struct {
+	Key      string
+	Desc     string
+	Result   result
+	Elapsed  string
+	Err      string
+	Trace    string
+	Payload  string
+	Included []*htmlRunView
+}
+
+// NewHTMLReport returns a Capturer that writes the HTML report of runbook runs to the path when the run ends.
+func NewHTMLReport(path string) *htmlReport {
+	return newHTMLReport(&htmlReportOut{
+		path:     path,
+		payloads: map[string]*bytes.Buffer{},
+	})
+}
+
+func newHTMLReport(out *htmlReportOut) *htmlReport {
+	r := &htmlReport{
+		out: out,
+	}
+	r.debugger = NewDebugger(r)
+	return r
+}
+
+func (r *htmlReport) forOperator() Capturer {
+	return newHTMLReport(r.out)
+}
+
+// Write writes the payload captured by the debugger to the buffer of the current step.
+func (r *htmlReport) Write(p []byte) (int, error) {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	if len(r.currentTrails) == 0 {
+		return len(p), nil
+	}
+	key := r.currentTrails.runbookID()
+	b, ok := r.out.payloads[key]
+	if !ok {
+		b = &bytes.Buffer{}
+		r.out.payloads[key] = b
+	}
+	return b.Write(p)
+}
+
+func (r *htmlReport) CaptureResult(trs Trails, result *RunResult) {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	r.out.results = append(r.out.results, result)
+}
+
+func (r *htmlReport) SetCurrentTrails(trs Trails) {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	r.currentTrails = trs
+}
+
+func (r *htmlReport) Errs() error {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	return r.out.errs
+}
+
+// captureRunNEnd writes the results captured in the run to the HTML report and clears them for the next run.
+func (r *htmlReport) captureRunNEnd() error {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	err := r.out.writeReport()
+	if err != nil {
+		r.out.errs = errors.Join(r.out.errs, err)
+	}
+	r.out.results = nil
+	r.out.payloads = map[string]*bytes.Buffer{}
+	return err
+}
+
+// writeReport renders all results captured so far. r.mu must be locked.
+func (r *htmlReportOut) writeReport() error {
+	v := &htmlReportView{}
+	for _, rr := range r.results {
+		mask := func(in string) string { return in }
+		if rr.store != nil {
+			mask = rr.store.MaskRule().Mask
+		}
+		rv := r.runView(rr, mask)
+		v.Total++
+		switch rv.Result {
+		case resultFailure:
+			v.Failure++
+		case resultSkipped:
+			v.Skipped++
+		default:
+			v.Success++
+		}
+		v.Runs = append(v.Runs, rv)
+	}
+	buf := &bytes.Buffer{}
+	if err := htmlReportTmpl.Execute(buf, v); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, buf.Bytes(), 0600)
+}
+
+func (r *htmlReportOut) runView(rr *RunResult, mask func(string) string) *htmlRunView {
+	rv := &htmlRunView{
+		ID:      rr.ID,
+		Desc:    mask(rr.Desc),
+		Path:    normalizePath(rr.Path),
+		Result:  resultSuccess,
+		Elapsed: htmlElapsed(rr.Elapsed),
+	}
+	switch {
+	case rr.Err != nil:
+		rv.Result = resultFailure
+		rv.Err = mask(strings.TrimRight(rr.Err.Error(), "\n"))
+	case rr.Skipped:
+		rv.Result = resultSkipped
+	}
+	for _, sr := range rr.StepResults {
+		if sr == nil {
+			continue
+		}
+		sv := &htmlStepView{
+			Key:     sr.Key,
+			Desc:    mask(sr.Desc),
+			Result:  resultSuccess,
+			Elapsed: htmlElapsed(sr.Elapsed),
+		}
+		switch {
+		case sr.Err != nil:
+			sv.Result = resultFailure
+			sv.Err = mask(strings.TrimRight(sr.Err.Error(), "\n"))
+			var fe *condFalseError
+			if errors.As(sr.Err, &fe) {
+				sv.Trace = mask(strings.TrimRight(fe.tree, "\n"))
+			}
+		case sr.Skipped:
+			sv.Result = resultSkipped
+		}
+		if b, ok := r.payloads[sr.ID]; ok {
+			sv.Payload = mask(b.String())
+		}
+		for _, ir := range sr.IncludedRunResults {
+			imask := mask
+			if ir.store != nil {
+				m := ir.store.MaskRule().Mask
+				imask = func(in string) string { return m(mask(in)) }
+			}
+			sv.Included = append(sv.Included, r.runView(ir, imask))
+		}
+		rv.Steps = append(rv.Steps, sv)
+	}
+	return rv
+}
+
+func htmlElapsed(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+var htmlReportTmpl = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>runn report</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+summary { cursor: pointer; padding: 0.3em 0; }
+details { margin-left: 1em; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; white-space: pre-wrap; word-break: break-all; }
+.success { color: #1a7f37; }
+.failure { color: #cf222e; }
+.skipped { color: #9a6700; }
+.meta { color: #57606a; font-size: 0.9em; }
+.label { font-weight: bold; margin-top: 0.5em; }
+</style>
+</head>
+<body>
+<h1>runn report</h1>
+<p>{{ .Total }} scenarios, <span class="success">{{ .Success }} success</span>, <span class="failure">{{ .Failure }} failure</span>, <span class="skipped">{{ .Skipped }} skipped</span></p>
+{{- range .Runs }}
+{{ template "run" . }}
+{{- end }}
+</body>
+</html>
+{{ define "run" }}<details{{ if eq .Result "failure" }} open{{ end }}>
+<summary><span class="{{ .Result }}">[{{ .Result }}]</span> {{ if .Desc }}{{ .Desc }} {{ end }}<span class="meta">{{ .Path }} {{ .ID }}{{ if .Elapsed }} ({{ .Elapsed }}){{ end }}</span></summary>
+{{- if .Err }}
+<div class="label">Error</div><pre class="failure">{{ .Err }}</pre>
+{{- end }}
+{{- range .Steps }}
+<details{{ if eq .Result "failure" }} open{{ end }}>
+<summary><span class="{{ .Result }}">[{{ .Result }}]</span> {{ if .Desc }}{{ .Desc }} {{ end }}<span class="meta">({{ .Key }}){{ if .Elapsed }} {{ .Elapsed }}{{ end }}</span></summary>
+{{- if .Err }}
+<div class="label">Error</div><pre class="failure">{{ .Err }}</pre>
+{{- end }}
+{{- if .Trace }}
+<div class="label">Condition</div><pre>{{ .Trace }}</pre>
+{{- end }}
+{{- if .Payload }}
+<div class="label">Payload</div><pre>{{ .Payload }}</pre>
+{{- end }}
+{{- range .Included }}
+{{ template "run" . }}
+{{- end }}
+</details>
+{{- end }}
+</details>
+{{- end }}`))
@@ -0,0 +1,99 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTMLReport(t *testing.T) {
+	tests := []struct {
+		book     string
+		want     []string
+		dontWant []string
+	}{
+		{
+			"testdata/book/with_secrets.yml",
+			[]string{"With secrets", "[success]", "-----START COMMAND-----", "echo *****", "-----START STDOUT-----"},
+			[]string{"hello world"},
+		},
+		{
+			"testdata/book/always_failure.yml",
+			[]string{"[failure]", "condition is not true", "Condition"},
+			nil,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "report.html")
+			o, err := Load(tt.book, Scopes(ScopeAllowRunExec), Capture(NewHTMLReport(p)), Stderr(io.Discard))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.RunN(ctx); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(b)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %s\nwant to contain %q", got, w)
+				}
+			}
+			for _, w := range tt.dontWant {
+				if strings.Contains(got, w) {
+					t.Errorf("got %s\nwant not to contain %q", got, w)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLReportOperators(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "report.html")
+	r := NewHTMLReport(p)
+	a := r.forOperator()
+	b := r.forOperator()
+	zero := 0
+	trsA := Trails{{Type: TrailTypeRunbook, RunbookID: "a"}, {Type: TrailTypeStep, StepIndex: &zero}}
+	trsB := Trails{{Type: TrailTypeRunbook, RunbookID: "b"}, {Type: TrailTypeStep, StepIndex: &zero}}
+	a.SetCurrentTrails(trsA)
+	b.SetCurrentTrails(trsB)
+	a.CaptureExecStdout("stdout of a")
+	b.CaptureExecStdout("stdout of b")
+	a.CaptureExecStderr("stderr of a")
+
+	gotA := r.out.payloads[trsA.runbookID()].String()
+	gotB := r.out.payloads[trsB.runbookID()].String()
+	if !strings.Contains(gotA, "stdout of a") || !strings.Contains(gotA, "stderr of a") || strings.Contains(gotA, "of b") {
+		t.Errorf("invalid payload of a: %s", gotA)
+	}
+	if !strings.Contains(gotB, "stdout of b") || strings.Contains(gotB, "of a") {
+		t.Errorf("invalid payload of b: %s", gotB)
+	}
+
+	a.CaptureResult(trsA, &RunResult{ID: "a", StepResults: []*StepResult{{ID: trsA.runbookID(), Key: "0"}}})
+	b.CaptureResult(trsB, &RunResult{ID: "b", StepResults: []*StepResult{{ID: trsB.runbookID(), Key: "0"}}})
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("the report should not be written until the run ends: %v", err)
+	}
+	if err := r.captureRunNEnd(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"stdout of a", "stdout of b"} {
+		if !strings.Contains(string(got), w) {
+			t.Errorf("got %s\nwant to contain %q", got, w)
+		}
+	}
+}
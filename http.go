@@ -23,7 +23,11 @@ import (
 	"time"
 
 	"github.com/ajg/form"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/fxamacker/cbor/v2"
 	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 const (
@@ -32,6 +36,14 @@ const (
 	MediaTypeApplicationFormUrlencoded = "application/x-www-form-urlencoded"
 	MediaTypeMultipartFormData         = "multipart/form-data"
 	MediaTypeApplicationOctetStream    = "application/octet-stream"
+	MediaTypeApplicationXML            = "application/xml"
+	MediaTypeTextXML                   = "text/xml"
+	MediaTypeApplicationProtobuf       = "application/x-protobuf"
+	MediaTypeApplicationMsgPack        = "application/msgpack"
+	MediaTypeApplicationXMsgPack       = "application/x-msgpack"
+	MediaTypeApplicationCBOR           = "application/cbor"
+	MediaTypeTextEventStream           = "text/event-stream"
+	MediaTypeApplicationNDJSON         = "application/x-ndjson"
 )
 
 const (
@@ -61,6 +73,16 @@ type httpRunner struct {
 	useCookie         *bool
 	trace             *bool
 	traceHeaderName   string
+	cassettes         *cassettes
+	auth              httpAuth
+	proxy             *proxy
+	importPaths       []string
+	protos            []string
+	bufDirs           []string
+	bufLocks          []string
+	bufConfigs        []string
+	bufModules        []string
+	protoFiles        linker.Files
 }
 
 type httpRequest struct {
@@ -71,7 +93,15 @@ type httpRequest struct {
 	body      any
 	useCookie *bool
 	trace     *bool
-
+	// protoRequestType - The message type of the request body of application/x-protobuf.
+	protoRequestType string
+	// protoResponseType - The message type of the response body of application/x-protobuf.
+	protoResponseType string
+	// stream - The condition to stop reading text/event-stream or application/x-ndjson.
+	stream *httpStream
+
+	protoRequestDesc  protoreflect.MessageDescriptor
+	streamMessages    []any
 	multipartWriter   *multipart.Writer
 	multipartBoundary string
 	// operator.root
@@ -109,6 +139,27 @@ func newHTTPRunnerWithHandler(name string, h http.Handler) (*httpRunner, error)
 	}, nil
 }
 
+// setProxy sets the outbound proxy to the transport of the client.
+func (rnr *httpRunner) setProxy(p *proxy) error {
+	if rnr.client == nil {
+		return nil
+	}
+	if rnr.client.Transport == nil {
+		tp, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("failed to cast: %v", http.DefaultTransport)
+		}
+		rnr.client.Transport = tp.Clone()
+	}
+	tp, ok := rnr.client.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("failed to cast: %v", rnr.client.Transport)
+	}
+	tp.Proxy = p.httpProxyFunc()
+	rnr.proxy = p
+	return nil
+}
+
 func (r *httpRequest) validate() error {
 	switch r.method {
 	case http.MethodPost, http.MethodPatch:
@@ -123,7 +174,11 @@ func (r *httpRequest) validate() error {
 		return nil
 	}
 	switch r.mediaType {
-	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, "":
+	case MediaTypeApplicationJSON, MediaTypeTextPlain, MediaTypeApplicationFormUrlencoded, MediaTypeApplicationOctetStream, MediaTypeApplicationXML, MediaTypeTextXML, MediaTypeApplicationMsgPack, MediaTypeApplicationXMsgPack, MediaTypeApplicationCBOR, "":
+	case MediaTypeApplicationProtobuf:
+		if r.body != nil && r.protoRequestType == "" {
+			return fmt.Errorf("%s requires the message type of the request ( protobuf.request )", r.mediaType)
+		}
 	default:
 		return fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
@@ -200,6 +255,36 @@ func (r *httpRequest) encodeBody() (io.Reader, error) {
 			return nil, fmt.Errorf("invalid body: %v", r.body)
 		}
 		return strings.NewReader(s), nil
+	case MediaTypeApplicationXML, MediaTypeTextXML:
+		if s, ok := r.body.(string); ok {
+			return strings.NewReader(s), nil
+		}
+		b, err := encodeXML(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationProtobuf:
+		if r.protoRequestDesc == nil {
+			return nil, fmt.Errorf("the message type of the request is not resolved: %s", r.protoRequestType)
+		}
+		b, err := encodeProtobuf(r.protoRequestDesc, r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationMsgPack, MediaTypeApplicationXMsgPack:
+		b, err := msgpack.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
+	case MediaTypeApplicationCBOR:
+		b, err := cbor.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewBuffer(b), nil
 	default:
 		return nil, fmt.Errorf("unsupported mediaType: %s", r.mediaType)
 	}
@@ -393,12 +478,109 @@ func (rnr *httpRunner) Run(ctx context.Context, s *step) error {
 }
 
 func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
+	o := s.parent
+	res, resBody, err := rnr.roundTrip(ctx, r, s)
+	if err != nil {
+		return err
+	}
+
+	d := map[string]any{}
+	d[httpStoreStatusKey] = res.StatusCode
+	switch {
+	case r.streamMessages != nil:
+		d[httpStoreBodyKey] = nil
+		d[httpStoreMessagesKey] = r.streamMessages
+		d[httpStoreMessageKey] = nil
+		if len(r.streamMessages) > 0 {
+			d[httpStoreMessageKey] = r.streamMessages[len(r.streamMessages)-1]
+		}
+	case strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0:
+		var b any
+		if err := json.Unmarshal(resBody, &b); err != nil {
+			return err
+		}
+		d[httpStoreBodyKey] = b
+	case isXMLContentType(res.Header.Get("Content-Type")) && len(resBody) > 0:
+		b, err := decodeXML(resBody)
+		if err != nil {
+			return err
+		}
+		d[httpStoreBodyKey] = b
+	case isProtobufContentType(res.Header.Get("Content-Type")):
+		typ := protoResponseType(r, res)
+		if typ == "" {
+			d[httpStoreBodyKey] = nil
+			break
+		}
+		md, err := rnr.resolveProtoMessage(ctx, typ)
+		if err != nil {
+			return err
+		}
+		b, err := decodeProtobuf(md, resBody)
+		if err != nil {
+			return err
+		}
+		d[httpStoreBodyKey] = b
+	case isMsgPackContentType(res.Header.Get("Content-Type")) && len(resBody) > 0:
+		b, err := decodeMsgPack(resBody)
+		if err != nil {
+			return err
+		}
+		d[httpStoreBodyKey] = b
+	case isCBORContentType(res.Header.Get("Content-Type")) && len(resBody) > 0:
+		b, err := decodeCBOR(resBody)
+		if err != nil {
+			return err
+		}
+		d[httpStoreBodyKey] = b
+	default:
+		d[httpStoreBodyKey] = nil
+	}
+	d[httpStoreRawBodyKey] = string(resBody)
+	d[httpStoreHeaderKey] = res.Header
+
+	cookies := res.Cookies()
+
+	if len(cookies) > 0 {
+		keyMap := make(map[string]http.Cookie)
+
+		for _, c := range cookies {
+			// If the Domain attribute is not specified, the host is taken over
+			if c.Domain == "" && rnr.endpoint != nil {
+				c.Domain = rnr.endpoint.Host
+			}
+			keyMap[c.Name] = *c
+		}
+
+		d[httpStoreCookieKey] = keyMap
+		o.recordCookie(cookies)
+	} else {
+		d[httpStoreCookieKey] = map[string]http.Cookie{}
+	}
+
+	o.record(s.idx, map[string]any{
+		string(httpStoreResponseKey): d,
+	})
+
+	return nil
+}
+
+// roundTrip sends the HTTP request and returns the response with its plain body.
+// The body of the returned response has already been read and closed.
+func (rnr *httpRunner) roundTrip(ctx context.Context, r *httpRequest, s *step) (*http.Response, []byte, error) {
 	o := s.parent
 	r.multipartBoundary = rnr.multipartBoundary
 	r.root = o.root
+	if r.mediaType == MediaTypeApplicationProtobuf && r.protoRequestType != "" {
+		md, err := rnr.resolveProtoMessage(ctx, r.protoRequestType)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.protoRequestDesc = md
+	}
 	reqBody, err := r.encodeBody()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Override useCookie
@@ -414,19 +596,20 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		r.trace = rnr.trace
 	}
 	if err := r.setTraceHeader(s); err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	var (
-		req *http.Request
-		res *http.Response
+		req     *http.Request
+		res     *http.Response
+		started time.Time
 	)
 	switch {
 	case rnr.client != nil:
 		if rnr.client.Transport == nil {
 			tp, ok := http.DefaultTransport.(*http.Transport)
 			if !ok {
-				return fmt.Errorf("failed to cast: %v", http.DefaultTransport)
+				return nil, nil, fmt.Errorf("failed to cast: %v", http.DefaultTransport)
 			}
 			rnr.client.Transport = tp.Clone()
 		}
@@ -447,33 +630,33 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 				certpool = x509.NewCertPool()
 			}
 			if !certpool.AppendCertsFromPEM(rnr.cacert) {
-				return err
+				return nil, nil, errors.New("failed to append cacert")
 			}
 			ts, ok := rnr.client.Transport.(*http.Transport)
 			if !ok {
-				return fmt.Errorf("could not set cacert: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
+				return nil, nil, fmt.Errorf("could not set cacert: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
 			}
 			ts.TLSClientConfig.RootCAs = certpool
 		}
 		if len(rnr.cert) != 0 && len(rnr.key) != 0 {
 			cert, err := tls.X509KeyPair(rnr.cert, rnr.key)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
 			ts, ok := rnr.client.Transport.(*http.Transport)
 			if !ok {
-				return fmt.Errorf("could not set certificates: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
+				return nil, nil, fmt.Errorf("could not set certificates: interface conversion error: http.RoundTripper is %#v, not *http.Transport", rnr.client.Transport)
 			}
 			ts.TLSClientConfig.Certificates = []tls.Certificate{cert}
 		}
 
 		u, err := mergeURL(rnr.endpoint, r.path)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		req, err = http.NewRequestWithContext(ctx, r.method, u.String(), reqBody)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		r.setContentTypeHeader(req)
 		r.setCookieHeader(req, o.store.Cookies())
@@ -490,12 +673,24 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		o.capturers.captureHTTPRequest(rnr.name, req)
 
 		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
-			return err
+			return nil, nil, err
 		}
 
-		res, err = rnr.client.Do(req)
+		client := rnr.client
+		if rnr.cassettes != nil {
+			c := *rnr.client
+			c.Transport = rnr.cassettes.transport(rnr.client.Transport, s.runbookID(), rnr.name, o.maskRule)
+			client = &c
+		}
+		if rnr.auth != nil {
+			c := *client
+			c.Transport = &httpAuthTransport{auth: rnr.auth, base: client.Transport, mr: o.maskRule}
+			client = &c
+		}
+		started = time.Now()
+		res, err = client.Do(req)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
 		defer res.Body.Close()
 	case rnr.handler != nil:
@@ -516,14 +711,24 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		o.capturers.captureHTTPRequest(rnr.name, req)
 
 		if err := rnr.validator.ValidateRequest(ctx, req); err != nil {
-			return err
+			return nil, nil, err
 		}
 		w := httptest.NewRecorder()
+		started = time.Now()
 		rnr.handler.ServeHTTP(w, req)
 		res = w.Result()
 		defer res.Body.Close()
 	default:
-		return fmt.Errorf("invalid http runner: %s", rnr.name)
+		return nil, nil, fmt.Errorf("invalid http runner: %s", rnr.name)
+	}
+
+	if isStreamContentType(res.Header.Get("Content-Type")) {
+		messages, raw, err := readStream(o, r, res, started)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.streamMessages = messages
+		res.Body = io.NopCloser(bytes.NewReader(raw))
 	}
 
 	o.capturers.captureHTTPResponse(rnr.name, res)
@@ -533,53 +738,16 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 		if errors.As(err, &target) {
 			o.Debugf("Skip validate response due to unsupported format: %s", err.Error())
 		} else {
-			return err
+			return nil, nil, err
 		}
 	}
 
 	resBody, err := readPlainBody(res)
 	if err != nil {
-		return err
-	}
-
-	d := map[string]any{}
-	d[httpStoreStatusKey] = res.StatusCode
-	if strings.Contains(res.Header.Get("Content-Type"), "json") && len(resBody) > 0 {
-		var b any
-		if err := json.Unmarshal(resBody, &b); err != nil {
-			return err
-		}
-		d[httpStoreBodyKey] = b
-	} else {
-		d[httpStoreBodyKey] = nil
-	}
-	d[httpStoreRawBodyKey] = string(resBody)
-	d[httpStoreHeaderKey] = res.Header
-
-	cookies := res.Cookies()
-
-	if len(cookies) > 0 {
-		keyMap := make(map[string]http.Cookie)
-
-		for _, c := range cookies {
-			// If the Domain attribute is not specified, the host is taken over
-			if c.Domain == "" && rnr.endpoint != nil {
-				c.Domain = rnr.endpoint.Host
-			}
-			keyMap[c.Name] = *c
-		}
-
-		d[httpStoreCookieKey] = keyMap
-		o.recordCookie(cookies)
-	} else {
-		d[httpStoreCookieKey] = map[string]http.Cookie{}
+		return nil, nil, err
 	}
 
-	o.record(s.idx, map[string]any{
-		string(httpStoreResponseKey): d,
-	})
-
-	return nil
+	return res, resBody, nil
 }
 
 func mergeURL(u *url.URL, p string) (*url.URL, error) {
@@ -0,0 +1,542 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/maskedio"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	oauth2GrantTypeClientCredentials = "client_credentials"
+	oauth2GrantTypePassword          = "password"
+	oauth2GrantTypeRefreshToken      = "refresh_token"
+)
+
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// httpAuth authenticates requests of the HTTP runner.
+type httpAuth interface {
+	// roundTrip sends the request with the credentials.
+	roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error)
+	// secrets returns the values that should be masked.
+	secrets() []string
+}
+
+// httpAuthTransport is the http.RoundTripper that authenticates requests using httpAuth.
+// Requests that already have the Authorization header are sent as they are.
+type httpAuthTransport struct {
+	auth httpAuth
+	base http.RoundTripper
+	mr   *maskedio.Rule
+}
+
+func (t *httpAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	// Secrets are masked before the request is sent so that the exchange is masked ( e.g. in cassettes ),
+	// and after that to mask the secrets acquired by the exchange ( e.g. the access token of OAuth2 ).
+	t.setSecrets()
+	res, err := t.auth.roundTrip(req, t.base)
+	t.setSecrets()
+	return res, err
+}
+
+func (t *httpAuthTransport) setSecrets() {
+	if t.mr == nil {
+		return
+	}
+	for _, s := range t.auth.secrets() {
+		if s == "" || t.mr.Mask(s) != s {
+			continue
+		}
+		t.mr.SetKeyword(s)
+	}
+}
+
+func newHTTPAuth(c *httpAuthConfig) (httpAuth, error) {
+	var auths []httpAuth
+	if c.OAuth2 != nil {
+		a, err := newOAuth2Auth(c.OAuth2)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, a)
+	}
+	if c.Basic != nil {
+		auths = append(auths, &basicAuth{username: c.Basic.Username, password: c.Basic.Password})
+	}
+	if c.Digest != nil {
+		auths = append(auths, &digestAuth{username: c.Digest.Username, password: c.Digest.Password})
+	}
+	if c.SigV4 != nil {
+		a, err := newSigV4Auth(c.SigV4)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, a)
+	}
+	switch len(auths) {
+	case 0:
+		return nil, errors.New("invalid auth: one of oauth2, basic, digest and sigv4 is required")
+	case 1:
+		return auths[0], nil
+	default:
+		return nil, errors.New("invalid auth: only one of oauth2, basic, digest and sigv4 can be set")
+	}
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+func (a *basicAuth) roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(a.username, a.password)
+	return base.RoundTrip(req)
+}
+
+func (a *basicAuth) secrets() []string {
+	return []string{a.password}
+}
+
+// oauth2Auth acquires the access token and sends requests with the bearer token.
+// The token is cached per runner and refreshed before it expires.
+type oauth2Auth struct {
+	c     *oauth2AuthConfig
+	mu    sync.Mutex
+	src   oauth2.TokenSource
+	token string
+}
+
+func newOAuth2Auth(c *oauth2AuthConfig) (*oauth2Auth, error) {
+	if c.GrantType == "" {
+		c.GrantType = oauth2GrantTypeClientCredentials
+	}
+	if c.TokenURL == "" && c.Issuer == "" {
+		return nil, errors.New("invalid oauth2 auth: tokenURL or issuer is required")
+	}
+	switch c.GrantType {
+	case oauth2GrantTypeClientCredentials:
+		if c.ClientID == "" {
+			return nil, errors.New("invalid oauth2 auth: clientID is required for client_credentials grant")
+		}
+	case oauth2GrantTypePassword:
+		if c.Username == "" {
+			return nil, errors.New("invalid oauth2 auth: username is required for password grant")
+		}
+	case oauth2GrantTypeRefreshToken:
+		if c.RefreshToken == "" {
+			return nil, errors.New("invalid oauth2 auth: refreshToken is required for refresh_token grant")
+		}
+	default:
+		return nil, fmt.Errorf("invalid oauth2 auth: unsupported grantType: %s", c.GrantType)
+	}
+	switch c.AuthStyle {
+	case "", "header", "params":
+	default:
+		return nil, fmt.Errorf("invalid oauth2 auth: unsupported authStyle: %s", c.AuthStyle)
+	}
+	return &oauth2Auth{c: c}, nil
+}
+
+func (a *oauth2Auth) roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error) {
+	src, err := a.tokenSource(req.Context(), base)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire oauth2 token: %w", err)
+	}
+	a.mu.Lock()
+	a.token = tok.AccessToken
+	a.mu.Unlock()
+	req = req.Clone(req.Context())
+	tok.SetAuthHeader(req)
+	return base.RoundTrip(req)
+}
+
+func (a *oauth2Auth) secrets() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return []string{a.c.ClientSecret, a.c.Password, a.c.RefreshToken, a.token}
+}
+
+func (a *oauth2Auth) tokenSource(ctx context.Context, base http.RoundTripper) (oauth2.TokenSource, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.src != nil {
+		return a.src, nil
+	}
+	client := &http.Client{Transport: base}
+	tokenURL := a.c.TokenURL
+	if tokenURL == "" {
+		u, err := discoverTokenEndpoint(ctx, client, a.c.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		tokenURL = u
+	}
+	var style oauth2.AuthStyle
+	switch a.c.AuthStyle {
+	case "header":
+		style = oauth2.AuthStyleInHeader
+	case "params":
+		style = oauth2.AuthStyleInParams
+	}
+	// The token source outlives the request, so it does not use the context of the request
+	tctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	switch a.c.GrantType {
+	case oauth2GrantTypeClientCredentials:
+		cc := &clientcredentials.Config{
+			ClientID:     a.c.ClientID,
+			ClientSecret: a.c.ClientSecret,
+			TokenURL:     tokenURL,
+			Scopes:       a.c.Scopes,
+			AuthStyle:    style,
+		}
+		if len(a.c.EndpointParams) > 0 {
+			cc.EndpointParams = map[string][]string{}
+			for k, v := range a.c.EndpointParams {
+				cc.EndpointParams.Set(k, v)
+			}
+		}
+		a.src = cc.TokenSource(tctx)
+	case oauth2GrantTypePassword:
+		a.src = oauth2.ReuseTokenSource(nil, &passwordTokenSource{
+			ctx:      tctx,
+			conf:     a.oauth2Config(tokenURL, style),
+			username: a.c.Username,
+			password: a.c.Password,
+		})
+	case oauth2GrantTypeRefreshToken:
+		a.src = a.oauth2Config(tokenURL, style).TokenSource(tctx, &oauth2.Token{RefreshToken: a.c.RefreshToken})
+	}
+	return a.src, nil
+}
+
+func (a *oauth2Auth) oauth2Config(tokenURL string, style oauth2.AuthStyle) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     a.c.ClientID,
+		ClientSecret: a.c.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  tokenURL,
+			AuthStyle: style,
+		},
+		Scopes: a.c.Scopes,
+	}
+}
+
+// passwordTokenSource acquires the token using the resource owner password credentials grant.
+// After the first acquisition, the token is refreshed using the refresh token if it is issued.
+type passwordTokenSource struct {
+	ctx      context.Context
+	conf     *oauth2.Config
+	username string
+	password string
+	tok      *oauth2.Token
+}
+
+func (s *passwordTokenSource) Token() (*oauth2.Token, error) {
+	if s.tok != nil && s.tok.RefreshToken != "" {
+		tok, err := s.conf.TokenSource(s.ctx, s.tok).Token()
+		if err == nil {
+			s.tok = tok
+			return tok, nil
+		}
+	}
+	tok, err := s.conf.PasswordCredentialsToken(s.ctx, s.username, s.password)
+	if err != nil {
+		return nil, err
+	}
+	s.tok = tok
+	return tok, nil
+}
+
+// discoverTokenEndpoint returns the token endpoint of the OpenID Provider using OpenID Connect Discovery.
+func discoverTokenEndpoint(ctx context.Context, client *http.Client, issuer string) (string, error) {
+	u := strings.TrimSuffix(issuer, "/") + oidcDiscoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover token endpoint: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to discover token endpoint: %s returned %d", u, res.StatusCode)
+	}
+	var conf struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&conf); err != nil {
+		return "", fmt.Errorf("failed to discover token endpoint: %w", err)
+	}
+	if conf.TokenEndpoint == "" {
+		return "", fmt.Errorf("failed to discover token endpoint: token_endpoint not found in %s", u)
+	}
+	return conf.TokenEndpoint, nil
+}
+
+// digestAuth authenticates requests using HTTP Digest Access Authentication ( RFC 7616 ).
+// The challenge is cached, so the subsequent requests are sent with the credentials at once.
+type digestAuth struct {
+	username  string
+	password  string
+	mu        sync.Mutex
+	challenge map[string]string
+	nc        int
+}
+
+func (a *digestAuth) roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error) {
+	getBody, err := rewindBody(req)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	challenge := a.challenge
+	a.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		r := req.Clone(req.Context())
+		if r.Body, err = getBody(); err != nil {
+			return nil, err
+		}
+		if challenge != nil {
+			h, err := a.authorization(r, challenge)
+			if err != nil {
+				return nil, err
+			}
+			r.Header.Set("Authorization", h)
+		}
+		res, err := base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusUnauthorized || i > 0 {
+			return res, nil
+		}
+		c := parseDigestChallenge(res.Header.Values("WWW-Authenticate"))
+		if c == nil || (challenge != nil && !strings.EqualFold(c["stale"], "true")) {
+			return res, nil
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		a.mu.Lock()
+		a.challenge = c
+		a.nc = 0
+		a.mu.Unlock()
+		challenge = c
+	}
+	return nil, errors.New("failed to authenticate with digest auth")
+}
+
+func (a *digestAuth) secrets() []string {
+	return []string{a.password}
+}
+
+func (a *digestAuth) authorization(req *http.Request, c map[string]string) (string, error) {
+	algorithm := c["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var h func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		h = md5.New
+	case "SHA-256":
+		h = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+	}
+	digest := func(s string) string {
+		hh := h()
+		_, _ = hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+	cnonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	a.nc++
+	nc := fmt.Sprintf("%08x", a.nc)
+	a.mu.Unlock()
+
+	uri := req.URL.RequestURI()
+	ha1 := digest(fmt.Sprintf("%s:%s:%s", a.username, c["realm"], a.password))
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = digest(fmt.Sprintf("%s:%s:%s", ha1, c["nonce"], cnonce))
+	}
+	ha2 := digest(fmt.Sprintf("%s:%s", req.Method, uri))
+	qop := ""
+	for _, q := range strings.Split(c["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+	var response string
+	if qop == "" {
+		response = digest(fmt.Sprintf("%s:%s:%s", ha1, c["nonce"], ha2))
+	} else {
+		response = digest(fmt.Sprintf("%s:%s:%s:%s:%s:%s", ha1, c["nonce"], nc, cnonce, qop, ha2))
+	}
+	params := []string{
+		fmt.Sprintf("username=%q", a.username),
+		fmt.Sprintf("realm=%q", c["realm"]),
+		fmt.Sprintf("nonce=%q", c["nonce"]),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("algorithm=%s", algorithm),
+		fmt.Sprintf("response=%q", response),
+	}
+	if qop != "" {
+		params = append(params, fmt.Sprintf("qop=%s", qop), fmt.Sprintf("nc=%s", nc), fmt.Sprintf("cnonce=%q", cnonce))
+	}
+	if opaque, ok := c["opaque"]; ok {
+		params = append(params, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// parseDigestChallenge parses the parameters of the Digest challenge in WWW-Authenticate headers.
+func parseDigestChallenge(values []string) map[string]string {
+	for _, v := range values {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		params := map[string]string{}
+		for rest != "" {
+			var key, value string
+			key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+			rest = strings.TrimSpace(rest)
+			if strings.HasPrefix(rest, `"`) {
+				// quoted-string
+				var b strings.Builder
+				i := 1
+				for ; i < len(rest) && rest[i] != '"'; i++ {
+					if rest[i] == '\\' && i+1 < len(rest) {
+						i++
+					}
+					b.WriteByte(rest[i])
+				}
+				value = b.String()
+				rest = rest[min(i+1, len(rest)):]
+			} else {
+				value, rest, _ = strings.Cut(rest, ",")
+				value = strings.TrimSpace(value)
+			}
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+			rest = strings.TrimLeft(rest, " ,")
+		}
+		return params
+	}
+	return nil
+}
+
+// sigV4Auth signs requests with AWS Signature Version 4.
+type sigV4Auth struct {
+	region  string
+	service string
+	creds   aws.Credentials
+	signer  *v4.Signer
+}
+
+func newSigV4Auth(c *sigV4AuthConfig) (*sigV4Auth, error) {
+	if c.Region == "" || c.Service == "" {
+		return nil, errors.New("invalid sigv4 auth: region and service are required")
+	}
+	creds := aws.Credentials{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		SessionToken:    c.SessionToken,
+	}
+	if creds.AccessKeyID == "" {
+		creds = aws.Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("invalid sigv4 auth: accessKeyID and secretAccessKey are required")
+	}
+	return &sigV4Auth{
+		region:  c.Region,
+		service: c.Service,
+		creds:   creds,
+		signer:  v4.NewSigner(),
+	}, nil
+}
+
+func (a *sigV4Auth) roundTrip(req *http.Request, base http.RoundTripper) (*http.Response, error) {
+	getBody, err := rewindBody(req)
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	body, err := getBody()
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	sum := sha256.Sum256(b)
+	if err := a.signer.SignHTTP(r.Context(), a.creds, r, hex.EncodeToString(sum[:]), a.service, a.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request with sigv4: %w", err)
+	}
+	return base.RoundTrip(r)
+}
+
+func (a *sigV4Auth) secrets() []string {
+	return []string{a.creds.SecretAccessKey, a.creds.SessionToken}
+}
+
+// rewindBody returns the function that returns a new copy of the request body.
+func rewindBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+	return func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
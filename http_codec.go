@@ -0,0 +1,199 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const httpProtobufMessageHeader = "X-Protobuf-Message"
+
+// httpProtoMu guards the proto files compiled by HTTP runners.
+var httpProtoMu sync.Mutex
+
+// resolveProtoMessage returns the message descriptor of the message type.
+// The message type is looked up in the proto files of the runner ( compiled once ), then in the proto files already loaded ( e.g. by gRPC runners ).
+func (rnr *httpRunner) resolveProtoMessage(ctx context.Context, name string) (protoreflect.MessageDescriptor, error) {
+	httpProtoMu.Lock()
+	defer httpProtoMu.Unlock()
+	if rnr.protoFiles == nil && (len(rnr.protos) > 0 || len(rnr.bufDirs) > 0 || len(rnr.bufLocks) > 0 || len(rnr.bufConfigs) > 0 || len(rnr.bufModules) > 0) {
+		fds, err := compileProtos(ctx, rnr.importPaths, rnr.protos, rnr.bufDirs, rnr.bufLocks, rnr.bufConfigs, rnr.bufModules)
+		if err != nil {
+			return nil, err
+		}
+		rnr.protoFiles = fds
+	}
+	var (
+		d   protoreflect.Descriptor
+		err error
+	)
+	if rnr.protoFiles != nil {
+		d, err = rnr.protoFiles.AsResolver().FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil && !errors.Is(err, protoregistry.NotFound) {
+			return nil, err
+		}
+	}
+	if d == nil {
+		d, err = protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the message type %s: %w", name, err)
+		}
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not the message type", name)
+	}
+	return md, nil
+}
+
+// protoResponseType returns the message type of the response body.
+// The type specified in the request takes precedence over the parameter of Content-Type ( messageType or proto ) and the X-Protobuf-Message header.
+func protoResponseType(r *httpRequest, res *http.Response) string {
+	if r.protoResponseType != "" {
+		return r.protoResponseType
+	}
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err == nil {
+		for _, k := range []string{"messagetype", "proto"} {
+			if v := params[k]; v != "" {
+				return v
+			}
+		}
+	}
+	return res.Header.Get(httpProtobufMessageHeader)
+}
+
+// encodeProtobuf encodes the body to the protobuf binary of the message type.
+// The body is converted in the same way as the message of the gRPC runner.
+func encodeProtobuf(md protoreflect.MessageDescriptor, body any) ([]byte, error) {
+	msg := dynamicpb.NewMessage(md)
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := protojson.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", md.FullName(), err)
+	}
+	return proto.Marshal(msg)
+}
+
+// decodeProtobuf decodes the protobuf binary of the message type in the same way as the message of the gRPC runner.
+func decodeProtobuf(md protoreflect.MessageDescriptor, b []byte) (map[string]any, error) {
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", md.FullName(), err)
+	}
+	jb, err := protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true, EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(jb, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// decodeMsgPack decodes MessagePack into the value in the same types as JSON.
+func decodeMsgPack(b []byte) (any, error) {
+	var v any
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (any, error) {
+		return d.DecodeUntypedMap()
+	})
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return normalizeBinaryBody(v)
+}
+
+// decodeCBOR decodes CBOR into the value in the same types as JSON.
+func decodeCBOR(b []byte) (any, error) {
+	var v any
+	if err := cbor.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return normalizeBinaryBody(v)
+}
+
+// normalizeBinaryBody converts the decoded value to the value in the same types as the JSON body ( e.g. numbers are float64 ), so that tests can assert on them in the same way.
+func normalizeBinaryBody(v any) (any, error) {
+	b, err := json.Marshal(normalizeMapKeys(v))
+	if err != nil {
+		return nil, err
+	}
+	var n any
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// normalizeMapKeys converts keys of maps to strings.
+func normalizeMapKeys(v any) any {
+	switch vv := v.(type) {
+	case map[any]any:
+		m := map[string]any{}
+		for k, e := range vv {
+			m[fmt.Sprint(k)] = normalizeMapKeys(e)
+		}
+		return m
+	case map[string]any:
+		for k, e := range vv {
+			vv[k] = normalizeMapKeys(e)
+		}
+		return vv
+	case []any:
+		for i, e := range vv {
+			vv[i] = normalizeMapKeys(e)
+		}
+		return vv
+	default:
+		return v
+	}
+}
+
+func isProtobufContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case MediaTypeApplicationProtobuf, "application/protobuf", "application/vnd.google.protobuf":
+		return true
+	}
+	return false
+}
+
+func isMsgPackContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case MediaTypeApplicationMsgPack, MediaTypeApplicationXMsgPack, "application/vnd.msgpack":
+		return true
+	}
+	return false
+}
+
+func isCBORContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == MediaTypeApplicationCBOR
+}
@@ -0,0 +1,208 @@
+package runn
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/go-cmp/cmp"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestHTTPRunnerWithProtobuf(t *testing.T) {
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newHTTPRunnerWithHandler("req", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.protos = []string{"testdata/grpcserver.proto"}
+	reqDesc, err := r.resolveProtoMessage(ctx, "grpcserver.HelloRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resDesc, err := r.resolveProtoMessage(ctx, "grpcserver.HelloResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := http.NewServeMux()
+	s.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != MediaTypeApplicationProtobuf {
+			t.Errorf("got %v\nwant %v", got, MediaTypeApplicationProtobuf)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := dynamicpb.NewMessage(reqDesc)
+		if err := proto.Unmarshal(b, req); err != nil {
+			t.Fatal(err)
+		}
+		name := req.Get(reqDesc.Fields().ByName("name")).String()
+		num := req.Get(reqDesc.Fields().ByName("num")).Int()
+		res := dynamicpb.NewMessage(resDesc)
+		res.Set(resDesc.Fields().ByName("message"), protoreflect.ValueOfString("hello "+name))
+		res.Set(resDesc.Fields().ByName("num"), protoreflect.ValueOfInt32(int32(num)))
+		rb, err := proto.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", MediaTypeApplicationProtobuf+"; messageType=grpcserver.HelloResponse")
+		_, _ = w.Write(rb)
+	})
+	r.handler = s
+
+	req := &httpRequest{
+		path:      "/hello",
+		method:    http.MethodPost,
+		mediaType: MediaTypeApplicationProtobuf,
+		headers:   http.Header{},
+		body: map[string]any{
+			"name": "alice",
+			"num":  uint64(3),
+		},
+		protoRequestType: "grpcserver.HelloRequest",
+	}
+	step := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, step); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	want := map[string]any{
+		"message":     "hello alice",
+		"num":         float64(3),
+		"create_time": nil,
+	}
+	if diff := cmp.Diff(res["body"], any(want)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestHTTPRunnerWithMsgPackAndCBOR(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		unmarshal func([]byte, any) error
+		marshal   func(any) ([]byte, error)
+	}{
+		{MediaTypeApplicationMsgPack, msgpack.Unmarshal, msgpack.Marshal},
+		{MediaTypeApplicationCBOR, cbor.Unmarshal, cbor.Marshal},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := http.NewServeMux()
+			s.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Content-Type"); got != tt.mediaType {
+					t.Errorf("got %v\nwant %v", got, tt.mediaType)
+				}
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var v map[string]any
+				if err := tt.unmarshal(b, &v); err != nil {
+					t.Fatal(err)
+				}
+				if v["name"] != "alice" {
+					t.Errorf("got %v\nwant %v", v["name"], "alice")
+				}
+				rb, err := tt.marshal(map[string]any{
+					"id":     1,
+					"name":   v["name"],
+					"groups": []any{"admin", "users"},
+					"attrs":  map[int]string{1: "a"},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Header().Set("Content-Type", tt.mediaType)
+				_, _ = w.Write(rb)
+			})
+			r, err := newHTTPRunnerWithHandler("req", s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &httpRequest{
+				path:      "/users",
+				method:    http.MethodPost,
+				mediaType: tt.mediaType,
+				headers:   http.Header{},
+				body: map[string]any{
+					"name": "alice",
+				},
+			}
+			step := newStep(0, "stepKey", o, nil)
+			if err := r.run(ctx, req, step); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.Latest()["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+			}
+			want := map[string]any{
+				"id":     float64(1),
+				"name":   "alice",
+				"groups": []any{"admin", "users"},
+				"attrs":  map[string]any{"1": "a"},
+			}
+			if diff := cmp.Diff(res["body"], any(want)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestProtoResponseType(t *testing.T) {
+	tests := []struct {
+		req    *httpRequest
+		header http.Header
+		want   string
+	}{
+		{
+			&httpRequest{protoResponseType: "a.Res"},
+			http.Header{"Content-Type": []string{"application/x-protobuf; messageType=b.Res"}},
+			"a.Res",
+		},
+		{
+			&httpRequest{},
+			http.Header{"Content-Type": []string{"application/x-protobuf; messageType=b.Res"}},
+			"b.Res",
+		},
+		{
+			&httpRequest{},
+			http.Header{"Content-Type": []string{"application/x-protobuf; proto=c.Res"}},
+			"c.Res",
+		},
+		{
+			&httpRequest{},
+			http.Header{"Content-Type": []string{"application/x-protobuf"}, "X-Protobuf-Message": []string{"d.Res"}},
+			"d.Res",
+		},
+		{
+			&httpRequest{},
+			http.Header{"Content-Type": []string{"application/x-protobuf"}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		got := protoResponseType(tt.req, &http.Response{Header: tt.header})
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
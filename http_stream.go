@@ -0,0 +1,237 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+const (
+	httpStoreMessagesKey = "messages"
+	httpStoreMessageKey  = "message"
+)
+
+const (
+	httpMessageStoreIDKey         = "id"
+	httpMessageStoreEventKey      = "event"
+	httpMessageStoreDataKey       = "data"
+	httpMessageStoreRetryKey      = "retry"
+	httpMessageStoreBodyKey       = "body"
+	httpMessageStoreElapsedKey    = "elapsed"
+	httpMessageStoreReceivedAtKey = "receivedAt"
+)
+
+const defaultSSEEventType = "message"
+
+// httpStream - The condition to stop reading the streaming response.
+type httpStream struct {
+	until   string
+	timeout time.Duration
+}
+
+// readStream reads the body of text/event-stream or application/x-ndjson message by message
+// until the body ends, the until condition is met or the timeout is reached.
+// It returns the messages and the raw body that has been read.
+func readStream(o *operator, r *httpRequest, res *http.Response, started time.Time) ([]any, []byte, error) {
+	buf := &bytes.Buffer{}
+	var rd io.Reader = io.TeeReader(res.Body, buf)
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(rd)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gr.Close()
+		rd = gr
+	}
+	var (
+		until    string
+		timedOut atomic.Bool
+	)
+	if r.stream != nil {
+		until = r.stream.until
+		if r.stream.timeout > 0 {
+			t := time.AfterFunc(r.stream.timeout, func() {
+				timedOut.Store(true)
+				_ = res.Body.Close()
+			})
+			defer t.Stop()
+		}
+	}
+
+	messages := []any{}
+	d := map[string]any{
+		httpStoreStatusKey:   res.StatusCode,
+		httpStoreHeaderKey:   res.Header,
+		httpStoreMessagesKey: messages,
+		httpStoreMessageKey:  nil,
+	}
+	onMessage := func(m map[string]any) (bool, error) {
+		now := time.Now()
+		m[httpMessageStoreElapsedKey] = now.Sub(started).Milliseconds()
+		m[httpMessageStoreReceivedAtKey] = now
+		messages = append(messages, m)
+		d[httpStoreMessagesKey] = messages
+		d[httpStoreMessageKey] = m
+		if until == "" {
+			return false, nil
+		}
+		sm := o.store.ToMap()
+		sm[store.RootKeyCurrent] = map[string]any{
+			httpStoreResponseKey: d,
+		}
+		return expr.EvalCond(until, sm)
+	}
+
+	var err error
+	if isEventStreamContentType(res.Header.Get("Content-Type")) {
+		err = readSSE(rd, onMessage)
+	} else {
+		err = readNDJSON(rd, onMessage)
+	}
+	if err != nil && !timedOut.Load() {
+		return nil, nil, err
+	}
+	return messages, buf.Bytes(), nil
+}
+
+// readSSE parses Server-Sent Events and calls fn for each event until fn returns true.
+// ref: https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func readSSE(rd io.Reader, fn func(m map[string]any) (bool, error)) error {
+	br := bufio.NewReader(rd)
+	var (
+		id    string
+		event string
+		data  []string
+		retry any
+	)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// An incomplete event at the end of the stream is discarded.
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			if len(data) == 0 {
+				event = ""
+				continue
+			}
+			if event == "" {
+				event = defaultSSEEventType
+			}
+			m := map[string]any{
+				httpMessageStoreIDKey:    id,
+				httpMessageStoreEventKey: event,
+				httpMessageStoreDataKey:  strings.Join(data, "\n"),
+				httpMessageStoreRetryKey: retry,
+				httpMessageStoreBodyKey:  nil,
+			}
+			var v any
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &v); err == nil {
+				m[httpMessageStoreBodyKey] = v
+			}
+			event = ""
+			data = nil
+			stop, err := fn(m)
+			if err != nil {
+				return err
+			}
+			if stop {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				id = value
+			}
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				retry = n
+			}
+		}
+	}
+}
+
+// readNDJSON parses newline delimited JSON and calls fn for each line until fn returns true.
+func readNDJSON(rd io.Reader, fn func(m map[string]any) (bool, error)) error {
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		eof := err != nil
+		line = strings.TrimSpace(line)
+		if line != "" {
+			var v any
+			if err := json.Unmarshal([]byte(line), &v); err != nil {
+				return fmt.Errorf("invalid NDJSON line: %s: %w", line, err)
+			}
+			stop, err := fn(map[string]any{
+				httpMessageStoreDataKey: line,
+				httpMessageStoreBodyKey: v,
+			})
+			if err != nil {
+				return err
+			}
+			if stop {
+				return nil
+			}
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+func isStreamContentType(contentType string) bool {
+	return isEventStreamContentType(contentType) || isNDJSONContentType(contentType)
+}
+
+func isEventStreamContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == MediaTypeTextEventStream
+}
+
+func isNDJSONContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case MediaTypeApplicationNDJSON, "application/ndjson", "application/jsonl":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadSSE(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []map[string]any
+	}{
+		{
+			"data: hello\n\n",
+			[]map[string]any{
+				{"id": "", "event": "message", "data": "hello", "retry": nil, "body": nil},
+			},
+		},
+		{
+			": comment\nid: 1\nevent: delta\ndata: {\"text\":\"a\"}\n\ndata: first\ndata: second\n\n",
+			[]map[string]any{
+				{"id": "1", "event": "delta", "data": `{"text":"a"}`, "retry": nil, "body": map[string]any{"text": "a"}},
+				{"id": "1", "event": "message", "data": "first\nsecond", "retry": nil, "body": nil},
+			},
+		},
+		{
+			"retry: 3000\r\nevent: ping\r\n\r\ndata:no space\r\n\r\ndata: incomplete",
+			[]map[string]any{
+				{"id": "", "event": "message", "data": "no space", "retry": uint64(3000), "body": nil},
+			},
+		},
+		{
+			"data\n\ndata: [DONE]\n\n",
+			[]map[string]any{
+				{"id": "", "event": "message", "data": "", "retry": nil, "body": nil},
+				{"id": "", "event": "message", "data": "[DONE]", "retry": nil, "body": nil},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := []map[string]any{}
+			if err := readSSE(strings.NewReader(tt.in), func(m map[string]any) (bool, error) {
+				got = append(got, m)
+				return false, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			"{\"n\":1}\n\n{\"n\":2}\r\n[3]",
+			[]map[string]any{
+				{"data": `{"n":1}`, "body": map[string]any{"n": float64(1)}},
+				{"data": `{"n":2}`, "body": map[string]any{"n": float64(2)}},
+				{"data": `[3]`, "body": []any{float64(3)}},
+			},
+			false,
+		},
+		{
+			"{\"n\":1}\nnot json\n",
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := []map[string]any{}
+			err := readNDJSON(strings.NewReader(tt.in), func(m map[string]any) (bool, error) {
+				got = append(got, m)
+				return false, nil
+			})
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestHTTPRunnerWithStream(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeTextEventStream)
+		f, _ := w.(http.Flusher)
+		for i := 0; ; i++ {
+			if i == 3 {
+				_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+				f.Flush()
+				<-r.Context().Done()
+				return
+			}
+			_, _ = fmt.Fprintf(w, "id: %d\ndata: {\"n\":%d}\n\n", i, i)
+			f.Flush()
+		}
+	})
+	mux.HandleFunc("/ndjson", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeApplicationNDJSON)
+		f, _ := w.(http.Flusher)
+		for i := range 2 {
+			_, _ = fmt.Fprintf(w, "{\"n\":%d}\n", i)
+			f.Flush()
+		}
+	})
+	mux.HandleFunc("/stall", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", MediaTypeTextEventStream)
+		f, _ := w.(http.Flusher)
+		_, _ = fmt.Fprint(w, "data: first\n\n")
+		f.Flush()
+		<-r.Context().Done()
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		req       *httpRequest
+		wantData  []string
+		wantLast  string
+		wantRawHd string
+	}{
+		{
+			&httpRequest{
+				path:    "/sse",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				stream:  &httpStream{until: `current.res.message.data == "[DONE]"`},
+			},
+			[]string{`{"n":0}`, `{"n":1}`, `{"n":2}`, "[DONE]"},
+			"[DONE]",
+			"id: 0\n",
+		},
+		{
+			&httpRequest{
+				path:    "/ndjson",
+				method:  http.MethodGet,
+				headers: http.Header{},
+			},
+			[]string{`{"n":0}`, `{"n":1}`},
+			`{"n":1}`,
+			`{"n":0}`,
+		},
+		{
+			&httpRequest{
+				path:    "/stall",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				stream:  &httpStream{timeout: 100 * time.Millisecond},
+			},
+			[]string{"first"},
+			"first",
+			"data: first",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		// Streams are read in the same way while recording and replaying exchanges
+		dir := t.TempDir()
+		rec := newCassettes(dir, false)
+		for _, mode := range []string{"", "record", "replay"} {
+			t.Run(fmt.Sprintf("%s %s", tt.req.path, mode), func(t *testing.T) {
+				o, err := New()
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := newHTTPRunner("req", ts.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				o.id = "stream" // Replay the cassette recorded by another operator
+				switch mode {
+				case "record":
+					r.cassettes = rec
+				case "replay":
+					r.cassettes = newCassettes(dir, true)
+				}
+				s := newStep(0, "stepKey", o, nil)
+				if err := r.run(ctx, tt.req, s); err != nil {
+					t.Fatal(err)
+				}
+				if mode == "record" {
+					if err := rec.flush(s.runbookID()); err != nil {
+						t.Fatal(err)
+					}
+				}
+				testHTTPStreamResult(t, o, tt.wantData, tt.wantLast, tt.wantRawHd)
+			})
+		}
+	}
+}
+
+func testHTTPStreamResult(t *testing.T, o *operator, wantData []string, wantLast, wantRawHd string) {
+	t.Helper()
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	messages, ok := res["messages"].([]any)
+	if !ok {
+		t.Fatalf("invalid messages: %#v", res["messages"])
+	}
+	got := []string{}
+	for _, m := range messages {
+		mm := m.(map[string]any)
+		got = append(got, mm["data"].(string))
+		if _, ok := mm["elapsed"].(int64); !ok {
+			t.Errorf("invalid elapsed: %#v", mm["elapsed"])
+		}
+		if _, ok := mm["receivedAt"].(time.Time); !ok {
+			t.Errorf("invalid receivedAt: %#v", mm["receivedAt"])
+		}
+	}
+	if diff := cmp.Diff(got, wantData); diff != "" {
+		t.Error(diff)
+	}
+	if got := res["message"].(map[string]any)["data"]; got != wantLast {
+		t.Errorf("got %v\nwant %v", got, wantLast)
+	}
+	if res["body"] != nil {
+		t.Errorf("got %v\nwant nil", res["body"])
+	}
+	if !strings.HasPrefix(res["rawBody"].(string), wantRawHd) {
+		t.Errorf("got %v\nwant prefix %v", res["rawBody"], wantRawHd)
+	}
+}
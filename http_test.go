@@ -631,6 +631,75 @@ func TestHTTPRunnerWithHandler(t *testing.T) {
 	}
 }
 
+func TestHTTPRunnerWithXML(t *testing.T) {
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := http.NewServeMux()
+	s.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Content-Type"); got != MediaTypeTextXML {
+			t.Errorf("got %v\nwant %v", got, MediaTypeTextXML)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetUser><id>1</id></GetUser></soap:Body></soap:Envelope>`
+		if string(b) != want {
+			t.Errorf("got %v\nwant %v", string(b), want)
+		}
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		_, _ = w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetUserResponse><user id="1">alice</user></GetUserResponse></soap:Body></soap:Envelope>`))
+	})
+	r, err := newHTTPRunnerWithHandler("req", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &httpRequest{
+		path:      "/users",
+		method:    http.MethodPost,
+		mediaType: MediaTypeTextXML,
+		headers:   http.Header{},
+		body: map[string]any{
+			"soap:Envelope": map[string]any{
+				"@xmlns:soap": "http://schemas.xmlsoap.org/soap/envelope/",
+				"soap:Body": map[string]any{
+					"GetUser": map[string]any{
+						"id": uint64(1),
+					},
+				},
+			},
+		},
+	}
+	step := newStep(0, "stepKey", o, nil)
+	if err := r.run(ctx, req, step); err != nil {
+		t.Fatal(err)
+	}
+	res, ok := o.store.Latest()["res"].(map[string]any)
+	if !ok {
+		t.Fatalf("invalid res: %#v", o.store.Latest()["res"])
+	}
+	want := map[string]any{
+		"soap:Envelope": map[string]any{
+			"@xmlns:soap": "http://schemas.xmlsoap.org/soap/envelope/",
+			"soap:Body": map[string]any{
+				"GetUserResponse": map[string]any{
+					"user": map[string]any{
+						"@id":   "1",
+						"#text": "alice",
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(res["body"], any(want)); diff != "" {
+		t.Error(diff)
+	}
+}
+
 func TestNotFollowRedirect(t *testing.T) {
 	tests := []struct {
 		req               *httpRequest
@@ -240,12 +240,31 @@ func (o *operator) exportOptionsToBePropagated() []Option {
 	for k, r := range o.sshRunners {
 		opts = append(opts, reuseSSHRunner(k, r))
 	}
+	for k, r := range o.graphqlRunners {
+		opts = append(opts, reuseGraphQLRunner(k, r))
+	}
+	for k, r := range o.wsRunners {
+		opts = append(opts, reuseWSRunner(k, r))
+	}
+	for k, r := range o.serverRunners {
+		opts = append(opts, reuseServerRunner(k, r))
+	}
+	for k, r := range o.grpcServerRunners {
+		opts = append(opts, reuseGRPCServerRunner(k, r))
+	}
 
 	opts = append(opts, Debug(o.debug))
 	opts = append(opts, Profile(o.profile))
 	opts = append(opts, SkipTest(o.skipTest))
 	opts = append(opts, Force(o.force))
 	opts = append(opts, Trace(o.trace))
+	opts = append(opts, UpdateSnapshots(o.updateSnapshots))
+	if o.cassettes != nil {
+		opts = append(opts, withCassettes(o.cassettes))
+	}
+	if o.grpcDescriptors != nil {
+		opts = append(opts, withGRPCDescriptors(o.grpcDescriptors))
+	}
 	for k, f := range o.store.Funcs() {
 		opts = append(opts, Func(k, f))
 	}
@@ -0,0 +1,181 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+const inlineJSONSchemaURL = "urn:runn:jsonschema:inline"
+
+var jsonSchemaPrinter = message.NewPrinter(language.English)
+
+// JSONSchemaLoadFunc loads the JSON Schema document of the location.
+// It returns the absolute URL of the document that is used to resolve `$ref`, and the content of the document.
+type JSONSchemaLoadFunc func(loc string) (string, []byte, error)
+
+type JSONSchema struct {
+	load  JSONSchemaLoadFunc
+	mu    sync.Mutex
+	cache map[string]*jsonschema.Schema
+}
+
+func NewJSONSchema(load JSONSchemaLoadFunc) *JSONSchema {
+	return &JSONSchema{
+		load:  load,
+		cache: map[string]*jsonschema.Schema{},
+	}
+}
+
+// Validate validates the value against the JSON Schema.
+// The schema is a location of the schema document ( path or URL ) or the schema itself.
+// It returns {"valid": bool, "errors": [{"instancePath": string, "keywordPath": string, "message": string}]}.
+func (j *JSONSchema) Validate(v, schema any) (map[string]any, error) {
+	sch, err := j.compile(schema)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := normalizeJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+	errs := []any{}
+	if err := sch.Validate(instance); err != nil {
+		var verr *jsonschema.ValidationError
+		if !errors.As(err, &verr) {
+			return nil, err
+		}
+		for _, u := range leafOutputUnits(verr.DetailedOutput()) {
+			errs = append(errs, map[string]any{
+				"instancePath": u.InstanceLocation,
+				"keywordPath":  u.KeywordLocation,
+				"message":      u.Error.Kind.LocalizedString(jsonSchemaPrinter),
+			})
+		}
+	}
+	return map[string]any{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	}, nil
+}
+
+// Valid returns whether the value is valid against the JSON Schema.
+func (j *JSONSchema) Valid(v, schema any) (bool, error) {
+	res, err := j.Validate(v, schema)
+	if err != nil {
+		return false, err
+	}
+	return res["valid"].(bool), nil
+}
+
+// JSONSchemaViolations returns violations of the result of Validate as strings ( e.g. "/name: got number, want string" ).
+func JSONSchemaViolations(res any) []string {
+	m, ok := res.(map[string]any)
+	if !ok {
+		return nil
+	}
+	errs, ok := m["errors"].([]any)
+	if !ok {
+		return nil
+	}
+	var violations []string
+	for _, e := range errs {
+		ee, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		p := ee["instancePath"]
+		if p == "" {
+			p = "/"
+		}
+		violations = append(violations, fmt.Sprintf("%s: %s", p, ee["message"]))
+	}
+	return violations
+}
+
+func (j *JSONSchema) compile(schema any) (*jsonschema.Schema, error) {
+	var (
+		key string
+		doc any
+	)
+	switch s := schema.(type) {
+	case string:
+		key = s
+	default:
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+		}
+		key = string(b)
+		doc, err = jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+		}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if sch, ok := j.cache[key]; ok {
+		return sch, nil
+	}
+	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonSchemaLoader(j.load))
+	loc := inlineJSONSchemaURL
+	if doc == nil {
+		u, b, err := j.load(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JSON Schema %s: %w", key, err)
+		}
+		doc, err = jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON Schema %s: %w", key, err)
+		}
+		loc = u
+	}
+	if err := c.AddResource(loc, doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	sch, err := c.Compile(loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	j.cache[key] = sch
+	return sch, nil
+}
+
+// jsonSchemaLoader loads documents referenced by `$ref`.
+type jsonSchemaLoader JSONSchemaLoadFunc
+
+func (l jsonSchemaLoader) Load(u string) (any, error) {
+	_, b, err := l(u)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(b))
+}
+
+// normalizeJSONValue converts the value to the JSON value that the validator accepts.
+func normalizeJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(b))
+}
+
+// leafOutputUnits returns the output units that have the error ( the causes of the validation error ).
+func leafOutputUnits(u *jsonschema.OutputUnit) []jsonschema.OutputUnit {
+	if u.Error != nil {
+		return []jsonschema.OutputUnit{*u}
+	}
+	var units []jsonschema.OutputUnit
+	for _, e := range u.Errors {
+		units = append(units, leafOutputUnits(&e)...)
+	}
+	return units
+}
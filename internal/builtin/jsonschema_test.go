@@ -0,0 +1,87 @@
+package builtin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema := map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+		"properties": map[string]any{
+			"id":   map[string]any{"type": "integer"},
+			"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	tests := []struct {
+		v              any
+		wantValid      bool
+		wantViolations []string
+	}{
+		{map[string]any{"id": 1}, true, nil},
+		{map[string]any{"id": 1, "tags": []any{"a", "b"}}, true, nil},
+		{map[string]any{"id": "1"}, false, []string{"/id: got string, want integer"}},
+		{map[string]any{"tags": []any{"a", 2}}, false, []string{"/: missing property 'id'", "/tags/1: got number, want string"}},
+		{[]any{}, false, []string{"/: got array, want object"}},
+	}
+	js := NewJSONSchema(func(loc string) (string, []byte, error) {
+		return "", nil, errors.New("not found")
+	})
+	for _, tt := range tests {
+		got, err := js.Validate(tt.v, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got["valid"] != tt.wantValid {
+			t.Errorf("got %v want %v", got["valid"], tt.wantValid)
+		}
+		if diff := cmp.Diff(JSONSchemaViolations(got), tt.wantViolations); diff != "" {
+			t.Error(diff)
+		}
+		valid, err := js.Valid(tt.v, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if valid != tt.wantValid {
+			t.Errorf("got %v want %v", valid, tt.wantValid)
+		}
+	}
+}
+
+func TestJSONSchemaLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "user.json"), []byte(`{"type":"object","properties":{"name":{"$ref":"name.json"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "name.json"), []byte(`{"type":"string","minLength":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var loaded []string
+	js := NewJSONSchema(func(loc string) (string, []byte, error) {
+		loaded = append(loaded, loc)
+		p := filepath.Join(dir, filepath.Base(loc))
+		b, err := os.ReadFile(p)
+		return "file://" + filepath.ToSlash(p), b, err
+	})
+	for range 2 {
+		got, err := js.Validate(map[string]any{"name": ""}, "user.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(JSONSchemaViolations(got), []string{"/name: minLength: got 0, want 1"}); diff != "" {
+			t.Error(diff)
+		}
+	}
+	want := []string{"user.json", "file://" + filepath.ToSlash(filepath.Join(dir, "name.json"))}
+	if diff := cmp.Diff(loaded, want); diff != "" {
+		t.Error(diff)
+	}
+	if _, err := js.Validate(map[string]any{}, "notfound.json"); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,71 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+)
+
+// XPath returns the value selected by the XPath expression from the XML document.
+// If the expression selects nodes, it returns the value of the first node ( nil if no node is selected ).
+// If the expression returns a number, a string or a boolean ( e.g. `count(//item)` ), it returns the value as it is.
+func XPath(doc any, expr string) (any, error) {
+	v, err := evaluateXPath(doc, expr)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := v.([]any)
+	if !ok {
+		return v, nil
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values[0], nil
+}
+
+// XPathAll returns the values of all nodes selected by the XPath expression from the XML document.
+func XPathAll(doc any, expr string) ([]any, error) {
+	v, err := evaluateXPath(doc, expr)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := v.([]any)
+	if !ok {
+		return []any{v}, nil
+	}
+	return values, nil
+}
+
+func evaluateXPath(doc any, expr string) (any, error) {
+	var b []byte
+	switch v := doc.(type) {
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", doc)
+	}
+	root, err := xmlquery.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+	e, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath %q: %w", expr, err)
+	}
+	switch v := e.Evaluate(xmlquery.CreateXPathNavigator(root)).(type) {
+	case *xpath.NodeIterator:
+		values := []any{}
+		for v.MoveNext() {
+			values = append(values, strings.TrimSpace(v.Current().Value()))
+		}
+		return values, nil
+	default:
+		return v, nil
+	}
+}
@@ -0,0 +1,79 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testXPathDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <ListUsersResponse>
+      <user id="1">alice</user>
+      <user id="2">bob</user>
+    </ListUsersResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXPath(t *testing.T) {
+	tests := []struct {
+		doc     any
+		expr    string
+		want    any
+		wantErr bool
+	}{
+		{testXPathDoc, "//user", "alice", false},
+		{testXPathDoc, "//user[@id='2']", "bob", false},
+		{testXPathDoc, "//user[2]/@id", "2", false},
+		{testXPathDoc, "/soap:Envelope/soap:Body/ListUsersResponse/user", "alice", false},
+		{testXPathDoc, "//group", nil, false},
+		{testXPathDoc, "count(//user)", float64(2), false},
+		{testXPathDoc, "boolean(//user[.='bob'])", true, false},
+		{[]byte(testXPathDoc), "string(//user[1]/@id)", "1", false},
+		{testXPathDoc, "//user[", nil, true},
+		{"not xml", "//user", nil, true},
+		{map[string]any{"user": "alice"}, "//user", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := XPath(tt.doc, tt.expr)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestXPathAll(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{"//user", []any{"alice", "bob"}},
+		{"//user/@id", []any{"1", "2"}},
+		{"//group", []any{}},
+		{"count(//user)", []any{float64(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := XPathAll(testXPathDoc, tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
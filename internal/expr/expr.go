@@ -280,4 +280,26 @@ func onPrintTraceCallNode(tp exprtrace.TreePrinter, tree treeprint.Tree, callNod
 
 		tree.AddNode(fmt.Sprintf("(diff) => %s", strings.TrimSuffix(diff, "\n")))
 	}
+
+	switch callNode.Callee.String() {
+	case "jsonschema":
+		for _, v := range builtin.JSONSchemaViolations(callOutput) {
+			tree.AddNode(fmt.Sprintf("(violation) => %s", v))
+		}
+	case "jsonschemaValid":
+		if callOutput != false || len(callNode.Arguments) != 2 {
+			return
+		}
+		validate, ok := tp.EvalEnv()["jsonschema"].(func(any, any) (map[string]any, error))
+		if !ok {
+			return
+		}
+		res, err := validate(tp.PeekNodeEvalResultOutput(callNode.Arguments[0]), tp.PeekNodeEvalResultOutput(callNode.Arguments[1]))
+		if err != nil {
+			return
+		}
+		for _, v := range builtin.JSONSchemaViolations(res) {
+			tree.AddNode(fmt.Sprintf("(violation) => %s", v))
+		}
+	}
 }
@@ -10,6 +10,7 @@ import (
 )
 
 func TestEvalWithTraceFormatTraceTree(t *testing.T) {
+	js := builtin.NewJSONSchema(nil)
 	tests := []struct {
 		in   string
 		env  exprtrace.EvalEnv
@@ -508,6 +509,28 @@ func TestEvalWithTraceFormatTraceTree(t *testing.T) {
 │     }
 ├── vars.v1 => {"a":1,"b":"foo","c":[1,2,3]}
 └── vars.v2 => {"a":2,"b":"bar","c":[1,2,3,4]}
+`,
+		},
+		{
+			"jsonschemaValid(vars.v, vars.schema)",
+			exprtrace.EvalEnv{
+				"vars": map[string]any{
+					"v": map[string]any{
+						"name": 1,
+					},
+					"schema": map[string]any{
+						"type":     "object",
+						"required": []any{"id"},
+					},
+				},
+				"jsonschema":      js.Validate,
+				"jsonschemaValid": js.Valid,
+			},
+			`jsonschemaValid(vars.v, vars.schema)
+│
+├── (violation) => /: missing property 'id'
+├── vars.v => {"name":1}
+└── vars.schema => {"required":["id"],"type":"object"}
 `,
 		},
 	}
@@ -23,56 +23,71 @@ var intRe = regexp.MustCompile(`^\-?[0-9]+$`)
 var floatRe = regexp.MustCompile(`^\-?[0-9.]+$`)
 
 type Flags struct {
-	Debug           bool     `usage:"debug"`
-	Long            bool     `usage:"long format"`
-	FailFast        bool     `usage:"fail fast"`
-	SkipTest        bool     `usage:"skip \"test:\" section"`
-	SkipIncluded    bool     `usage:"skip running the included runbook by itself"`
-	RunMatch        string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
-	RunIDs          []string `usage:"run the matching runbooks in order if there is only one runbook with a forward matching ID"`
-	RunLabels       []string `usage:"run all runbooks matching the label specification"`
-	HTTPOpenApi3s   []string `usage:"set the path to the OpenAPI v3 document for HTTP runners (\"path/to/spec.yml\" or \"key:path/to/spec.yml\")"`
-	GRPCNoTLS       bool     `usage:"disable TLS use in all gRPC runners"`
-	GRPCProtos      []string `usage:"set the name of proto source for gRPC runners"`
-	GRPCImportPaths []string `usage:"set the path to the directory where proto sources can be imported for gRPC runners"`
-	GRPCBufDirs     []string `usage:"set the path to the buf directory for gRPC runners"`
-	GRPCBufLocks    []string `usage:"set the path to buf.lock for gRPC runners"`
-	GRPCBufConfigs  []string `usage:"set the path to buf.yaml for gRPC runners"`
-	GRPCBufModules  []string `usage:"set the buf modules for gRPC runners (\"buf.build/owner/repository\" or \"buf.build/owner/repository/tree/branch-or-commit\")"`
-	CaptureDir      string   `usage:"destination of runbook run capture results"`
-	Vars            []string `usage:"set var to runbook (\"key:value\")"`
-	Runners         []string `usage:"set runner to runbook (\"key:dsn\")"`
-	Overlays        []string `usage:"overlay values on the runbook"`
-	Underlays       []string `usage:"lay values under the runbook"`
-	Sample          int      `usage:"sample the specified number of runbooks"`
-	Shuffle         string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
-	Concurrent      string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
-	ShardIndex      int      `usage:"index of distributed runbooks"`
-	ShardN          int      `usage:"number of shards for distributing runbooks"`
-	Random          int      `usage:"run the specified number of runbooks at random"`
-	Desc            string   `usage:"description of runbook"`
-	Out             string   `usage:"target path of runbook"`
-	Format          string   `usage:"format of result output"`
-	AndRun          bool     `usage:"run created runbook and capture the response for test"`
-	LoadTConcurrent int      `usage:"number of concurrent load test runs. 0 means unlimited"`
-	LoadTDuration   string   `usage:"load test running duration"`
-	LoadTWarmUp     string   `usage:"warn-up time for load test"`
-	LoadTThreshold  string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
-	LoadTMaxRPS     int      `usage:"max RunN per second for load test. 0 means unlimited"`
-	Profile         bool     `usage:"profile runs of runbooks"`
-	ProfileOut      string   `usage:"profile output path"`
-	ProfileDepth    int      `usage:"depth of profile"`
-	ProfileUnit     string   `usage:"-"`
-	ProfileSort     string   `usage:"-"`
-	Attach          bool     `usage:"attach to runn process"`
-	CacheDir        string   `usage:"specify cache directory for remote runbooks"`
-	RetainCacheDir  bool     `usage:"retain cache directory for remote runbooks"`
-	Scopes          []string `usage:"additional scopes for runn"`
-	HostRules       []string `usage:"host rules for runn. (\"host rule,host rule,...\")"`
-	WaitTimeout     string   `usage:"timeout for waiting for cleanup process after running runbooks"`
-	EnvFile         string   `usage:"load environment variables from a file"`
-	ForceColor      bool     `usage:"force colorized output even in non-tty output streams"`
-	Verbose         bool     `usage:"verbose"`
+	Debug               bool     `usage:"debug"`
+	Long                bool     `usage:"long format"`
+	FailFast            bool     `usage:"fail fast"`
+	SkipTest            bool     `usage:"skip \"test:\" section"`
+	UpdateSnapshots     bool     `usage:"create or update snapshot files of \"snapshot:\" section with current values"`
+	SkipIncluded        bool     `usage:"skip running the included runbook by itself"`
+	RunMatch            string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
+	RunIDs              []string `usage:"run the matching runbooks in order if there is only one runbook with a forward matching ID"`
+	RunLabels           []string `usage:"run all runbooks matching the label specification"`
+	HTTPOpenApi3s       []string `usage:"set the path to the OpenAPI v3 document for HTTP runners (\"path/to/spec.yml\" or \"key:path/to/spec.yml\")"`
+	GRPCNoTLS           bool     `usage:"disable TLS use in all gRPC runners"`
+	GRPCProtos          []string `usage:"set the name of proto source for gRPC runners"`
+	GRPCProtosets       []string `usage:"set the path to the protoset file (FileDescriptorSet) for gRPC runners"`
+	GRPCImportPaths     []string `usage:"set the path to the directory where proto sources can be imported for gRPC runners"`
+	GRPCBufDirs         []string `usage:"set the path to the buf directory for gRPC runners"`
+	GRPCBufLocks        []string `usage:"set the path to buf.lock for gRPC runners"`
+	GRPCBufConfigs      []string `usage:"set the path to buf.yaml for gRPC runners"`
+	GRPCBufModules      []string `usage:"set the buf modules for gRPC runners (\"buf.build/owner/repository\" or \"buf.build/owner/repository/tree/branch-or-commit\")"`
+	CaptureDir          string   `usage:"destination of runbook run capture results"`
+	RecordDir           string   `usage:"record HTTP and gRPC exchanges to cassette files in the directory"`
+	ReplayDir           string   `usage:"replay HTTP and gRPC exchanges from cassette files in the directory without touching the network"`
+	Vars                []string `usage:"set var to runbook (\"key:value\")"`
+	Runners             []string `usage:"set runner to runbook (\"key:dsn\")"`
+	Overlays            []string `usage:"overlay values on the runbook"`
+	Underlays           []string `usage:"lay values under the runbook"`
+	Sample              int      `usage:"sample the specified number of runbooks"`
+	Shuffle             string   `usage:"randomize the order of running runbooks (\"on\",\"off\",N)"`
+	Concurrent          string   `usage:"run runbooks concurrently (\"on\",\"off\",N)"`
+	ShardIndex          int      `usage:"index of distributed runbooks"`
+	ShardN              int      `usage:"number of shards for distributing runbooks"`
+	Random              int      `usage:"run the specified number of runbooks at random"`
+	Desc                string   `usage:"description of runbook"`
+	Out                 string   `usage:"target path of runbook"`
+	Format              string   `usage:"format of result output"`
+	ReportOut           string   `usage:"write the result output of --format to the file, keeping the console output"`
+	ReportHTML          string   `usage:"write the HTML report of runbook runs to the file"`
+	Watch               bool     `usage:"watch runbooks and the files they depend on, and rerun the affected runbooks on change"`
+	AndRun              bool     `usage:"run created runbook and capture the response for test"`
+	HAR                 string   `usage:"create steps from HAR file"`
+	HARHosts            []string `usage:"create steps only from entries of HAR with the hosts"`
+	HARContentTypes     []string `usage:"create steps only from entries of HAR whose response has the content types"`
+	HARVars             bool     `usage:"turn cookies and authorization headers of HAR into vars"`
+	FromOpenAPI         string   `usage:"create steps for all operations of OpenAPI v3 document"`
+	FromPostman         string   `usage:"create runbooks from Postman collection"`
+	PostmanEnvironments []string `usage:"Postman environment files whose values are converted to vars"`
+	LoadTConcurrent     int      `usage:"number of concurrent load test runs. 0 means unlimited"`
+	LoadTDuration       string   `usage:"load test running duration"`
+	LoadTWarmUp         string   `usage:"warn-up time for load test"`
+	LoadTThreshold      string   `usage:"if this threshold condition is not met, loadt command returns exit status 1 (EXIT_FAILURE)"`
+	LoadTMaxRPS         int      `usage:"max RunN per second for load test. 0 means unlimited"`
+	Profile             bool     `usage:"profile runs of runbooks"`
+	ProfileOut          string   `usage:"profile output path"`
+	ProfileDepth        int      `usage:"depth of profile"`
+	ProfileUnit         string   `usage:"-"`
+	ProfileSort         string   `usage:"-"`
+	Attach              bool     `usage:"attach to runn process"`
+	CacheDir            string   `usage:"specify cache directory for remote runbooks"`
+	RetainCacheDir      bool     `usage:"retain cache directory for remote runbooks"`
+	Scopes              []string `usage:"additional scopes for runn"`
+	HostRules           []string `usage:"host rules for runn. (\"host rule,host rule,...\")"`
+	Proxy               string   `usage:"outbound proxy for HTTP, gRPC and CDP runners. the hosts in NO_PROXY are not proxied"`
+	WaitTimeout         string   `usage:"timeout for waiting for cleanup process after running runbooks"`
+	EnvFile             string   `usage:"load environment variables from a file"`
+	ForceColor          bool     `usage:"force colorized output even in non-tty output streams"`
+	Verbose             bool     `usage:"verbose"`
 }
 
 func (f *Flags) ToOpts() ([]runn.Option, error) {
@@ -88,10 +103,12 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 	opts := []runn.Option{
 		runn.Debug(f.Debug),
 		runn.SkipTest(f.SkipTest),
+		runn.UpdateSnapshots(f.UpdateSnapshots),
 		runn.SkipIncluded(f.SkipIncluded),
 		runn.HTTPOpenApi3s(f.HTTPOpenApi3s),
 		runn.GRPCNoTLS(f.GRPCNoTLS),
 		runn.GRPCProtos(f.GRPCProtos),
+		runn.GRPCProtosets(f.GRPCProtosets),
 		runn.GRPCImportPaths(f.GRPCImportPaths),
 		runn.GRPCBufDir(f.GRPCBufDirs...),
 		runn.GRPCBufLock(f.GRPCBufLocks...),
@@ -100,6 +117,7 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		runn.Profile(f.Profile),
 		runn.Scopes(f.Scopes...),
 		runn.HostRules(f.HostRules...),
+		runn.Proxy(f.Proxy),
 		runn.RunLabel(f.RunLabels...),
 		runn.FailFast(f.FailFast),
 		runn.Attach(f.Attach),
@@ -214,7 +232,29 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		}
 		opts = append(opts, runn.Capture(capture.Runbook(f.CaptureDir)))
 	}
-	if f.Format == "" {
+	if f.ReportHTML != "" {
+		opts = append(opts, runn.Capture(runn.NewHTMLReport(f.ReportHTML)))
+	}
+	if f.RecordDir != "" && f.ReplayDir != "" {
+		return nil, errors.New("--record and --replay cannot be used together")
+	}
+	if f.RecordDir != "" {
+		opts = append(opts, runn.Record(f.RecordDir))
+	}
+	if f.ReplayDir != "" {
+		fi, err := os.Stat(f.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("%s is not directory", f.ReplayDir)
+		}
+		opts = append(opts, runn.Replay(f.ReplayDir))
+	}
+	if f.ReportOut != "" && (f.Format == "" || f.Format == "none") {
+		return nil, errors.New("--report-out requires --format")
+	}
+	if f.Format == "" || f.ReportOut != "" {
 		opts = append(opts, runn.Capture(runn.NewCmdOut(os.Stdout, f.Verbose)))
 	}
 	return opts, nil
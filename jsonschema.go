@@ -0,0 +1,55 @@
+package runn
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/k1LoW/runn/internal/builtin"
+)
+
+// jsonSchemaFuncs - Set up the built-in functions to validate values against JSON Schema.
+func jsonSchemaFuncs() Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		js := builtin.NewJSONSchema(bk.loadJSONSchema)
+		bk.funcs["jsonschema"] = js.Validate
+		bk.funcs["jsonschemaValid"] = js.Valid
+		return nil
+	}
+}
+
+// loadJSONSchema loads the JSON Schema document of the location.
+// Local paths are relative to the runbook, and remote locations ( https://, github:// and gist:// ) are fetched via the cache.
+func (bk *book) loadJSONSchema(loc string) (string, []byte, error) {
+	if hasRemotePrefix(loc) {
+		p, err := fetchPath(loc)
+		if err != nil {
+			return "", nil, err
+		}
+		b, err := readFile(p)
+		if err != nil {
+			return "", nil, err
+		}
+		return loc, b, nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return "", nil, err
+	}
+	// The root should be absolute because `$ref` is resolved to the absolute URL ( file:///path/to/schema.json )
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", nil, err
+	}
+	p, err := fp(loc, root)
+	if err != nil {
+		return "", nil, err
+	}
+	b, err := readFile(p)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s%s", prefixFile, filepath.ToSlash(p)), b, nil
+}
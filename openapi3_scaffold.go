@@ -0,0 +1,394 @@
+package runn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	// scaffoldDummyEndpoint is the endpoint of the HTTP runner when the OpenAPI Document does not have servers.
+	scaffoldDummyEndpoint = "https://dummy.example.com"
+	// scaffoldMaxDepth is the max depth of nested schemas to synthesize values.
+	scaffoldMaxDepth = 8
+)
+
+// templateVarRe matches variables of path templating and server URL templating ( e.g. "{id}" ).
+var templateVarRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+type scaffoldOperation struct {
+	tag       string
+	path      string
+	method    string
+	pathItem  *v3.PathItem
+	operation *v3.Operation
+}
+
+// AppendOpenAPI3 appends HTTP steps generated from all operations of the OpenAPI v3 document.
+// spec is the path of the document that is set to `openapi3:` of the HTTP runner.
+// The steps are grouped by tag and each step asserts the documented success status.
+func (rb *runbook) AppendOpenAPI3(in io.Reader, spec string) error {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	doc, err := libopenapi.NewDocument(b)
+	if err != nil {
+		return err
+	}
+	m, errs := doc.BuildV3Model()
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to build OpenAPI v3 model: %w", errors.Join(errs...))
+	}
+	endpoint := scaffoldEndpoint(&m.Model)
+	key := rb.setRunner(endpoint)
+	rb.Runners[key] = yaml.MapSlice{
+		{Key: "endpoint", Value: endpoint},
+		{Key: "openapi3", Value: spec},
+	}
+
+	// Group operations by tag in the order of the document
+	var tags []string
+	for _, t := range m.Model.Tags {
+		tags = append(tags, t.Name)
+	}
+	var ops []*scaffoldOperation
+	if m.Model.Paths != nil {
+		for p, pi := range m.Model.Paths.PathItems.FromOldest() {
+			for method, op := range pi.GetOperations().FromOldest() {
+				tag := ""
+				if len(op.Tags) > 0 {
+					tag = op.Tags[0]
+					if !slices.Contains(tags, tag) {
+						tags = append(tags, tag)
+					}
+				}
+				ops = append(ops, &scaffoldOperation{
+					tag:       tag,
+					path:      p,
+					method:    strings.ToUpper(method),
+					pathItem:  pi,
+					operation: op,
+				})
+			}
+		}
+	}
+	tags = append(tags, "")
+	for _, tag := range tags {
+		for _, op := range ops {
+			if op.tag != tag {
+				continue
+			}
+			step, err := op.toStep(key)
+			if err != nil {
+				return err
+			}
+			if rb.useMap {
+				rb.stepKeys = append(rb.stepKeys, scaffoldStepKey(op, len(rb.stepKeys)))
+			}
+			rb.Steps = append(rb.Steps, step)
+		}
+	}
+	return nil
+}
+
+func (op *scaffoldOperation) toStep(key string) (yaml.MapSlice, error) {
+	params := slices.Concat(op.pathItem.Parameters, op.operation.Parameters)
+	p := templateVarRe.ReplaceAllStringFunc(op.path, func(in string) string {
+		name := strings.Trim(in, "{}")
+		for _, param := range params {
+			if param.In == "path" && param.Name == name {
+				return url.PathEscape(fmt.Sprintf("%v", scaffoldParamValue(param)))
+			}
+		}
+		return "1"
+	})
+	q := url.Values{}
+	for _, param := range params {
+		if param.In != "query" || param.Required == nil || !*param.Required {
+			continue
+		}
+		q.Add(param.Name, fmt.Sprintf("%v", scaffoldParamValue(param)))
+	}
+	if len(q) > 0 {
+		p = fmt.Sprintf("%s?%s", p, q.Encode())
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if op.operation.RequestBody != nil && op.operation.RequestBody.Content != nil {
+		var mt *v3.MediaType
+		for ct, v := range op.operation.RequestBody.Content.FromOldest() {
+			if contentType == "" || strings.Contains(ct, "json") || (ct == MediaTypeApplicationFormUrlencoded && !strings.Contains(contentType, "json")) {
+				contentType = ct
+				mt = v
+			}
+		}
+		v := scaffoldMediaTypeValue(mt)
+		switch {
+		case strings.Contains(contentType, "json"):
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(b)
+		case contentType == MediaTypeApplicationFormUrlencoded:
+			f := url.Values{}
+			if vv, ok := v.(map[string]any); ok {
+				for k, vvv := range vv {
+					f.Add(k, fmt.Sprintf("%v", vvv))
+				}
+			}
+			body = strings.NewReader(f.Encode())
+		}
+	}
+	// Only the path and the query of the request are used for the step
+	req, err := http.NewRequest(op.method, scaffoldDummyEndpoint+p, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, param := range params {
+		if param.In != "header" || param.Required == nil || !*param.Required {
+			continue
+		}
+		req.Header.Set(param.Name, fmt.Sprintf("%v", scaffoldParamValue(param)))
+	}
+	s, err := CreateHTTPStepMapSlice(key, req)
+	if err != nil {
+		return nil, err
+	}
+	desc := op.operation.Summary
+	if desc == "" {
+		desc = op.operation.OperationId
+	}
+	if desc == "" {
+		desc = fmt.Sprintf("%s %s", op.method, op.path)
+	}
+	if op.tag != "" {
+		desc = fmt.Sprintf("[%s] %s", op.tag, desc)
+	}
+	step := yaml.MapSlice{{Key: "desc", Value: desc}}
+	step = append(step, s...)
+	step = append(step, yaml.MapItem{Key: testRunnerKey, Value: scaffoldStatusCond(op.operation.Responses)})
+	return step, nil
+}
+
+// scaffoldStepKey returns the key of the step for mapped runbooks ( e.g. operationId ).
+func scaffoldStepKey(op *scaffoldOperation, i int) string {
+	if op.operation.OperationId != "" {
+		return op.operation.OperationId
+	}
+	return fmt.Sprintf("%s%d", strings.ToLower(op.method), i)
+}
+
+// scaffoldEndpoint returns the URL of the first server of the document.
+func scaffoldEndpoint(m *v3.Document) string {
+	if len(m.Servers) == 0 || !strings.HasPrefix(m.Servers[0].URL, "http") {
+		return scaffoldDummyEndpoint
+	}
+	s := m.Servers[0]
+	return templateVarRe.ReplaceAllStringFunc(s.URL, func(in string) string {
+		name := strings.Trim(in, "{}")
+		if v, ok := s.Variables.Get(name); ok {
+			return v.Default
+		}
+		return in
+	})
+}
+
+// scaffoldStatusCond returns the condition to assert the documented success status.
+// The lowest 2xx status is preferred, and the lowest documented status is used if there is no 2xx status ( e.g. redirects ).
+func scaffoldStatusCond(res *v3.Responses) string {
+	const anySuccess = "current.res.status >= 200 && current.res.status < 300"
+	if res == nil || res.Codes == nil {
+		return anySuccess
+	}
+	var (
+		codes  []int
+		has2XX bool
+	)
+	for code := range res.Codes.KeysFromOldest() {
+		if strings.EqualFold(code, "2XX") {
+			has2XX = true
+			continue
+		}
+		c, err := strconv.Atoi(code)
+		if err != nil {
+			continue
+		}
+		codes = append(codes, c)
+	}
+	slices.Sort(codes)
+	for _, c := range codes {
+		if c >= 200 && c < 300 {
+			return fmt.Sprintf("current.res.status == %d", c)
+		}
+	}
+	if has2XX || len(codes) == 0 {
+		return anySuccess
+	}
+	return fmt.Sprintf("current.res.status == %d", codes[0])
+}
+
+func scaffoldParamValue(param *v3.Parameter) any {
+	if v, ok := decodeYAMLNode(param.Example); ok {
+		return v
+	}
+	for _, e := range param.Examples.FromOldest() {
+		if v, ok := decodeYAMLNode(e.Value); ok {
+			return v
+		}
+	}
+	if param.Schema == nil {
+		return "example"
+	}
+	return scaffoldSchemaValue(param.Schema.Schema(), 0)
+}
+
+func scaffoldMediaTypeValue(mt *v3.MediaType) any {
+	if mt == nil {
+		return nil
+	}
+	if v, ok := decodeYAMLNode(mt.Example); ok {
+		return v
+	}
+	for _, e := range mt.Examples.FromOldest() {
+		if v, ok := decodeYAMLNode(e.Value); ok {
+			return v
+		}
+	}
+	if mt.Schema == nil {
+		return nil
+	}
+	return scaffoldSchemaValue(mt.Schema.Schema(), 0)
+}
+
+// scaffoldSchemaValue synthesizes a value from the schema using example, default and enum values or the type.
+func scaffoldSchemaValue(s *base.Schema, depth int) any {
+	if s == nil || depth > scaffoldMaxDepth {
+		return nil
+	}
+	if v, ok := decodeYAMLNode(s.Example); ok {
+		return v
+	}
+	for _, e := range s.Examples {
+		if v, ok := decodeYAMLNode(e); ok {
+			return v
+		}
+	}
+	if v, ok := decodeYAMLNode(s.Default); ok {
+		return v
+	}
+	if v, ok := decodeYAMLNode(s.Const); ok {
+		return v
+	}
+	for _, e := range s.Enum {
+		if v, ok := decodeYAMLNode(e); ok {
+			return v
+		}
+	}
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, sp := range s.AllOf {
+			if v, ok := scaffoldSchemaValue(sp.Schema(), depth+1).(map[string]any); ok {
+				for k, vv := range v {
+					merged[k] = vv
+				}
+			}
+		}
+		if v, ok := scaffoldObjectValue(s, depth).(map[string]any); ok {
+			for k, vv := range v {
+				merged[k] = vv
+			}
+		}
+		return merged
+	}
+	for _, sps := range [][]*base.SchemaProxy{s.OneOf, s.AnyOf} {
+		if len(sps) > 0 {
+			return scaffoldSchemaValue(sps[0].Schema(), depth+1)
+		}
+	}
+	typ := ""
+	for _, t := range s.Type {
+		if t != "null" {
+			typ = t
+			break
+		}
+	}
+	switch typ {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "date":
+			return "2006-01-02"
+		case "email":
+			return "alice@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "example"
+	case "integer":
+		if s.Minimum != nil {
+			return int64(*s.Minimum)
+		}
+		return 1
+	case "number":
+		if s.Minimum != nil {
+			return *s.Minimum
+		}
+		return 1.0
+	case "boolean":
+		return true
+	case "array":
+		if s.Items == nil || s.Items.A == nil {
+			return []any{}
+		}
+		return []any{scaffoldSchemaValue(s.Items.A.Schema(), depth+1)}
+	default:
+		return scaffoldObjectValue(s, depth)
+	}
+}
+
+func scaffoldObjectValue(s *base.Schema, depth int) any {
+	if s.Properties == nil {
+		return map[string]any{}
+	}
+	o := map[string]any{}
+	for k, sp := range s.Properties.FromOldest() {
+		o[k] = scaffoldSchemaValue(sp.Schema(), depth+1)
+	}
+	return o
+}
+
+func decodeYAMLNode(n *yamlv3.Node) (any, bool) {
+	if n == nil {
+		return nil, false
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
@@ -53,51 +53,58 @@ type deferredOpAndSteps struct {
 }
 
 type operator struct {
-	id              string
-	httpRunners     map[string]*httpRunner
-	dbRunners       map[string]*dbRunner
-	grpcRunners     map[string]*grpcRunner
-	cdpRunners      map[string]*cdpRunner
-	sshRunners      map[string]*sshRunner
-	includeRunners  map[string]*includeRunner
-	steps           []*step
-	deferred        *deferredOpAndSteps
-	store           *store.Store
-	desc            string
-	needs           map[string]*need                       // Map of `needs:` in runbook. key is the operator.bookPath.
-	nm              *waitmap.WaitMap[string, *store.Store] // Map of runbook result stores. key is the operator.bookPath.
-	labels          []string
-	useMap          bool // Use map syntax in `steps:`.
-	debug           bool // Enable debug mode
-	profile         bool
-	interval        time.Duration
-	loop            *Loop
-	loopIndex       *int // Index of the loop is dynamically recorded at runtime
-	concurrency     []string
-	root            string // Root directory of runbook ( rubbook path or working directory )
-	t               *testing.T
-	thisT           *testing.T
-	parent          *step
-	force           bool
-	trace           bool // Enable tracing ( e.g. add trace header to HTTP request )
-	waitTimeout     time.Duration
-	included        bool
-	ifCond          string
-	skipTest        bool
-	skipped         bool
-	stdout          *maskedio.Writer
-	stderr          *maskedio.Writer
-	newOnly         bool // Skip some errors for `runn list`
-	bookPath        string
-	numberOfSteps   int // Number of steps for `runn list`
-	beforeFuncs     []func(*RunResult) error
-	afterFuncs      []func(*RunResult) error
-	sw              *stopw.Span
-	capturers       capturers
-	runResult       *RunResult
-	dbg             *dbg
-	hasRunnerRunner bool
-	maskRule        *maskedio.Rule
+	id                string
+	httpRunners       map[string]*httpRunner
+	dbRunners         map[string]*dbRunner
+	grpcRunners       map[string]*grpcRunner
+	cdpRunners        map[string]*cdpRunner
+	sshRunners        map[string]*sshRunner
+	includeRunners    map[string]*includeRunner
+	graphqlRunners    map[string]*graphqlRunner
+	wsRunners         map[string]*wsRunner
+	serverRunners     map[string]*serverRunner
+	grpcServerRunners map[string]*grpcServerRunner
+	steps             []*step
+	deferred          *deferredOpAndSteps
+	store             *store.Store
+	desc              string
+	needs             map[string]*need                       // Map of `needs:` in runbook. key is the operator.bookPath.
+	nm                *waitmap.WaitMap[string, *store.Store] // Map of runbook result stores. key is the operator.bookPath.
+	labels            []string
+	useMap            bool // Use map syntax in `steps:`.
+	debug             bool // Enable debug mode
+	profile           bool
+	interval          time.Duration
+	loop              *Loop
+	loopIndex         *int // Index of the loop is dynamically recorded at runtime
+	concurrency       []string
+	root              string // Root directory of runbook ( rubbook path or working directory )
+	t                 *testing.T
+	thisT             *testing.T
+	parent            *step
+	force             bool
+	trace             bool // Enable tracing ( e.g. add trace header to HTTP request )
+	waitTimeout       time.Duration
+	included          bool
+	ifCond            string
+	skipTest          bool
+	updateSnapshots   bool
+	skipped           bool
+	stdout            *maskedio.Writer
+	stderr            *maskedio.Writer
+	newOnly           bool // Skip some errors for `runn list`
+	bookPath          string
+	numberOfSteps     int // Number of steps for `runn list`
+	beforeFuncs       []func(*RunResult) error
+	afterFuncs        []func(*RunResult) error
+	sw                *stopw.Span
+	capturers         capturers
+	cassettes         *cassettes
+	grpcDescriptors   *grpcDescriptors
+	runResult         *RunResult
+	dbg               *dbg
+	hasRunnerRunner   bool
+	maskRule          *maskedio.Rule
 
 	mu sync.Mutex
 }
@@ -108,7 +115,7 @@ func (op *operator) ID() string {
 }
 
 // runbookID returns id of the root runbook.
-func (op *operator) runbookID() string { //nolint:unused
+func (op *operator) runbookID() string {
 	return op.trails().runbookID()
 }
 
@@ -153,6 +160,15 @@ func (op *operator) Close(force bool) {
 	for _, r := range op.sshRunners {
 		_ = r.Close()
 	}
+	for _, r := range op.wsRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.serverRunners {
+		_ = r.Close()
+	}
+	for _, r := range op.grpcServerRunners {
+		_ = r.Close()
+	}
 	for _, r := range op.dbRunners {
 		if !force && r.dsn == "" {
 			continue
@@ -199,6 +215,17 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 		op.Debugf(cyan("Run %q on %s\n"), s.runnerKey, op.stepName(idx))
 	}
 
+	// Server runners respond using the values stored until this step.
+	if len(op.serverRunners) > 0 || len(op.grpcServerRunners) > 0 {
+		sm := op.store.ToMap()
+		for _, r := range op.serverRunners {
+			r.setStore(sm)
+		}
+		for _, r := range op.grpcServerRunners {
+			r.setStore(sm)
+		}
+	}
+
 	stepFn := func(t *testing.T) error {
 		s.clearResult()
 		if t != nil {
@@ -226,6 +253,22 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 				s.sshRunner = r
 				s.sshCommand = s.runnerValues
 			}
+			if r, ok := op.graphqlRunners[s.runnerKey]; ok {
+				s.graphqlRunner = r
+				s.graphqlRequest = s.runnerValues
+			}
+			if r, ok := op.wsRunners[s.runnerKey]; ok {
+				s.wsRunner = r
+				s.wsRequest = s.runnerValues
+			}
+			if r, ok := op.serverRunners[s.runnerKey]; ok {
+				s.serverRunner = r
+				s.serverRequest = s.runnerValues
+			}
+			if r, ok := op.grpcServerRunners[s.runnerKey]; ok {
+				s.grpcServerRunner = r
+				s.grpcServerRequest = s.runnerValues
+			}
 		}
 		switch {
 		case s.httpRunner != nil && s.httpRequest != nil:
@@ -233,6 +276,11 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 				return fmt.Errorf("http request failed on %s: %w", op.stepName(idx), err)
 			}
 			run = true
+		case s.graphqlRunner != nil && s.graphqlRequest != nil:
+			if err := s.graphqlRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("graphql request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
 		case s.dbRunner != nil && s.dbQuery != nil:
 			if err := s.dbRunner.Run(ctx, s); err != nil {
 				return fmt.Errorf("db query failed on %s: %w", op.stepName(idx), err)
@@ -253,6 +301,21 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 				return fmt.Errorf("ssh command failed on %s: %w", op.stepName(idx), err)
 			}
 			run = true
+		case s.wsRunner != nil && s.wsRequest != nil:
+			if err := s.wsRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("websocket request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.serverRunner != nil && s.serverRequest != nil:
+			if err := s.serverRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("server request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
+		case s.grpcServerRunner != nil && s.grpcServerRequest != nil:
+			if err := s.grpcServerRunner.Run(ctx, s); err != nil {
+				return fmt.Errorf("gRPC server request failed on %s: %w", op.stepName(idx), err)
+			}
+			run = true
 		case s.execRunner != nil && s.execCommand != nil:
 			if err := s.execRunner.Run(ctx, s); err != nil {
 				return fmt.Errorf("exec command failed on %s: %w", op.stepName(idx), err)
@@ -285,6 +348,21 @@ func (op *operator) runStep(ctx context.Context, s *step) error {
 			}
 			run = true
 		}
+		// snapshot runner
+		if s.snapshotRunner != nil && s.snapshotRequest != nil {
+			if op.skipTest {
+				op.Debugf(yellow("Skip %q on %s\n"), snapshotRunnerKey, op.stepName(idx))
+				if !run && (s.testRunner == nil || s.testCond == "") {
+					return errStepSkipped
+				}
+			} else {
+				op.Debugf(cyan("Run %q on %s\n"), snapshotRunnerKey, op.stepName(idx))
+				if err := s.snapshotRunner.Run(ctx, s, !run); err != nil {
+					return fmt.Errorf("snapshot failed on %s: %w", op.stepName(idx), err)
+				}
+				run = true
+			}
+		}
 		// test runner
 		if s.testRunner != nil && s.testCond != "" {
 			if op.skipTest {
@@ -449,43 +527,50 @@ func New(opts ...Option) (*operator, error) {
 	}
 	st := store.New(bk.vars, bk.funcs, bk.secrets, bk.stepKeys)
 	op := &operator{
-		id:             id,
-		httpRunners:    map[string]*httpRunner{},
-		dbRunners:      map[string]*dbRunner{},
-		grpcRunners:    map[string]*grpcRunner{},
-		cdpRunners:     map[string]*cdpRunner{},
-		sshRunners:     map[string]*sshRunner{},
-		includeRunners: map[string]*includeRunner{},
-		deferred:       &deferredOpAndSteps{},
-		store:          st,
-		useMap:         bk.useMap,
-		desc:           bk.desc,
-		labels:         bk.labels,
-		debug:          bk.debug,
-		nm:             waitmap.New[string, *store.Store](),
-		profile:        bk.profile,
-		interval:       bk.interval,
-		loop:           bk.loop,
-		concurrency:    bk.concurrency,
-		t:              bk.t,
-		thisT:          bk.t,
-		force:          bk.force,
-		trace:          bk.trace,
-		waitTimeout:    bk.waitTimeout,
-		included:       bk.included,
-		ifCond:         bk.ifCond,
-		skipTest:       bk.skipTest,
-		stdout:         st.MaskRule().NewWriter(bk.stdout),
-		stderr:         st.MaskRule().NewWriter(bk.stderr),
-		newOnly:        bk.loadOnly,
-		bookPath:       bk.path,
-		beforeFuncs:    bk.beforeFuncs,
-		afterFuncs:     bk.afterFuncs,
-		sw:             stopw.New(),
-		capturers:      bk.capturers,
-		runResult:      newRunResult(bk.desc, bk.labels, bk.path, bk.included, st),
-		dbg:            newDBG(bk.attach),
-		maskRule:       st.MaskRule(),
+		id:                id,
+		httpRunners:       map[string]*httpRunner{},
+		dbRunners:         map[string]*dbRunner{},
+		grpcRunners:       map[string]*grpcRunner{},
+		cdpRunners:        map[string]*cdpRunner{},
+		sshRunners:        map[string]*sshRunner{},
+		includeRunners:    map[string]*includeRunner{},
+		graphqlRunners:    map[string]*graphqlRunner{},
+		wsRunners:         map[string]*wsRunner{},
+		serverRunners:     map[string]*serverRunner{},
+		grpcServerRunners: map[string]*grpcServerRunner{},
+		deferred:          &deferredOpAndSteps{},
+		store:             st,
+		useMap:            bk.useMap,
+		desc:              bk.desc,
+		labels:            bk.labels,
+		debug:             bk.debug,
+		nm:                waitmap.New[string, *store.Store](),
+		profile:           bk.profile,
+		interval:          bk.interval,
+		loop:              bk.loop,
+		concurrency:       bk.concurrency,
+		t:                 bk.t,
+		thisT:             bk.t,
+		force:             bk.force,
+		trace:             bk.trace,
+		waitTimeout:       bk.waitTimeout,
+		included:          bk.included,
+		ifCond:            bk.ifCond,
+		skipTest:          bk.skipTest,
+		updateSnapshots:   bk.updateSnapshots,
+		stdout:            st.MaskRule().NewWriter(bk.stdout),
+		stderr:            st.MaskRule().NewWriter(bk.stderr),
+		newOnly:           bk.loadOnly,
+		bookPath:          bk.path,
+		beforeFuncs:       bk.beforeFuncs,
+		afterFuncs:        bk.afterFuncs,
+		sw:                stopw.New(),
+		capturers:         bk.capturers.forOperator(),
+		cassettes:         bk.cassettes,
+		grpcDescriptors:   bk.grpcDescriptors,
+		runResult:         newRunResult(bk.desc, bk.labels, bk.path, bk.included, st),
+		dbg:               newDBG(bk.attach),
+		maskRule:          st.MaskRule(),
 	}
 
 	if op.debug {
@@ -514,6 +599,8 @@ func New(opts ...Option) (*operator, error) {
 
 	// The host rules specified by the option take precedence.
 	hostRules := append(bk.hostRulesFromOpts, bk.hostRules...)
+	// The proxy of each runner takes precedence over the global proxy.
+	globalProxy := bk.proxy
 
 	for k, v := range bk.httpRunners {
 		if _, ok := v.validator.(*nopValidator); ok {
@@ -547,6 +634,32 @@ func New(opts ...Option) (*operator, error) {
 			}
 			tp.DialContext = hostRules.dialContextFunc()
 		}
+		if v.proxy == nil && globalProxy != nil {
+			if err := v.setProxy(globalProxy); err != nil {
+				return nil, err
+			}
+		}
+		for _, proto := range bk.grpcProtos {
+			key, p := splitKeyAndPath(proto)
+			if key != "" && key != k {
+				continue
+			}
+			v.protos = unique(append(v.protos, p))
+		}
+		for _, ip := range bk.grpcImportPaths {
+			key, p := splitKeyAndPath(ip)
+			if key != "" && key != k {
+				continue
+			}
+			v.importPaths = unique(append(v.importPaths, p))
+		}
+		v.bufDirs = unique(append(v.bufDirs, bk.grpcBufDirs...))
+		v.bufLocks = unique(append(v.bufLocks, bk.grpcBufLocks...))
+		v.bufConfigs = unique(append(v.bufConfigs, bk.grpcBufConfigs...))
+		v.bufModules = unique(append(v.bufModules, bk.grpcBufModules...))
+		if v.cassettes == nil {
+			v.cassettes = bk.cassettes
+		}
 		op.httpRunners[k] = v
 	}
 	for k, v := range bk.dbRunners {
@@ -571,14 +684,21 @@ func New(opts ...Option) (*operator, error) {
 			if key != "" && key != k {
 				continue
 			}
-			v.protos = append(v.protos, p)
+			v.protos = unique(append(v.protos, p))
+		}
+		for _, ps := range bk.grpcProtosets {
+			key, p := splitKeyAndPath(ps)
+			if key != "" && key != k {
+				continue
+			}
+			v.protosets = unique(append(v.protosets, p))
 		}
 		for _, ip := range bk.grpcImportPaths {
 			key, p := splitKeyAndPath(ip)
 			if key != "" && key != k {
 				continue
 			}
-			v.importPaths = append(v.importPaths, p)
+			v.importPaths = unique(append(v.importPaths, p))
 		}
 		v.bufDirs = unique(append(v.bufDirs, bk.grpcBufDirs...))
 		v.bufLocks = unique(append(v.bufLocks, bk.grpcBufLocks...))
@@ -590,6 +710,18 @@ func New(opts ...Option) (*operator, error) {
 				return nil, err
 			}
 		}
+		if v.proxy == nil && globalProxy != nil && v.target != "" {
+			v.proxy = globalProxy
+			if err := v.Renew(); err != nil {
+				return nil, err
+			}
+		}
+		if v.cassettes == nil && v.cc == nil {
+			v.cassettes = bk.cassettes
+		}
+		if v.descriptors == nil {
+			v.descriptors = bk.grpcDescriptors
+		}
 		if v.operatorID == "" {
 			v.operatorID = op.id
 		}
@@ -599,6 +731,9 @@ func New(opts ...Option) (*operator, error) {
 		if len(hostRules) > 0 {
 			v.opts = append(v.opts, hostRules.chromedpOpt())
 		}
+		if v.proxy == nil && globalProxy != nil {
+			v.setProxy(globalProxy)
+		}
 		if err := v.Renew(); err != nil {
 			return nil, err
 		}
@@ -622,6 +757,48 @@ func New(opts ...Option) (*operator, error) {
 	for k, v := range bk.includeRunners {
 		op.includeRunners[k] = v
 	}
+	for k, v := range bk.graphqlRunners {
+		if len(hostRules) > 0 {
+			tp, ok := v.httpRunner.client.Transport.(*http.Transport)
+			if !ok {
+				return nil, fmt.Errorf("failed to cast: %v", v.httpRunner.client.Transport)
+			}
+			tp.DialContext = hostRules.dialContextFunc()
+		}
+		if v.httpRunner.proxy == nil && globalProxy != nil {
+			if err := v.httpRunner.setProxy(globalProxy); err != nil {
+				return nil, err
+			}
+		}
+		if v.httpRunner.cassettes == nil {
+			v.httpRunner.cassettes = bk.cassettes
+		}
+		op.graphqlRunners[k] = v
+	}
+	for k, v := range bk.wsRunners {
+		if len(hostRules) > 0 {
+			v.hostRules = hostRules
+			if err := v.Renew(); err != nil {
+				return nil, err
+			}
+		}
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.wsRunners[k] = v
+	}
+	for k, v := range bk.serverRunners {
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.serverRunners[k] = v
+	}
+	for k, v := range bk.grpcServerRunners {
+		if v.operatorID == "" {
+			v.operatorID = op.id
+		}
+		op.grpcServerRunners[k] = v
+	}
 
 	keys := map[string]struct{}{}
 	for k := range op.httpRunners {
@@ -657,6 +834,30 @@ func New(opts ...Option) (*operator, error) {
 		}
 		keys[k] = struct{}{}
 	}
+	for k := range op.graphqlRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.wsRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.serverRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
+	for k := range op.grpcServerRunners {
+		if _, ok := keys[k]; ok {
+			return nil, fmt.Errorf("duplicate runner names (%s): %s", op.bookPath, k)
+		}
+		keys[k] = struct{}{}
+	}
 	var errs error
 	for k, err := range bk.runnerErrs {
 		errs = errors.Join(errs, fmt.Errorf("runner %s error: %w", k, err))
@@ -783,6 +984,41 @@ func (op *operator) appendStep(idx int, key string, s map[string]any) error {
 		}
 		delete(s, dumpRunnerKey)
 	}
+	// snapshot runner
+	if v, ok := s[snapshotRunnerKey]; ok {
+		st.snapshotRunner = newSnapshotRunner()
+		switch vv := v.(type) {
+		case string:
+			st.snapshotRequest = &snapshotRequest{
+				expr: vv,
+			}
+		case map[string]any:
+			expr, ok := vv["expr"]
+			if !ok {
+				return fmt.Errorf("invalid snapshot request: %v", vv)
+			}
+			file, ok := vv["file"]
+			if !ok {
+				file = "" // default: __snapshots__/<runbook name>.<step key>.json
+			}
+			var ignores []string
+			if v, ok := vv["ignores"]; ok {
+				var err error
+				ignores, err = cast.ToStringSliceE(v)
+				if err != nil {
+					return fmt.Errorf("invalid snapshot ignores: %v: %w", v, err)
+				}
+			}
+			st.snapshotRequest = &snapshotRequest{
+				expr:    cast.ToString(expr),
+				file:    cast.ToString(file),
+				ignores: ignores,
+			}
+		default:
+			return fmt.Errorf("invalid snapshot request: %v", vv)
+		}
+		delete(s, snapshotRunnerKey)
+	}
 	// bind runner
 	if v, ok := s[bindRunnerKey]; ok {
 		st.bindRunner = newBindRunner()
@@ -877,6 +1113,46 @@ func (op *operator) appendStep(idx int, key string, s map[string]any) error {
 				st.sshCommand = vv
 				detected = true
 			}
+			gqc, ok := op.graphqlRunners[k]
+			if ok && !detected {
+				st.graphqlRunner = gqc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid graphql request: %v", v)
+				}
+				st.graphqlRequest = vv
+				detected = true
+			}
+			wc, ok := op.wsRunners[k]
+			if ok && !detected {
+				st.wsRunner = wc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid websocket request: %v", v)
+				}
+				st.wsRequest = vv
+				detected = true
+			}
+			svc, ok := op.serverRunners[k]
+			if ok && !detected {
+				st.serverRunner = svc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid server request: %v", v)
+				}
+				st.serverRequest = vv
+				detected = true
+			}
+			gsc, ok := op.grpcServerRunners[k]
+			if ok && !detected {
+				st.grpcServerRunner = gsc
+				vv, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("invalid gRPC server request: %v", v)
+				}
+				st.grpcServerRequest = vv
+				detected = true
+			}
 			ic, ok := op.includeRunners[k]
 			if ok && !detected {
 				st.includeRunner = ic
@@ -1220,6 +1496,18 @@ func (op *operator) runInternal(ctx context.Context) (rerr error) {
 		op.sw.Stop(trsi...)
 	}
 
+	// servers
+	for _, r := range op.serverRunners {
+		if err := r.start(ctx, op); err != nil {
+			return err
+		}
+	}
+	for _, r := range op.grpcServerRunners {
+		if err := r.start(ctx, op); err != nil {
+			return err
+		}
+	}
+
 	// steps
 	failed := false
 	force := op.force
@@ -1317,22 +1605,22 @@ func (op *operator) stepName(i int) string {
 
 // expandBeforeRecord - expand before the runner records the result.
 func (op *operator) expandBeforeRecord(in any, s *step) (any, error) {
-	sm := op.store.ToMap()
-	sm[store.RootKeyIncluded] = op.included
-	if !s.deferred {
-		sm[store.RootKeyPrevious] = op.store.Latest()
-	}
-	return expr.EvalExpand(in, sm)
+	return expr.EvalExpand(in, op.envBeforeRecord(s))
 }
 
 // expandCondBeforeRecord - expand condition before the runner records the result.
 func (op *operator) expandCondBeforeRecord(ifCond string, s *step) (bool, error) {
+	return expr.EvalCond(ifCond, op.envBeforeRecord(s))
+}
+
+// envBeforeRecord - returns the environment for evaluation before the runner records the result.
+func (op *operator) envBeforeRecord(s *step) map[string]any {
 	sm := op.store.ToMap()
 	sm[store.RootKeyIncluded] = op.included
 	if !s.deferred {
 		sm[store.RootKeyPrevious] = op.store.Latest()
 	}
-	return expr.EvalCond(ifCond, sm)
+	return sm
 }
 
 // Debugln print to out when debug = true.
@@ -1388,6 +1676,7 @@ func (op *operator) toOperatorN() *operatorN {
 		kv:        op.store.KV(),
 		runNIndex: atomic.Int64{},
 		opts:      op.exportOptionsToBePropagated(),
+		capturers: op.capturers,
 		dbg:       op.dbg,
 	}
 	opn.runNIndex.Store(-1)
@@ -1435,6 +1724,9 @@ type operatorN struct {
 	concmax      int
 	failFast     bool
 	opts         []Option
+	capturers    capturers
+	keepRunners  bool   // keepRunners is the flag to keep runners open after running to reuse them in the next run ( e.g. Watch ).
+	pathp        string // pathp is the path pattern of the loaded runbooks.
 	results      []*runNResult
 	runNIndex    atomic.Int64 // runNIndex holds the runN execution index (starting from 0). It is incremented each time runN is executed
 	kv           *kv.KV
@@ -1477,6 +1769,8 @@ func Load(pathp string, opts ...Option) (*operatorN, error) {
 		failFast:     bk.failFast,
 		concmax:      1,
 		opts:         opts,
+		capturers:    bk.capturers,
+		pathp:        pathp,
 		runNIndex:    atomic.Int64{},
 		kv:           kv.New(),
 		dbg:          newDBG(bk.attach),
@@ -1769,7 +2063,9 @@ func (opn *operatorN) runN(ctx context.Context) (*runNResult, error) {
 		opn.t.Helper()
 	}
 	defer opn.sw.Start().Stop()
-	defer opn.Close()
+	if !opn.keepRunners {
+		defer opn.Close()
+	}
 	runNIndex := opn.runNIndex.Add(1)
 	cg, cctx := concgroup.WithContext(ctx)
 	cg.SetLimit(opn.concmax)
@@ -1783,10 +2079,22 @@ func (opn *operatorN) runN(ctx context.Context) (*runNResult, error) {
 		op.store.SetRunNIndex(int(runNIndex)) // Set runN index
 		cg.GoMulti(op.concurrency, func() error {
 			defer func() {
+				if op.cassettes != nil {
+					if err := op.cassettes.flush(op.runbookID()); err != nil {
+						op.runResult.Err = errors.Join(op.runResult.Err, err)
+					}
+				}
 				r := op.Result()
 				op.capturers.captureResult(op.trails(), r)
 				op.capturers.captureEnd(op.trails(), op.bookPath, op.desc)
-				op.Close(false)
+				if opn.keepRunners {
+					// CDP runners are not reused
+					for _, r := range op.cdpRunners {
+						_ = r.Close()
+					}
+				} else {
+					op.Close(false)
+				}
 				result.mu.Lock()
 				result.RunResults = append(result.RunResults, r)
 				result.mu.Unlock()
@@ -1800,7 +2108,11 @@ func (opn *operatorN) runN(ctx context.Context) (*runNResult, error) {
 			return nil
 		})
 	}
-	if err := cg.Wait(); err != nil {
+	err = cg.Wait()
+	if cerr := opn.capturers.captureRunNEnd(); cerr != nil {
+		err = errors.Join(err, cerr)
+	}
+	if err != nil {
 		return result, err
 	}
 	return result, nil
@@ -316,6 +316,8 @@ func TestLoad(t *testing.T) {
 				Runner("sc", fmt.Sprintf("ssh://%s", sshdAddr)),
 				Runner("sc2", fmt.Sprintf("ssh://%s", sshdAddr)),
 				Runner("sc3", fmt.Sprintf("ssh://%s", sshdAddr)),
+				GraphQLRunner("gql", "https://example.com/graphql"),
+				GRPCServerRunner("mock", "127.0.0.1:0"),
 				testFunc,
 			}
 			ops, err := Load(tt.paths, opts...)
@@ -958,7 +960,7 @@ func TestShard(t *testing.T) {
 			}
 			sortOperators(got)
 			allow := []any{
-				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, includeRunner{},
+				operator{}, httpRunner{}, dbRunner{}, grpcRunner{}, cdpRunner{}, sshRunner{}, includeRunner{}, graphqlRunner{}, wsRunner{}, serverRunner{}, grpcServerRunner{}, grpcServerResponse{},
 			}
 			ignore := []any{
 				step{}, store.Store{}, sql.DB{}, os.File{}, stopw.Span{}, debugger{}, nest.DB{}, Loop{}, hostRule{},
@@ -972,6 +974,9 @@ func TestShard(t *testing.T) {
 				cmpopts.IgnoreFields(sshRunner{}, "client", "sess", "stdin", "stdout", "stderr", "operatorID"),
 				cmpopts.IgnoreFields(grpcRunner{}, "mu", "operatorID"),
 				cmpopts.IgnoreFields(dbRunner{}, "operatorID"),
+				cmpopts.IgnoreFields(wsRunner{}, "conn", "rw", "mu", "operatorID"),
+				cmpopts.IgnoreFields(serverRunner{}, "server", "env", "received", "mu", "operatorID"),
+				cmpopts.IgnoreFields(grpcServerRunner{}, "server", "mds", "env", "received", "mu", "operatorID"),
 				cmpopts.IgnoreFields(RunResult{}, "included", "store"),
 				cmpopts.IgnoreFields(http.Client{}, "Transport"),
 			}
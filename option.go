@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/Songmu/prompter"
-	"github.com/chromedp/chromedp"
 	"github.com/elk-language/go-prompt"
 	pstrings "github.com/elk-language/go-prompt/strings"
 	"github.com/k1LoW/duration"
@@ -85,6 +84,18 @@ func Overlay(path string) Option {
 		for k, r := range loaded.sshRunners {
 			bk.sshRunners[k] = r
 		}
+		for k, r := range loaded.graphqlRunners {
+			bk.graphqlRunners[k] = r
+		}
+		for k, r := range loaded.wsRunners {
+			bk.wsRunners[k] = r
+		}
+		for k, r := range loaded.serverRunners {
+			bk.serverRunners[k] = r
+		}
+		for k, r := range loaded.grpcServerRunners {
+			bk.grpcServerRunners[k] = r
+		}
 		for k, v := range loaded.vars {
 			bk.vars[k] = v
 		}
@@ -157,6 +168,26 @@ func Underlay(path string) Option {
 				bk.sshRunners[k] = r
 			}
 		}
+		for k, r := range loaded.graphqlRunners {
+			if _, ok := bk.graphqlRunners[k]; !ok {
+				bk.graphqlRunners[k] = r
+			}
+		}
+		for k, r := range loaded.wsRunners {
+			if _, ok := bk.wsRunners[k]; !ok {
+				bk.wsRunners[k] = r
+			}
+		}
+		for k, r := range loaded.serverRunners {
+			if _, ok := bk.serverRunners[k]; !ok {
+				bk.serverRunners[k] = r
+			}
+		}
+		for k, r := range loaded.grpcServerRunners {
+			if _, ok := bk.grpcServerRunners[k]; !ok {
+				bk.grpcServerRunners[k] = r
+			}
+		}
 		for k, v := range loaded.vars {
 			if _, ok := bk.vars[k]; !ok {
 				bk.vars[k] = v
@@ -245,6 +276,24 @@ func Runner(name, dsn string, opts ...httpRunnerOption) Option {
 			}
 			r.validator = v
 		}
+		if c.Auth != nil {
+			r.auth, err = newHTTPAuth(c.Auth)
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if c.Proxy != "" {
+			p, err := newProxy(c.Proxy, false)
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			if err := r.setProxy(p); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
 		bk.httpRunners[name] = r
 		return nil
 	}
@@ -299,49 +348,35 @@ func HTTPRunner(name, endpoint string, client *http.Client, opts ...httpRunnerOp
 				return err
 			}
 		}
-		if c.CACert != "" {
-			p, err := fp(c.CACert, root)
-			if err != nil {
-				return err
-			}
-			b, err := readFile(p)
-			if err != nil {
-				return err
-			}
-			r.cacert = b
+		r.cacert, r.cert, r.key, err = readTLSFiles(root, c.CACert, c.Cert, c.Key)
+		if err != nil {
+			return err
 		}
-		if c.Cert != "" {
-			p, err := fp(c.Cert, root)
+		r.skipVerify = c.SkipVerify
+		if c.Timeout != "" {
+			r.client.Timeout, err = duration.Parse(c.Timeout)
 			if err != nil {
-				return err
+				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
 			}
-			b, err := readFile(p)
+		}
+		r.useCookie = c.UseCookie
+		r.trace = c.Trace.Enable
+		r.traceHeaderName = c.Trace.HeaderName
+		if c.Auth != nil {
+			r.auth, err = newHTTPAuth(c.Auth)
 			if err != nil {
 				return err
 			}
-			r.cert = b
 		}
-		if c.Key != "" {
-			p, err := fp(c.Key, root)
+		if c.Proxy != "" {
+			p, err := newProxy(c.Proxy, false)
 			if err != nil {
 				return err
 			}
-			b, err := readFile(p)
-			if err != nil {
+			if err := r.setProxy(p); err != nil {
 				return err
 			}
-			r.key = b
 		}
-		r.skipVerify = c.SkipVerify
-		if c.Timeout != "" {
-			r.client.Timeout, err = duration.Parse(c.Timeout)
-			if err != nil {
-				return fmt.Errorf("timeout in HttpRunnerConfig is invalid: %w", err)
-			}
-		}
-		r.useCookie = c.UseCookie
-		r.trace = c.Trace.Enable
-		r.traceHeaderName = c.Trace.HeaderName
 
 		hv, err := newHttpValidator(c)
 		if err != nil {
@@ -407,6 +442,130 @@ func HTTPRunnerWithHandler(name string, h http.Handler, opts ...httpRunnerOption
 	}
 }
 
+// GraphQLRunner - Set GraphQL runner to runbook.
+func GraphQLRunner(name, endpoint string, opts ...graphqlRunnerOption) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return err
+		}
+		r, err := newGraphQLRunner(name, endpoint)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		c := &graphqlRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.applyConfig(c, root); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		bk.graphqlRunners[name] = r
+		return nil
+	}
+}
+
+// WSRunner - Set WebSocket runner to runbook.
+func WSRunner(name, endpoint string, opts ...wsRunnerOption) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return err
+		}
+		r, err := newWSRunner(name, endpoint)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		c := &wsRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.applyConfig(c, root); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
+// ServerRunner - Set HTTP stub server runner to runbook.
+func ServerRunner(name, addr string, opts ...serverRunnerOption) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		r, err := newServerRunner(name, addr)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		c := &serverRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.applyConfig(c); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		bk.serverRunners[name] = r
+		return nil
+	}
+}
+
+// GRPCServerRunner - Set gRPC mock server runner to runbook.
+func GRPCServerRunner(name, addr string, opts ...grpcServerRunnerOption) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runnerErrs, name)
+		root, err := bk.generateOperatorRoot()
+		if err != nil {
+			return err
+		}
+		r, err := newGRPCServerRunner(name, addr)
+		if err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		c := &grpcServerRunnerConfig{}
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+		}
+		if err := r.applyConfig(c, root); err != nil {
+			bk.runnerErrs[name] = err
+			return nil
+		}
+		bk.grpcServerRunners[name] = r
+		return nil
+	}
+}
+
 // DBRunner - Set DB runner to runbook.
 func DBRunner(name string, client Querier) Option {
 	return func(bk *book) error {
@@ -488,6 +647,11 @@ func GrpcRunnerWithOptions(name, target string, opts ...grpcRunnerOption) Option
 					return nil
 				}
 			}
+			r.protocol = c.Protocol
+			if err := r.applyTransportConfig(c); err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
 			r.tls = c.TLS
 			if len(c.cacert) != 0 {
 				r.cacert = c.cacert
@@ -521,6 +685,7 @@ func GrpcRunnerWithOptions(name, target string, opts ...grpcRunnerOption) Option
 			}
 			r.importPaths = c.ImportPaths
 			r.protos = c.Protos
+			r.protosets = c.Protosets
 			r.bufDirs = c.BufDirs
 			r.bufLocks = c.BufLocks
 			r.bufConfigs = c.BufConfigs
@@ -528,6 +693,14 @@ func GrpcRunnerWithOptions(name, target string, opts ...grpcRunnerOption) Option
 			r.skipVerify = c.SkipVerify
 			r.trace = c.Trace.Enable
 			r.traceHeaderName = c.Trace.HeaderName
+			if c.Proxy != "" {
+				p, err := newProxy(c.Proxy, false)
+				if err != nil {
+					bk.runnerErrs[name] = err
+					return nil
+				}
+				r.proxy = p
+			}
 		}
 		bk.grpcRunners[name] = r
 		return nil
@@ -657,8 +830,8 @@ func CDPRunner(name string, opts ...cdpRunnerOption) Option {
 		if err != nil {
 			return err
 		}
-		for n, v := range c.Flags {
-			r.opts = append(r.opts, chromedp.Flag(n, v))
+		if err := r.applyConfig(c); err != nil {
+			return err
 		}
 		bk.cdpRunners[name] = r
 		return nil
@@ -818,6 +991,19 @@ func SkipTest(enable bool) Option {
 	}
 }
 
+// UpdateSnapshots - Create or update snapshot files of `snapshot:` section with current values.
+func UpdateSnapshots(enable bool) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if !bk.updateSnapshots {
+			bk.updateSnapshots = enable
+		}
+		return nil
+	}
+}
+
 // Force - Force all steps to run.
 func Force(enable bool) Option {
 	return func(bk *book) error {
@@ -906,6 +1092,17 @@ func GRPCProtos(protos []string) Option {
 	}
 }
 
+// GRPCProtosets - Set the protoset files ( FileDescriptorSet ) for gRPC runners.
+func GRPCProtosets(protosets []string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.grpcProtosets = protosets
+		return nil
+	}
+}
+
 // GRPCImportPaths - Set the path to the directory where proto sources can be imported for gRPC runners.
 func GRPCImportPaths(paths []string) Option {
 	return func(bk *book) error {
@@ -1058,6 +1255,38 @@ func Capture(c Capturer) Option {
 	}
 }
 
+// Record - Record HTTP and gRPC exchanges to cassette files in the directory.
+func Record(dir string) Option {
+	cs := newCassettes(dir, false)
+	return withCassettes(cs)
+}
+
+// Replay - Replay HTTP and gRPC exchanges from cassette files in the directory without touching the network.
+func Replay(dir string) Option {
+	cs := newCassettes(dir, true)
+	return withCassettes(cs)
+}
+
+func withCassettes(cs *cassettes) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.cassettes = cs
+		return nil
+	}
+}
+
+func withGRPCDescriptors(d *grpcDescriptors) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.grpcDescriptors = d
+		return nil
+	}
+}
+
 // RunMatch - Run only runbooks with matching paths.
 func RunMatch(m string) Option { //nostyle:repetition
 	return func(bk *book) error {
@@ -1253,6 +1482,25 @@ func HostRules(rules ...string) Option {
 	}
 }
 
+// Proxy - Set the outbound proxy for HTTP, gRPC and CDP runners that do not have their own proxy.
+// The hosts in NO_PROXY are not proxied.
+func Proxy(proxy string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if proxy == "" {
+			return nil
+		}
+		p, err := newProxy(proxy, true)
+		if err != nil {
+			return err
+		}
+		bk.proxy = p
+		return nil
+	}
+}
+
 // bookWithStore - Load runbook with store.
 func bookWithStore(path string, store map[string]any) Option {
 	return func(bk *book) error {
@@ -1323,6 +1571,9 @@ func setupBuiltinFunctions(opts ...Option) []Option {
 		}),
 		Func("basename", filepath.Base),
 		Func("faker", builtin.NewFaker()),
+		Func("xpath", builtin.XPath),
+		Func("xpathAll", builtin.XPathAll),
+		jsonSchemaFuncs(),
 	},
 		opts...,
 	)
@@ -1391,6 +1642,46 @@ func reuseSSHRunner(name string, r *sshRunner) Option {
 	}
 }
 
+func reuseGraphQLRunner(name string, r *graphqlRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.graphqlRunners[name] = r
+		return nil
+	}
+}
+
+func reuseWSRunner(name string, r *wsRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.wsRunners[name] = r
+		return nil
+	}
+}
+
+func reuseServerRunner(name string, r *serverRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.serverRunners[name] = r
+		return nil
+	}
+}
+
+func reuseGRPCServerRunner(name string, r *grpcServerRunner) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.grpcServerRunners[name] = r
+		return nil
+	}
+}
+
 var (
 	AsTestHelper = T
 	Runbook      = Book
@@ -7,8 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/gobwas/ws"
+	"github.com/goccy/go-json"
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/duration"
+	"github.com/spf13/cast"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -102,6 +105,52 @@ func parseHTTPRequest(v map[string]any) (*httpRequest, error) {
 					}
 				}
 			}
+			pm, ok := vvvvv["protobuf"]
+			if ok && pm != nil {
+				pm, ok := pm.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				for k, v := range pm {
+					s, ok := v.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					switch k {
+					case "request":
+						req.protoRequestType = s
+					case "response":
+						req.protoResponseType = s
+					default:
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				}
+			}
+			sm, ok := vvvvv["stream"]
+			if ok && sm != nil {
+				sm, ok := sm.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.stream = &httpStream{}
+				for k, v := range sm {
+					switch k {
+					case "until":
+						s, ok := v.(string)
+						if !ok {
+							return nil, fmt.Errorf("invalid request: %s", string(part))
+						}
+						req.stream.until = s
+					case "timeout":
+						req.stream.timeout, err = parseDuration(cast.ToString(v))
+						if err != nil {
+							return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+						}
+					default:
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				}
+			}
 		}
 
 		break
@@ -144,6 +193,235 @@ func parseDBQuery(v map[string]any) (*dbQuery, error) {
 	return q, nil
 }
 
+func parseGraphQLRequest(v map[string]any) (*graphqlRequest, error) {
+	req := &graphqlRequest{
+		headers: http.Header{},
+	}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	q, ok := v["query"]
+	if !ok {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	query, ok := q.(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	req.query = query
+	vm, ok := v["variables"]
+	if ok && vm != nil {
+		vars, ok := vm.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		req.variables = vars
+	}
+	om, ok := v["operationName"]
+	if ok && om != nil {
+		name, ok := om.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		req.operationName = name
+	}
+	hm, ok := v["headers"]
+	if ok && hm != nil {
+		hm, ok := hm.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+		for k, v := range hm {
+			switch v := v.(type) {
+			case string:
+				req.headers.Add(k, v)
+			case []any:
+				for _, vv := range v {
+					svv, ok := vv.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					req.headers.Add(k, svv)
+				}
+			default:
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+		}
+	}
+	tm, ok := v["trace"]
+	if ok {
+		switch v := tm.(type) {
+		case bool:
+			req.trace = &v
+		default:
+			if v != nil {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+		}
+	}
+	return req, nil
+}
+
+func parseWSRequest(v map[string]any) (*wsRequest, error) {
+	req := &wsRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return nil, fmt.Errorf("invalid request: %s", string(part))
+	}
+	for k, vv := range v {
+		switch k {
+		case wsOpSend:
+			m, ok := vv.(map[string]any)
+			if !ok || len(m) != 1 {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			for typ, data := range m {
+				switch typ {
+				case "text":
+					s, ok := data.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+					req.send = &WSMessage{Type: WSMessageText, Data: []byte(s)}
+				case "json":
+					b, err := json.Marshal(data)
+					if err != nil {
+						return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+					}
+					req.send = &WSMessage{Type: WSMessageText, Data: b}
+				case "binary":
+					switch d := data.(type) {
+					case string:
+						req.send = &WSMessage{Type: WSMessageBinary, Data: []byte(d)}
+					case []byte:
+						req.send = &WSMessage{Type: WSMessageBinary, Data: d}
+					case []any:
+						b := make([]byte, len(d))
+						for i := range d {
+							u8, err := cast.ToUint8E(d[i])
+							if err != nil {
+								return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+							}
+							b[i] = u8
+						}
+						req.send = &WSMessage{Type: WSMessageBinary, Data: b}
+					default:
+						return nil, fmt.Errorf("invalid request: %s", string(part))
+					}
+				default:
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+		case wsOpReceive:
+			req.receive = &wsReceive{count: 1}
+			if vv == nil {
+				continue
+			}
+			m, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			if u, ok := m["until"]; ok {
+				s, ok := u.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.receive.until = s
+			}
+			if c, ok := m["count"]; ok {
+				req.receive.count, err = cast.ToIntE(c)
+				if err != nil || req.receive.count < 1 {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+			if t, ok := m["timeout"]; ok {
+				req.receive.timeout, err = parseDuration(cast.ToString(t))
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
+		case wsOpClose:
+			req.close = &wsClose{code: int(ws.StatusNormalClosure)}
+			if vv == nil {
+				continue
+			}
+			m, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			if c, ok := m["code"]; ok {
+				req.close.code, err = cast.ToIntE(c)
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
+			if r, ok := m["reason"]; ok {
+				s, ok := r.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.close.reason = s
+			}
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
+func parseServerRequest(v map[string]any) (*serverRequest, error) {
+	req := &serverRequest{}
+	part, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range v {
+		switch k {
+		case "wait":
+			req.wait = &serverWait{count: 1}
+			if vv == nil {
+				continue
+			}
+			m, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			if u, ok := m["until"]; ok {
+				s, ok := u.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+				req.wait.until = s
+			}
+			if c, ok := m["count"]; ok {
+				req.wait.count, err = cast.ToIntE(c)
+				if err != nil || req.wait.count < 1 {
+					return nil, fmt.Errorf("invalid request: %s", string(part))
+				}
+			}
+			if t, ok := m["timeout"]; ok {
+				req.wait.timeout, err = parseDuration(cast.ToString(t))
+				if err != nil {
+					return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+				}
+			}
+		case "clear":
+			b, ok := vv.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid request: %s", string(part))
+			}
+			req.clear = b
+		default:
+			return nil, fmt.Errorf("invalid request: %s", string(part))
+		}
+	}
+	return req, nil
+}
+
 func parseGrpcRequest(v map[string]any, s *step, expand func(any, *step) (any, error)) (*grpcRequest, error) {
 	v = trimDelimiter(v)
 	req := &grpcRequest{
@@ -270,10 +548,11 @@ func parseGrpcRequest(v map[string]any, s *step, expand func(any, *step) (any, e
 							op: op,
 						})
 					case map[string]any:
-						req.messages = append(req.messages, &grpcMessage{
-							op:     GRPCOpMessage,
-							params: v,
-						})
+						m, err := parseGrpcMessage(v)
+						if err != nil {
+							return nil, fmt.Errorf("invalid request: %s: %w", string(part), err)
+						}
+						req.messages = append(req.messages, m)
 					default:
 						return nil, fmt.Errorf("invalid request: %s", string(part))
 					}
@@ -295,6 +574,91 @@ func parseGrpcRequest(v map[string]any, s *step, expand func(any, *step) (any, e
 	return req, nil
 }
 
+// parseGrpcMessage parses an element of `messages:`.
+// `send:`, `receive:` and `test:` are the ops of the stream script only when the value has the shape of the op
+// ( `send:` with a map, `receive:` with null or a map of `until:` and `timeout:`, `test:` with a string ).
+// Otherwise the element is a message, so a message having only one such field should be wrapped in `send:`.
+func parseGrpcMessage(v map[string]any) (*grpcMessage, error) {
+	msg := &grpcMessage{
+		op:     GRPCOpMessage,
+		params: v,
+	}
+	if len(v) != 1 {
+		return msg, nil
+	}
+	for k, vv := range v {
+		switch k {
+		case "send":
+			params, ok := vv.(map[string]any)
+			if !ok {
+				return msg, nil
+			}
+			return &grpcMessage{
+				op:     GRPCOpMessage,
+				params: params,
+			}, nil
+		case string(GRPCOpReceive):
+			if vv == nil {
+				return &grpcMessage{
+					op: GRPCOpReceive,
+				}, nil
+			}
+			rm, ok := vv.(map[string]any)
+			if !ok || !isGrpcReceiveOp(rm) {
+				return msg, nil
+			}
+			m := &grpcMessage{
+				op: GRPCOpReceive,
+			}
+			if until, ok := rm["until"].(string); ok {
+				m.until = until
+			}
+			if t, ok := rm["timeout"]; ok {
+				timeout, err := parseDuration(cast.ToString(t))
+				if err != nil {
+					return nil, fmt.Errorf("invalid timeout: %w", err)
+				}
+				m.timeout = timeout
+			}
+			return m, nil
+		case string(GRPCOpTest):
+			cond, ok := vv.(string)
+			if !ok {
+				return msg, nil
+			}
+			return &grpcMessage{
+				op:   GRPCOpTest,
+				cond: cond,
+			}, nil
+		}
+	}
+	return msg, nil
+}
+
+// isGrpcReceiveOp returns whether the map has the shape of `receive:` ( a string `until:` and a duration `timeout:` ).
+func isGrpcReceiveOp(m map[string]any) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for k, v := range m {
+		switch k {
+		case "until":
+			if _, ok := v.(string); !ok {
+				return false
+			}
+		case "timeout":
+			switch v.(type) {
+			case string, int, int64, uint64, float64:
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func parseCDPActions(v map[string]any, s *step, expand func(any, *step) (any, error)) (CDPActions, error) {
 	v = trimDelimiter(v)
 	cas := CDPActions{}
@@ -156,6 +156,81 @@ func TestParseHTTPRequest(t *testing.T) {
     body: null
     useCookie: true
     trace: "true"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/users:
+  post:
+    body:
+      application/x-protobuf:
+        name: alice
+    protobuf:
+      request: grpcserver.HelloRequest
+      response: grpcserver.HelloResponse
+`,
+			&httpRequest{
+				path:              "/users",
+				method:            http.MethodPost,
+				mediaType:         MediaTypeApplicationProtobuf,
+				headers:           http.Header{},
+				body:              map[string]any{"name": "alice"},
+				protoRequestType:  "grpcserver.HelloRequest",
+				protoResponseType: "grpcserver.HelloResponse",
+			},
+			false,
+		},
+		{
+			`
+/users:
+  post:
+    body:
+      application/x-protobuf:
+        name: alice
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/users:
+  get:
+    body: null
+    protobuf:
+      message: grpcserver.HelloResponse
+`,
+			nil,
+			true,
+		},
+		{
+			`
+/chat:
+  get:
+    body: null
+    stream:
+      until: current.res.message.data == "[DONE]"
+      timeout: 10
+`,
+			&httpRequest{
+				path:    "/chat",
+				method:  http.MethodGet,
+				headers: http.Header{},
+				stream: &httpStream{
+					until:   `current.res.message.data == "[DONE]"`,
+					timeout: 10 * time.Second,
+				},
+			},
+			false,
+		},
+		{
+			`
+/chat:
+  get:
+    body: null
+    stream:
+      count: 3
 `,
 			nil,
 			true,
@@ -177,7 +252,7 @@ func TestParseHTTPRequest(t *testing.T) {
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(httpRequest{})
+		opts := cmp.AllowUnexported(httpRequest{}, httpStream{})
 		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
 			t.Error(diff)
 		}
@@ -340,6 +415,122 @@ my.custom.server.Service/Method:
 		},
 		{
 			`
+my.custom.server.Service/Method:
+  messages:
+    -
+      send:
+        key: value
+    -
+      receive:
+        until: current.res.message.key == "value"
+        timeout: 3
+    -
+      test: current.res.message.key == "value"
+    -
+      send:
+        test: "{{ current.res.message.key }}"
+    -
+      receive:
+    -
+      close
+`,
+			&grpcRequest{
+				service: "my.custom.server.Service",
+				method:  "Method",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"key": "value",
+						},
+					},
+					{
+						op:      GRPCOpReceive,
+						until:   `current.res.message.key == "value"`,
+						timeout: 3 * time.Second,
+					},
+					{
+						op:   GRPCOpTest,
+						cond: `current.res.message.key == "value"`,
+					},
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"test": "{{ current.res.message.key }}",
+						},
+					},
+					{
+						op: GRPCOpReceive,
+					},
+					{
+						op: GRPCOpClose,
+					},
+				},
+			},
+			false,
+		},
+		{
+			`
+my.custom.server.Service/Method:
+  messages:
+    -
+      send: hello
+    -
+      receive:
+        count: 3
+    -
+      receive:
+        until: 1
+    -
+      test: 1
+`,
+			&grpcRequest{
+				service: "my.custom.server.Service",
+				method:  "Method",
+				headers: metadata.MD{},
+				messages: []*grpcMessage{
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"send": "hello",
+						},
+					},
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"receive": map[string]any{"count": uint64(3)},
+						},
+					},
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"receive": map[string]any{"until": uint64(1)},
+						},
+					},
+					{
+						op: GRPCOpMessage,
+						params: map[string]any{
+							"test": uint64(1),
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			`
+my.custom.server.Service/Method:
+  messages:
+    -
+      receive:
+        timeout: 3x
+`,
+			nil,
+			true,
+		},
+		{
+			`
 "{{ vars.path }}":
   headers:
     "{{ vars.one }}": "{{ vars.two }}"
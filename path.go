@@ -323,6 +323,26 @@ func readFile(p string) ([]byte, error) {
 	}
 }
 
+// readTLSFiles reads the CA certificate, the certificate and the key files of runner configs.
+// Relative paths are resolved from root. Empty paths return nil.
+func readTLSFiles(root, cacert, cert, key string) ([]byte, []byte, []byte, error) {
+	var bs [3][]byte
+	for i, p := range []string{cacert, cert, key} {
+		if p == "" {
+			continue
+		}
+		pp, err := fp(p, root)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		bs[i], err = readFile(pp)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return bs[0], bs[1], bs[2], nil
+}
+
 func fetchPathViaHTTPS(urlstr string) (string, error) {
 	u, err := url.Parse(urlstr)
 	if err != nil {
@@ -529,9 +549,11 @@ func splitPathList(pathp string) []string {
 	return listp
 }
 
+// splitKeyAndPath splits `key:path` ( e.g. the value of --grpc-proto and --openapi3 ) into the key and the path.
+// If there is no key, the key is empty. Paths with a scheme or a volume name ( e.g. `C:\path\to\spec.yml` ) have no key.
 func splitKeyAndPath(kp string) (string, string) {
 	const sep = ":"
-	if !strings.Contains(kp, sep) || strings.HasPrefix(kp, prefixHttps) || strings.HasPrefix(kp, prefixGitHub) || strings.HasPrefix(kp, prefixGist) || strings.HasPrefix(kp, prefixFile) {
+	if !strings.Contains(kp, sep) || strings.HasPrefix(kp, prefixHttps) || strings.HasPrefix(kp, prefixGitHub) || strings.HasPrefix(kp, prefixGist) || strings.HasPrefix(kp, prefixFile) || filepath.VolumeName(kp) != "" {
 		return "", kp
 	}
 	pair := strings.SplitN(kp, sep, 2)
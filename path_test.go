@@ -3,6 +3,7 @@ package runn
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -214,3 +215,70 @@ func TestShortenPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitKeyAndPath(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantKey     string
+		want        string
+		windowsOnly bool
+	}{
+		{"path/to/spec.yml", "", "path/to/spec.yml", false},
+		{"req:path/to/spec.yml", "req", "path/to/spec.yml", false},
+		{"https://example.com/spec.yml", "", "https://example.com/spec.yml", false},
+		{"file://path/to/spec.yml", "", "file://path/to/spec.yml", false},
+		{`C:\path\to\spec.yml`, "", `C:\path\to\spec.yml`, true},
+		{`req:C:\path\to\spec.yml`, "req", `C:\path\to\spec.yml`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if tt.windowsOnly && runtime.GOOS != "windows" {
+				t.Skip("volume names are only on Windows")
+			}
+			gotKey, got := splitKeyAndPath(tt.in)
+			if gotKey != tt.wantKey {
+				t.Errorf("got %v\nwant %v", gotKey, tt.wantKey)
+			}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTLSFiles(t *testing.T) {
+	root, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cacert  string
+		cert    string
+		key     string
+		want    [3]bool
+		wantErr bool
+	}{
+		{"cacert.pem", "cert.pem", "key.pem", [3]bool{true, true, true}, false},
+		{"cacert.pem", "", "", [3]bool{true, false, false}, false},
+		{"", "", "", [3]bool{false, false, false}, false},
+		{"cacert.pem", "notexist.pem", "key.pem", [3]bool{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cacert+","+tt.cert+","+tt.key, func(t *testing.T) {
+			cacert, cert, key, err := readTLSFiles(root, tt.cacert, tt.cert, tt.key)
+			if err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			got := [3]bool{cacert != nil, cert != nil, key != nil}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,732 @@
+package runn
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+)
+
+// postmanDefaultScheme is the scheme used when the URL of a request in a Postman collection does not have it.
+const postmanDefaultScheme = "http"
+
+var (
+	postmanPlaceholderRe = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+	postmanIdentRe       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	postmanTestRe        = regexp.MustCompile("^pm\\.test\\((?:\"[^\"]*\"|'[^']*'|`[^`]*`)\\s*,\\s*(?:function\\s*\\(\\s*\\)|\\(\\s*\\)\\s*=>)\\s*\\{")
+	postmanAliasRe       = regexp.MustCompile(`^(?:var|let|const)\s+([A-Za-z_$][\w$]*)\s*=\s*pm\.response\.json\(\)$`)
+	postmanStatusRe      = regexp.MustCompile(`^pm\.response\.to\.have\.status\((\d+)\)$`)
+	postmanHeaderRe      = regexp.MustCompile(`^pm\.response\.to\.have\.header\((?:"([^"]+)"|'([^']+)')\)$`)
+	postmanExpectRe      = regexp.MustCompile(`^pm\.expect\((.+?)\)\.to\.([A-Za-z.]+?)(?:\((.*)\))?$`)
+	postmanSetRe         = regexp.MustCompile(`^pm\.(?:environment|collectionVariables|globals|variables)\.set\((?:"([^"]+)"|'([^']+)')\s*,\s*(.+)\)$`)
+	postmanPathRe        = regexp.MustCompile(`^(?:\.[A-Za-z_$][\w$]*|\[\d+\]|\["[^"]*"\]|\['[^']*'\])*$`)
+	postmanLiteralRe     = regexp.MustCompile(`^(?:-?\d+(?:\.\d+)?|true|false|null|'[^']*'|"[^"]*"|\[[^\[\]]*\])$`)
+	postmanAliasRefRe    = regexp.MustCompile(`^([A-Za-z_$][\w$]*)(.*)$`)
+	postmanHeaderGetRe   = regexp.MustCompile(`^pm\.response\.headers\.get\((?:"([^"]+)"|'([^']+)')\)$`)
+)
+
+// postmanDynamicVars are Postman dynamic variables that can be converted to runn expressions.
+var postmanDynamicVars = map[string]string{
+	"$guid":            "faker.UUID()",
+	"$randomUUID":      "faker.UUID()",
+	"$randomEmail":     "faker.Email()",
+	"$randomUserName":  "faker.Username()",
+	"$randomFirstName": "faker.FirstName()",
+	"$randomLastName":  "faker.LastName()",
+	"$randomFullName":  "faker.Name()",
+	"$randomInt":       "faker.IntRange(0, 1000)",
+	"$randomBoolean":   "faker.Bool()",
+	"$randomUrl":       "faker.URL()",
+	"$randomIP":        "faker.IPv4()",
+	"$randomIPV6":      "faker.IPv6()",
+	"$randomColor":     "faker.Color()",
+	"$randomHexColor":  "faker.HexColor()",
+	"$randomUserAgent": "faker.UserAgent()",
+}
+
+// postmanExpectOps are chains of Chai assertions in Postman test scripts and their runn operators.
+var postmanExpectOps = map[string]string{
+	"eql":            "==",
+	"eq":             "==",
+	"equal":          "==",
+	"equals":         "==",
+	"be.equal":       "==",
+	"deep.equal":     "==",
+	"not.eql":        "!=",
+	"not.equal":      "!=",
+	"be.above":       ">",
+	"be.gt":          ">",
+	"be.greaterThan": ">",
+	"be.below":       "<",
+	"be.lt":          "<",
+	"be.lessThan":    "<",
+	"be.at.least":    ">=",
+	"be.gte":         ">=",
+	"be.at.most":     "<=",
+	"be.lte":         "<=",
+	"be.oneOf":       "in",
+	"include":        "contains",
+	"contain":        "contains",
+	"have.string":    "contains",
+}
+
+// postmanExpectTerminals are chains of Chai assertions without arguments and their runn conditions.
+var postmanExpectTerminals = map[string]string{
+	"be.true":      "== true",
+	"be.false":     "== false",
+	"be.null":      "== nil",
+	"not.be.null":  "!= nil",
+	"exist":        "!= nil",
+	"not.exist":    "== nil",
+	"be.undefined": "== nil",
+}
+
+type postmanConfig struct {
+	environments []string
+}
+
+type postmanOption func(*postmanConfig) error
+
+// PostmanEnvironment - Set Postman environment files whose values are converted to vars.
+func PostmanEnvironment(paths ...string) postmanOption {
+	return func(c *postmanConfig) error {
+		c.environments = append(c.environments, paths...)
+		return nil
+	}
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []*postmanItem     `json:"item"`
+	Event    []*postmanEvent    `json:"event,omitempty"`
+	Variable []*postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []*postmanItem  `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+	Event   []*postmanEvent `json:"event,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string       `json:"method"`
+	Header []*postmanKV `json:"header,omitempty"`
+	URL    postmanURL   `json:"url"`
+	Body   *postmanBody `json:"body,omitempty"`
+	Auth   *postmanAuth `json:"auth,omitempty"`
+}
+
+// postmanURL is the URL of a request. It is either a string or an object with `raw` in collections.
+type postmanURL struct {
+	Raw string `json:"raw"`
+}
+
+func (u *postmanURL) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		u.Raw = s
+		return nil
+	}
+	var v struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	u.Raw = v.Raw
+	return nil
+}
+
+type postmanBody struct {
+	Mode       string       `json:"mode"`
+	Raw        string       `json:"raw,omitempty"`
+	URLEncoded []*postmanKV `json:"urlencoded,omitempty"`
+	FormData   []*postmanKV `json:"formdata,omitempty"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql,omitempty"`
+	Options *struct {
+		Raw *struct {
+			Language string `json:"language"`
+		} `json:"raw,omitempty"`
+	} `json:"options,omitempty"`
+}
+
+type postmanAuth struct {
+	Type   string       `json:"type"`
+	Bearer []*postmanKV `json:"bearer,omitempty"`
+	APIKey []*postmanKV `json:"apikey,omitempty"`
+}
+
+type postmanKV struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Src      any    `json:"src,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec any `json:"exec"`
+	} `json:"script"`
+}
+
+type postmanVariable struct {
+	Key     string `json:"key"`
+	Value   any    `json:"value"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+type postmanEnvironment struct {
+	Values []*postmanVariable `json:"values"`
+}
+
+// postmanConverter converts requests of a folder in Postman collection to steps of a runbook.
+type postmanConverter struct {
+	rb    *runbook
+	vars  map[string]any
+	bound map[string]struct{}
+}
+
+// ParsePostmanCollection converts Postman collection ( v2.1 ) to runbooks.
+// Requests in each folder are converted to steps of a runbook, and the key of the returned map is the path of the runbook ( e.g. "users/admin.yml" ).
+// Collection and environment variables are converted to vars, and test scripts that cannot be converted to conditions are left as comments.
+func ParsePostmanCollection(in io.Reader, opts ...postmanOption) (map[string]*runbook, error) {
+	c := &postmanConfig{}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	var col postmanCollection
+	if err := json.NewDecoder(in).Decode(&col); err != nil {
+		return nil, fmt.Errorf("failed to decode Postman collection: %w", err)
+	}
+	vars := map[string]any{}
+	for _, v := range col.Variable {
+		if v.Enabled != nil && !*v.Enabled {
+			continue
+		}
+		vars[v.Key] = v.Value
+	}
+	for _, p := range c.environments {
+		b, err := os.ReadFile(filepath.Clean(p))
+		if err != nil {
+			return nil, err
+		}
+		var env postmanEnvironment
+		if err := json.Unmarshal(b, &env); err != nil {
+			return nil, fmt.Errorf("failed to decode Postman environment %s: %w", p, err)
+		}
+		for _, v := range env.Values {
+			if v.Enabled != nil && !*v.Enabled {
+				continue
+			}
+			vars[v.Key] = v.Value
+		}
+	}
+
+	rbs := map[string]*runbook{}
+	var walk func(name string, dir []string, items []*postmanItem, events []*postmanEvent) error
+	walk = func(name string, dir []string, items []*postmanItem, events []*postmanEvent) error {
+		var (
+			reqs    []*postmanItem
+			folders []*postmanItem
+		)
+		for _, it := range items {
+			if it.Request != nil {
+				reqs = append(reqs, it)
+				continue
+			}
+			folders = append(folders, it)
+		}
+		if len(reqs) > 0 {
+			rb := NewRunbook(name)
+			for k, v := range vars {
+				rb.Vars[k] = v
+			}
+			pc := &postmanConverter{rb: rb, vars: vars, bound: map[string]struct{}{}}
+			if lines := postmanScriptLines(events); len(lines) > 0 {
+				pc.comment("$.steps", "Scripts of the collection or the folder:", lines)
+			}
+			for _, it := range reqs {
+				if err := pc.appendStep(it); err != nil {
+					return err
+				}
+			}
+			base := postmanSlug(name)
+			if len(dir) > 0 {
+				base = path.Join(dir...)
+			}
+			// Folders whose names are converted to the same slug get a numeric suffix
+			p := fmt.Sprintf("%s.yml", base)
+			for i := 2; ; i++ {
+				if _, ok := rbs[p]; !ok {
+					break
+				}
+				p = fmt.Sprintf("%s-%d.yml", base, i)
+			}
+			rbs[p] = rb
+		}
+		for _, f := range folders {
+			if err := walk(fmt.Sprintf("%s / %s", name, f.Name), append(slices.Clone(dir), postmanSlug(f.Name)), f.Item, slices.Concat(events, f.Event)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(col.Info.Name, nil, col.Item, col.Event); err != nil {
+		return nil, err
+	}
+	return rbs, nil
+}
+
+func (pc *postmanConverter) appendStep(it *postmanItem) error {
+	i := len(pc.rb.Steps)
+	var comments []string
+	dsn, p := pc.splitURL(it.Request.URL.Raw)
+	key := pc.rb.setRunner(dsn)
+
+	// headers
+	headers := yaml.MapSlice{}
+	for _, h := range it.Request.Header {
+		if h.Disabled {
+			continue
+		}
+		headers = append(headers, yaml.MapItem{Key: h.Key, Value: pc.replace(fmt.Sprintf("%v", h.Value))})
+	}
+	if it.Request.Auth != nil {
+		switch it.Request.Auth.Type {
+		case "noauth":
+		case "bearer":
+			if token, ok := postmanKVValue(it.Request.Auth.Bearer, "token"); ok {
+				headers = append(headers, yaml.MapItem{Key: "Authorization", Value: fmt.Sprintf("Bearer %s", pc.replace(token))})
+			}
+		case "apikey":
+			k, _ := postmanKVValue(it.Request.Auth.APIKey, "key")
+			v, _ := postmanKVValue(it.Request.Auth.APIKey, "value")
+			if in, _ := postmanKVValue(it.Request.Auth.APIKey, "in"); in == "query" {
+				comments = append(comments, fmt.Sprintf("apikey auth in query: %s", k))
+				break
+			}
+			headers = append(headers, yaml.MapItem{Key: k, Value: pc.replace(v)})
+		default:
+			comments = append(comments, fmt.Sprintf("%s auth is not converted", it.Request.Auth.Type))
+		}
+	}
+
+	// body
+	var body any
+	contentType := ""
+	for _, h := range headers {
+		if strings.EqualFold(h.Key.(string), "Content-Type") {
+			contentType = h.Value.(string)
+		}
+	}
+	headers = slices.DeleteFunc(headers, func(h yaml.MapItem) bool {
+		return strings.EqualFold(h.Key.(string), "Content-Type")
+	})
+	if b := it.Request.Body; b != nil {
+		switch b.Mode {
+		case "raw":
+			if contentType == "" {
+				contentType = MediaTypeTextPlain
+				if b.Options != nil && b.Options.Raw != nil && b.Options.Raw.Language == "json" {
+					contentType = MediaTypeApplicationJSON
+				}
+			}
+			var v any = pc.replace(b.Raw)
+			if strings.Contains(contentType, "json") {
+				var vv any
+				if err := json.Unmarshal([]byte(b.Raw), &vv); err == nil {
+					v = pc.replaceAll(vv)
+				} else {
+					contentType = MediaTypeTextPlain
+					comments = append(comments, "JSON body with placeholders outside strings is converted to text/plain")
+				}
+			}
+			body = yaml.MapSlice{{Key: contentType, Value: v}}
+		case "urlencoded":
+			f := yaml.MapSlice{}
+			for _, kv := range b.URLEncoded {
+				if kv.Disabled {
+					continue
+				}
+				f = append(f, yaml.MapItem{Key: kv.Key, Value: pc.replace(fmt.Sprintf("%v", kv.Value))})
+			}
+			body = yaml.MapSlice{{Key: MediaTypeApplicationFormUrlencoded, Value: f}}
+		case "formdata":
+			f := yaml.MapSlice{}
+			for _, kv := range b.FormData {
+				if kv.Disabled {
+					continue
+				}
+				if kv.Type == "file" {
+					f = append(f, yaml.MapItem{Key: kv.Key, Value: fmt.Sprintf("%v", kv.Src)})
+					continue
+				}
+				f = append(f, yaml.MapItem{Key: kv.Key, Value: pc.replace(fmt.Sprintf("%v", kv.Value))})
+			}
+			body = yaml.MapSlice{{Key: MediaTypeMultipartFormData, Value: f}}
+		case "graphql":
+			if b.GraphQL != nil {
+				q := yaml.MapSlice{{Key: "query", Value: pc.replace(b.GraphQL.Query)}}
+				var vars any
+				if err := json.Unmarshal([]byte(b.GraphQL.Variables), &vars); err == nil {
+					q = append(q, yaml.MapItem{Key: "variables", Value: pc.replaceAll(vars)})
+				}
+				body = yaml.MapSlice{{Key: MediaTypeApplicationJSON, Value: q}}
+			}
+		default:
+			if b.Mode != "" {
+				comments = append(comments, fmt.Sprintf("%s body is not converted", b.Mode))
+			}
+		}
+	}
+
+	req := yaml.MapSlice{}
+	if len(headers) > 0 {
+		req = append(req, yaml.MapItem{Key: "headers", Value: headers})
+	}
+	req = append(req, yaml.MapItem{Key: "body", Value: body})
+	method := strings.ToLower(it.Request.Method)
+	if method == "" {
+		method = strings.ToLower(http.MethodGet)
+	}
+	step := yaml.MapSlice{
+		{Key: "desc", Value: it.Name},
+		{Key: key, Value: yaml.MapSlice{{Key: p, Value: yaml.MapSlice{{Key: method, Value: req}}}}},
+	}
+
+	// scripts
+	for _, ev := range it.Event {
+		lines := postmanExecLines(ev.Script.Exec)
+		switch ev.Listen {
+		case "test":
+			conds, binds, rest := pc.convertTestScript(lines)
+			if len(conds) > 0 {
+				step = append(step, yaml.MapItem{Key: testRunnerKey, Value: strings.Join(conds, "\n&& ")})
+			}
+			if len(binds) > 0 {
+				step = append(step, yaml.MapItem{Key: bindRunnerKey, Value: binds})
+			}
+			if len(rest) > 0 {
+				comments = append(comments, "Test script that is not converted:")
+				comments = append(comments, rest...)
+			}
+		default:
+			if len(lines) > 0 {
+				comments = append(comments, fmt.Sprintf("Script of %s that is not converted:", ev.Listen))
+				comments = append(comments, lines...)
+			}
+		}
+	}
+	pc.rb.Steps = append(pc.rb.Steps, step)
+	if len(comments) > 0 {
+		pc.comment(fmt.Sprintf("$.steps[%d]", i), comments[0], comments[1:])
+	}
+	return nil
+}
+
+// splitURL splits the URL of the request to the DSN of the HTTP runner and the path of the step.
+// Placeholders in the scheme and the host are resolved using variables.
+func (pc *postmanConverter) splitURL(raw string) (string, string) {
+	withScheme := func(s string) string {
+		if !strings.Contains(s, "://") {
+			return fmt.Sprintf("%s://%s", postmanDefaultScheme, s)
+		}
+		return s
+	}
+	resolved := postmanPlaceholderRe.ReplaceAllStringFunc(raw, func(in string) string {
+		name := postmanPlaceholderRe.FindStringSubmatch(in)[1]
+		if v, ok := pc.vars[name]; ok {
+			return fmt.Sprintf("%v", v)
+		}
+		return in
+	})
+	u, err := url.Parse(withScheme(resolved))
+	if err != nil || u.Host == "" || strings.Contains(u.Host, "{{") {
+		return scaffoldDummyEndpoint, "/"
+	}
+	dsn := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	// Resolve placeholders from the left until the URL starts with the DSN
+	s := raw
+	for {
+		if rest, ok := strings.CutPrefix(withScheme(s), dsn); ok && (rest == "" || strings.HasPrefix(rest, "/") || strings.HasPrefix(rest, "?")) {
+			if !strings.HasPrefix(rest, "/") {
+				rest = "/" + rest
+			}
+			return dsn, pc.replace(rest)
+		}
+		loc := postmanPlaceholderRe.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return dsn, "/"
+		}
+		v, ok := pc.vars[s[loc[2]:loc[3]]]
+		if !ok {
+			return dsn, "/"
+		}
+		s = s[:loc[0]] + fmt.Sprintf("%v", v) + s[loc[1]:]
+	}
+}
+
+// replace replaces Postman placeholders ( e.g. "{{token}}" ) with runn expressions.
+func (pc *postmanConverter) replace(in string) string {
+	return postmanPlaceholderRe.ReplaceAllStringFunc(in, func(s string) string {
+		name := postmanPlaceholderRe.FindStringSubmatch(s)[1]
+		if e, ok := postmanDynamicVars[name]; ok {
+			return fmt.Sprintf("{{ %s }}", e)
+		}
+		return fmt.Sprintf("{{ %s }}", pc.ref(name))
+	})
+}
+
+// replaceAll replaces Postman placeholders in the strings of the value.
+func (pc *postmanConverter) replaceAll(v any) any {
+	switch vv := v.(type) {
+	case string:
+		return pc.replace(vv)
+	case map[string]any:
+		for k, vvv := range vv {
+			vv[k] = pc.replaceAll(vvv)
+		}
+		return vv
+	case []any:
+		for i, vvv := range vv {
+			vv[i] = pc.replaceAll(vvv)
+		}
+		return vv
+	default:
+		return v
+	}
+}
+
+// ref returns the runn expression referring to the Postman variable.
+// Variables set by test scripts of previous steps refer to bound values.
+func (pc *postmanConverter) ref(name string) string {
+	if _, ok := pc.bound[name]; ok && postmanIdentRe.MatchString(name) {
+		return name
+	}
+	if postmanIdentRe.MatchString(name) {
+		return fmt.Sprintf("vars.%s", name)
+	}
+	return fmt.Sprintf("vars[%s]", strconv.Quote(name))
+}
+
+// convertTestScript converts simple assertions of Postman test script to conditions and binds.
+// Lines that cannot be converted are returned as rest.
+func (pc *postmanConverter) convertTestScript(lines []string) ([]string, yaml.MapSlice, []string) {
+	var (
+		conds []string
+		binds yaml.MapSlice
+		rest  []string
+	)
+	aliases := map[string]struct{}{}
+	for _, l := range lines {
+		s := strings.TrimSpace(l)
+		s = postmanTestRe.ReplaceAllString(s, "")
+		converted := true
+		for _, stmt := range strings.Split(s, ";") {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" || stmt == "}" || stmt == "})" || strings.HasPrefix(stmt, "//") {
+				continue
+			}
+			if m := postmanAliasRe.FindStringSubmatch(stmt); m != nil {
+				aliases[m[1]] = struct{}{}
+				continue
+			}
+			if m := postmanSetRe.FindStringSubmatch(stmt); m != nil {
+				name := m[1] + m[2]
+				if subj, ok := postmanSubject(m[3], aliases); ok && postmanIdentRe.MatchString(name) {
+					binds = append(binds, yaml.MapItem{Key: name, Value: subj})
+					pc.bound[name] = struct{}{}
+					continue
+				}
+				converted = false
+				continue
+			}
+			if cond, ok := postmanCond(stmt, aliases); ok {
+				conds = append(conds, cond)
+				continue
+			}
+			converted = false
+		}
+		if !converted {
+			rest = append(rest, l)
+		}
+	}
+	return conds, binds, rest
+}
+
+// postmanCond converts an assertion of Postman test script to a condition.
+func postmanCond(stmt string, aliases map[string]struct{}) (string, bool) {
+	switch stmt {
+	case "pm.response.to.be.ok":
+		return "current.res.status == 200", true
+	case "pm.response.to.be.success":
+		return "current.res.status >= 200 && current.res.status < 300", true
+	}
+	if m := postmanStatusRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("current.res.status == %s", m[1]), true
+	}
+	if m := postmanHeaderRe.FindStringSubmatch(stmt); m != nil {
+		return fmt.Sprintf("current.res.headers[%s] != nil", strconv.Quote(http.CanonicalHeaderKey(m[1]+m[2]))), true
+	}
+	m := postmanExpectRe.FindStringSubmatch(stmt)
+	if m == nil {
+		return "", false
+	}
+	subj, ok := postmanSubject(m[1], aliases)
+	if !ok {
+		return "", false
+	}
+	if m[3] == "" && !strings.HasSuffix(stmt, ")") {
+		t, ok := postmanExpectTerminals[m[2]]
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("%s %s", subj, t), true
+	}
+	op, ok := postmanExpectOps[m[2]]
+	if !ok {
+		return "", false
+	}
+	v, ok := postmanLiteral(strings.TrimSpace(m[3]))
+	if !ok {
+		return "", false
+	}
+	// `include` of Chai works for both strings and arrays, so it is converted only for strings
+	if op == "contains" && subj != "current.res.rawBody" && !strings.HasPrefix(subj, "current.res.headers") {
+		return "", false
+	}
+	return fmt.Sprintf("%s %s %s", subj, op, v), true
+}
+
+// postmanSubject converts a value of Postman test script ( e.g. `pm.response.json().id` ) to a runn expression.
+func postmanSubject(in string, aliases map[string]struct{}) (string, bool) {
+	in = strings.TrimSpace(in)
+	switch in {
+	case "pm.response.code":
+		return "current.res.status", true
+	case "pm.response.text()":
+		return "current.res.rawBody", true
+	}
+	if m := postmanHeaderGetRe.FindStringSubmatch(in); m != nil {
+		return fmt.Sprintf("current.res.headers[%s][0]", strconv.Quote(http.CanonicalHeaderKey(m[1]+m[2]))), true
+	}
+	var p string
+	if pp, ok := strings.CutPrefix(in, "pm.response.json()"); ok {
+		p = pp
+	} else {
+		m := postmanAliasRefRe.FindStringSubmatch(in)
+		if m == nil {
+			return "", false
+		}
+		if _, ok := aliases[m[1]]; !ok {
+			return "", false
+		}
+		p = m[2]
+	}
+	if !postmanPathRe.MatchString(p) {
+		return "", false
+	}
+	return "current.res.body" + strings.ReplaceAll(p, "'", `"`), true
+}
+
+// postmanLiteral converts a JavaScript literal to a runn expression.
+func postmanLiteral(in string) (string, bool) {
+	if !postmanLiteralRe.MatchString(in) {
+		return "", false
+	}
+	switch {
+	case in == "null":
+		return "nil", true
+	case strings.HasPrefix(in, "'"):
+		return strconv.Quote(strings.Trim(in, "'")), true
+	case strings.HasPrefix(in, "["):
+		return strings.ReplaceAll(in, "'", `"`), true
+	}
+	return in, true
+}
+
+// comment adds comment lines above the node of the path.
+func (pc *postmanConverter) comment(p, head string, lines []string) {
+	if pc.rb.comments == nil {
+		pc.rb.comments = yaml.CommentMap{}
+	}
+	texts := []string{" " + head}
+	for _, l := range lines {
+		texts = append(texts, " "+l)
+	}
+	pc.rb.comments[p] = append(pc.rb.comments[p], yaml.HeadComment(texts...))
+}
+
+func postmanScriptLines(events []*postmanEvent) []string {
+	var lines []string
+	for _, ev := range events {
+		lines = append(lines, postmanExecLines(ev.Script.Exec)...)
+	}
+	return lines
+}
+
+// postmanExecLines returns lines of the script. `exec` is either a string or an array of strings.
+func postmanExecLines(exec any) []string {
+	var lines []string
+	switch v := exec.(type) {
+	case string:
+		lines = strings.Split(v, "\n")
+	case []any:
+		for _, l := range v {
+			if s, ok := l.(string); ok {
+				lines = append(lines, strings.Split(s, "\n")...)
+			}
+		}
+	}
+	return slices.DeleteFunc(lines, func(l string) bool {
+		return strings.TrimSpace(l) == ""
+	})
+}
+
+func postmanKVValue(kvs []*postmanKV, key string) (string, bool) {
+	for _, kv := range kvs {
+		if kv.Key == key {
+			return fmt.Sprintf("%v", kv.Value), true
+		}
+	}
+	return "", false
+}
+
+// postmanSlug returns the file name of the runbook from the name of the collection or the folder.
+func postmanSlug(name string) string {
+	s := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '_'
+		}
+	}, name)
+	s = strings.Trim(s, "_")
+	if s == "" {
+		return "runbook"
+	}
+	return s
+}
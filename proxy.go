@@ -0,0 +1,211 @@
+package runn
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chromedp/chromedp"
+	"golang.org/x/net/http/httpproxy"
+	xproxy "golang.org/x/net/proxy"
+)
+
+// proxy is the outbound proxy for runners.
+type proxy struct {
+	url *url.URL
+	// noProxy - The hosts that should not be proxied ( the value of NO_PROXY ).
+	noProxy string
+	// proxyFunc returns the URL of the proxy for the request URL. It returns nil if the request should not be proxied.
+	proxyFunc func(reqURL *url.URL) (*url.URL, error)
+}
+
+// newProxy returns the outbound proxy.
+// If honorNoProxy is true, the hosts in NO_PROXY ( and localhost ) are not proxied, the same as the standard library.
+func newProxy(rawURL string, honorNoProxy bool) (*proxy, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy: %s", rawURL)
+	}
+	p := &proxy{
+		url: u,
+	}
+	if !honorNoProxy {
+		p.proxyFunc = func(_ *url.URL) (*url.URL, error) {
+			return p.url, nil
+		}
+		return p, nil
+	}
+	p.noProxy = httpproxy.FromEnvironment().NoProxy
+	p.proxyFunc = (&httpproxy.Config{
+		HTTPProxy:  u.String(),
+		HTTPSProxy: u.String(),
+		NoProxy:    p.noProxy,
+	}).ProxyFunc()
+	return p, nil
+}
+
+// httpProxyFunc returns Proxy() for http.Transport.Proxy.
+func (p *proxy) httpProxyFunc() func(req *http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return p.proxyFunc(req.URL)
+	}
+}
+
+// contextDialerFunc returns Dialer() for grpc.WithContextDialer.
+// The connection to the address is tunneled through the proxy, and the address that should not be proxied is dialed with direct.
+func (p *proxy) contextDialerFunc(direct func(ctx context.Context, address string) (net.Conn, error)) func(ctx context.Context, address string) (net.Conn, error) {
+	return func(ctx context.Context, address string) (net.Conn, error) {
+		if network, _ := parseDialTarget(address); network != "tcp" {
+			return direct(ctx, address)
+		}
+		pu, err := p.proxyFunc(&url.URL{Scheme: "https", Host: address})
+		if err != nil {
+			return nil, err
+		}
+		if pu == nil {
+			return direct(ctx, address)
+		}
+		return p.dialContext(ctx, address)
+	}
+}
+
+// dialContext connects to the address through the proxy.
+func (p *proxy) dialContext(ctx context.Context, address string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	switch p.url.Scheme {
+	case "socks5", "socks5h":
+		d, err := xproxy.FromURL(p.url, dialer)
+		if err != nil {
+			return nil, err
+		}
+		cd, ok := d.(xproxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("failed to cast: %v", d)
+		}
+		return cd.DialContext(ctx, "tcp", address)
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", p.hostPort())
+	if err != nil {
+		return nil, err
+	}
+	if p.url.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: p.url.Hostname(),
+		})
+	}
+	c, err := p.connect(ctx, conn, address)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// connect establishes the tunnel to the address with the CONNECT method.
+func (p *proxy) connect(ctx context.Context, conn net.Conn, address string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = conn.SetDeadline(time.Time{})
+		}()
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if p.url.User != nil {
+		password, _ := p.url.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(p.url.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to connect to the proxy: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the proxy: %w", err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to connect to %s through the proxy: %s", address, res.Status)
+	}
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+func (p *proxy) hostPort() string {
+	if p.url.Port() != "" {
+		return p.url.Host
+	}
+	port := "80"
+	if p.url.Scheme == "https" {
+		port = "443"
+	}
+	return net.JoinHostPort(p.url.Hostname(), port)
+}
+
+// chromedpOpts returns the options to launch Chrome with the proxy.
+func (p *proxy) chromedpOpts() []chromedp.ExecAllocatorOption {
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.ProxyServer(p.chromeProxyServer()),
+	}
+	var bypass []string
+	for _, h := range strings.Split(p.noProxy, ",") {
+		h = strings.TrimSpace(h)
+		switch {
+		case h == "":
+			continue
+		case strings.HasPrefix(h, "."):
+			h = "*" + h
+		}
+		bypass = append(bypass, h)
+	}
+	if len(bypass) > 0 {
+		opts = append(opts, chromedp.Flag("proxy-bypass-list", strings.Join(bypass, ";")))
+	}
+	return opts
+}
+
+// chromeProxyServer returns the value of --proxy-server.
+// Chrome does not accept the credentials in --proxy-server ( authenticated proxies are not supported for CDP ), so they are stripped not to leak them via the process list.
+func (p *proxy) chromeProxyServer() string {
+	u := *p.url
+	u.User = nil
+	return strings.Replace(u.String(), "socks5h://", "socks5://", 1) // Chrome resolves hostnames via SOCKS5 proxy by default
+}
+
+// bufferedConn is net.Conn that reads the data buffered while establishing the tunnel first.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
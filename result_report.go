@@ -0,0 +1,238 @@
+package runn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Skipped    int               `xml:"skipped,attr"`
+	Time       string            `xml:"time,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	ID        string           `xml:"id,attr,omitempty"`
+	File      string           `xml:"file,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct{}
+
+// runbookCaseName is the name of the test case for the failure of the runbook itself ( e.g. failure of `needs:` ).
+const runbookCaseName = "(runbook)"
+
+// OutJUnit outputs the result in JUnit XML format.
+// Each RunResult is mapped to a test suite and each StepResult ( including steps of included runbooks ) is mapped to a test case.
+func (r *runNResult) OutJUnit(out io.Writer) error {
+	tss := &junitTestSuites{
+		Name: "runn",
+	}
+	var elapsed time.Duration
+	for _, rr := range r.RunResults {
+		ts := junitTestSuiteFromRunResult(rr)
+		tss.Tests += ts.Tests
+		tss.Failures += ts.Failures
+		tss.Skipped += ts.Skipped
+		elapsed += rr.Elapsed
+		tss.TestSuites = append(tss.TestSuites, ts)
+	}
+	tss.Time = junitTime(elapsed)
+	if _, err := fmt.Fprint(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(tss); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(out, "\n"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func junitTestSuiteFromRunResult(rr *RunResult) *junitTestSuite {
+	np := normalizePath(rr.Path)
+	name := rr.Desc
+	if name == "" {
+		name = np
+	}
+	ts := &junitTestSuite{
+		Name: name,
+		ID:   rr.ID,
+		File: np,
+		Time: junitTime(rr.Elapsed),
+	}
+	ts.TestCases = junitTestCasesFromStepResults(rr, "")
+	if rr.Err != nil && !lo.ContainsBy(ts.TestCases, func(tc *junitTestCase) bool { return tc.Failure != nil }) {
+		ts.TestCases = append(ts.TestCases, &junitTestCase{
+			Name:      runbookCaseName,
+			Classname: np,
+			Time:      junitTime(rr.Elapsed),
+			Failure:   newJUnitFailure(rr.Err),
+		})
+	}
+	for _, tc := range ts.TestCases {
+		ts.Tests++
+		switch {
+		case tc.Failure != nil:
+			ts.Failures++
+		case tc.Skipped != nil:
+			ts.Skipped++
+		}
+	}
+	return ts
+}
+
+func junitTestCasesFromStepResults(rr *RunResult, prefix string) []*junitTestCase {
+	var tcs []*junitTestCase
+	np := normalizePath(rr.Path)
+	for _, sr := range rr.StepResults {
+		tc := &junitTestCase{
+			Name:      prefix + sr.Key,
+			Classname: np,
+			Time:      junitTime(sr.Elapsed),
+		}
+		switch {
+		case sr.Err != nil:
+			tc.Failure = newJUnitFailure(sr.Err)
+		case sr.Skipped || rr.Skipped:
+			tc.Skipped = &junitSkipped{}
+		}
+		tcs = append(tcs, tc)
+		for _, ir := range sr.IncludedRunResults {
+			tcs = append(tcs, junitTestCasesFromStepResults(ir, fmt.Sprintf("%s > ", tc.Name))...)
+		}
+	}
+	return tcs
+}
+
+func newJUnitFailure(err error) *junitFailure {
+	msg := strings.TrimRight(err.Error(), "\n")
+	first, _, _ := strings.Cut(msg, "\n")
+	return &junitFailure{
+		Message: first,
+		Text:    msg,
+	}
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// OutTAP outputs the result in TAP ( Test Anything Protocol ) version 14 format.
+// Each RunResult is mapped to a test point and each StepResult is mapped to a test point of the subtest.
+func (r *runNResult) OutTAP(out io.Writer) error {
+	if _, err := fmt.Fprintln(out, "TAP version 14"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(out, "1..%d\n", len(r.RunResults)); err != nil {
+		return err
+	}
+	for i, rr := range r.RunResults {
+		if err := outTAPRunResult(out, rr, i+1, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func outTAPRunResult(out io.Writer, rr *RunResult, num, nest int) error {
+	indent := strings.Repeat("    ", nest)
+	np := normalizePath(rr.Path)
+	if len(rr.StepResults) > 0 {
+		if _, err := fmt.Fprintf(out, "%s    # Subtest: %s\n", indent, np); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "%s    1..%d\n", indent, len(rr.StepResults)); err != nil {
+			return err
+		}
+		for i, sr := range rr.StepResults {
+			if len(sr.IncludedRunResults) > 0 {
+				if _, err := fmt.Fprintf(out, "%s        # Subtest: %s\n", indent, sr.Key); err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintf(out, "%s        1..%d\n", indent, len(sr.IncludedRunResults)); err != nil {
+					return err
+				}
+				for ii, ir := range sr.IncludedRunResults {
+					if err := outTAPRunResult(out, ir, ii+1, nest+2); err != nil {
+						return err
+					}
+				}
+			}
+			if err := outTAPTestPoint(out, nest+1, i+1, sr.Key, sr.Err, sr.Skipped || rr.Skipped, sr.Elapsed); err != nil {
+				return err
+			}
+		}
+	}
+	return outTAPTestPoint(out, nest, num, np, rr.Err, rr.Skipped, rr.Elapsed)
+}
+
+func outTAPTestPoint(out io.Writer, nest, num int, desc string, err error, skipped bool, elapsed time.Duration) error {
+	indent := strings.Repeat("    ", nest)
+	var line string
+	switch {
+	case err != nil:
+		line = fmt.Sprintf("%snot ok %d - %s\n", indent, num, desc)
+	case skipped:
+		line = fmt.Sprintf("%sok %d - %s # SKIP\n", indent, num, desc)
+	default:
+		line = fmt.Sprintf("%sok %d - %s\n", indent, num, desc)
+	}
+	if _, werr := fmt.Fprint(out, line); werr != nil {
+		return werr
+	}
+	if err == nil && elapsed <= 0 {
+		return nil
+	}
+	// YAML diagnostic block
+	if _, werr := fmt.Fprintf(out, "%s  ---\n", indent); werr != nil {
+		return werr
+	}
+	if err != nil {
+		if _, werr := fmt.Fprintf(out, "%s  message: |\n", indent); werr != nil {
+			return werr
+		}
+		if _, werr := fmt.Fprint(out, sprintMultilinef(indent+"    %s\n", "%s", strings.TrimRight(err.Error(), "\n"))); werr != nil {
+			return werr
+		}
+	}
+	if elapsed > 0 {
+		if _, werr := fmt.Fprintf(out, "%s  duration_ms: %.3f\n", indent, float64(elapsed.Microseconds())/1000); werr != nil {
+			return werr
+		}
+	}
+	if _, werr := fmt.Fprintf(out, "%s  ...\n", indent); werr != nil {
+		return werr
+	}
+	return nil
+}
@@ -3,9 +3,11 @@ package runn
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/tenntenn/golden"
 )
@@ -258,3 +260,90 @@ func TestResultElasped(t *testing.T) {
 		})
 	}
 }
+
+func TestResultOutJUnitAndTAP(t *testing.T) {
+	tests := []struct {
+		r *runNResult
+	}{
+		{newRunNResult(t, 4, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Desc:        "success",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Elapsed: 1500 * time.Millisecond}},
+				Elapsed:     2 * time.Second,
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_1_fail.yml",
+				Err:         errDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: errors.New("condition is not true\n\nCondition:\n  current.res.status == 200")}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_2_success.yml",
+				Err:         errDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_3.skip.yml",
+				Skipped:     true,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil, Skipped: true, Elapsed: 250 * time.Microsecond}},
+				Elapsed:     time.Millisecond,
+			},
+		})},
+		{newRunNResult(t, 2, []*RunResult{
+			{
+				ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path:        "testdata/book/runn_0_success.yml",
+				Err:         nil,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: nil}},
+			},
+			{
+				ID:   "ab13ba1e546838ceafa17f91ab3220102f397b2e",
+				Path: "testdata/book/runn_1_fail.yml",
+				Err:  errDummy,
+				StepResults: []*StepResult{{ID: "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0", Key: "0", Err: errDummy, IncludedRunResults: []*RunResult{{
+					ID:          "ab13ba1e546838ceafa17f91ab3220102f397b2e?step=0",
+					Path:        "testdata/book/runn_included_0_fail.yml",
+					Err:         errDummy,
+					StepResults: []*StepResult{{Key: "0", Err: nil}, {Key: "1", Err: errDummy}},
+				}}}},
+			},
+		})},
+	}
+	for i, tt := range tests {
+		key := fmt.Sprintf("result_out_junit_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutJUnit(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+		key = fmt.Sprintf("result_out_tap_%d", i)
+		t.Run(key, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.OutTAP(buf); err != nil {
+				t.Error(err)
+			}
+			got := buf.String()
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", key, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", key, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -57,6 +58,7 @@ type runbook struct {
 
 	useMap   bool
 	stepKeys []string
+	comments yaml.CommentMap
 }
 
 type runbookListed runbook
@@ -103,6 +105,15 @@ func NewRunbook(desc string) *runbook {
 	return r
 }
 
+// Encode writes the YAML encoding of the runbook with comments ( e.g. scripts that could not be converted ) to w.
+func (rb *runbook) Encode(w io.Writer) error {
+	opts := slices.Clone(encOpts)
+	if len(rb.comments) > 0 {
+		opts = append(opts, yaml.WithComment(rb.comments))
+	}
+	return yaml.NewEncoder(w, opts...).Encode(rb)
+}
+
 func ParseRunbook(in io.Reader) (*runbook, error) {
 	b, err := io.ReadAll(in)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -303,3 +304,203 @@ func TestRunbookYamlAnchorAndAlias(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendHAR(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []harOption
+	}{
+		{"har", nil},
+		{"har_filtered", []harOption{HARHost("api.example.com"), HARContentType("application/json")}},
+		{"har_vars", []harOption{HARVars(true)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open("testdata/example.har")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = f.Close()
+			})
+			rb := NewRunbook(tt.name)
+			if err := rb.AppendHAR(f, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(bytes.Buffer)
+			enc := yaml.NewEncoder(got, encOpts...)
+			if err := enc.Encode(rb); err != nil {
+				t.Error(err)
+			}
+
+			gf := fmt.Sprintf("%s.append_har", tt.name)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", gf, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", gf, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestAppendOpenAPI3(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"openapi3", "testdata/openapi3.yml"},
+		{"openapi3_scaffold", "testdata/openapi3_scaffold.yml"},
+	}
+	t.Setenv("DEBUG", "false")
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := os.ReadFile(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rb := NewRunbook(tt.name)
+			if err := rb.AppendOpenAPI3(bytes.NewReader(b), tt.spec); err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(bytes.Buffer)
+			enc := yaml.NewEncoder(got, encOpts...)
+			if err := enc.Encode(rb); err != nil {
+				t.Error(err)
+			}
+
+			gf := fmt.Sprintf("%s.append_openapi3", tt.name)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", gf, got)
+			} else if diff := golden.Diff(t, "testdata", gf, got); diff != "" {
+				t.Error(diff)
+			}
+
+			t.Run("coverage", func(t *testing.T) {
+				// All operations of the spec should be covered by the generated runbook
+				spec, err := filepath.Abs(tt.spec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rb := NewRunbook(tt.name)
+				if err := rb.AppendOpenAPI3(bytes.NewReader(b), spec); err != nil {
+					t.Fatal(err)
+				}
+				b, err := yaml.Marshal(rb)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p := filepath.Join(t.TempDir(), "scaffold.yml")
+				if err := os.WriteFile(p, b, 0600); err != nil {
+					t.Fatal(err)
+				}
+				o, err := New(Book(p), Scopes(ScopeAllowReadParent))
+				if err != nil {
+					t.Fatal(err)
+				}
+				cov, err := o.collectCoverage(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, s := range cov.Specs {
+					for k, c := range s.Coverages {
+						if c == 0 {
+							t.Errorf("%s is not covered", k)
+						}
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestParsePostmanCollection(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []postmanOption
+	}{
+		{"postman", nil},
+		{"postman_env", []postmanOption{PostmanEnvironment("testdata/example.postman_environment.json")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open("testdata/example.postman_collection.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = f.Close()
+			})
+			rbs, err := ParsePostmanCollection(f, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for p := range rbs {
+				paths = append(paths, p)
+			}
+			slices.Sort(paths)
+
+			got := new(bytes.Buffer)
+			for _, p := range paths {
+				_, _ = fmt.Fprintf(got, "# %s\n", p)
+				b := new(bytes.Buffer)
+				if err := rbs[p].Encode(b); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := parseRunbook(b.Bytes()); err != nil {
+					t.Errorf("%s: %v", p, err)
+				}
+				_, _ = got.Write(b.Bytes())
+			}
+
+			gf := fmt.Sprintf("%s.parse_postman", tt.name)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", gf, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", gf, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParsePostmanCollectionWithSameSlugs(t *testing.T) {
+	col := `{
+  "info": {"name": "Users"},
+  "item": [
+    {"name": "List", "request": {"method": "GET", "url": "https://example.com/users"}},
+    {"name": "users", "item": [
+      {"name": "Get", "request": {"method": "GET", "url": "https://example.com/users/1"}}
+    ]},
+    {"name": "Foo Bar", "item": [
+      {"name": "Get", "request": {"method": "GET", "url": "https://example.com/foo"}}
+    ]},
+    {"name": "foo_bar", "item": [
+      {"name": "Get", "request": {"method": "GET", "url": "https://example.com/bar"}}
+    ]}
+  ]
+}`
+	rbs, err := ParsePostmanCollection(strings.NewReader(col))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"users.yml":     "Users",
+		"users-2.yml":   "Users / users",
+		"foo_bar.yml":   "Users / Foo Bar",
+		"foo_bar-2.yml": "Users / foo_bar",
+	}
+	got := map[string]string{}
+	for p, rb := range rbs {
+		got[p] = rb.Desc
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -26,6 +26,14 @@ type httpRunnerConfig struct {
 	Timeout                    string `yaml:"timeout,omitempty"`
 	UseCookie                  *bool  `yaml:"useCookie,omitempty"`
 	Trace                      traceConfig
+	Auth                       *httpAuthConfig `yaml:"auth,omitempty"`
+	Proxy                      string          `yaml:"proxy,omitempty"`
+	ImportPaths                []string        `yaml:"importPaths,omitempty"`
+	Protos                     []string        `yaml:"protos,omitempty"`
+	BufDirs                    []string        `yaml:"bufDirs,omitempty"`
+	BufLocks                   []string        `yaml:"bufLocks,omitempty"`
+	BufConfigs                 []string        `yaml:"bufConfigs,omitempty"`
+	BufModules                 []string        `yaml:"bufModules,omitempty"`
 
 	openAPI3Doc libopenapi.Document
 }
@@ -35,8 +43,43 @@ type traceConfig struct {
 	HeaderName string `yaml:"headerName,omitempty"`
 }
 
+type httpAuthConfig struct {
+	OAuth2 *oauth2AuthConfig `yaml:"oauth2,omitempty"`
+	Basic  *basicAuthConfig  `yaml:"basic,omitempty"`
+	Digest *basicAuthConfig  `yaml:"digest,omitempty"`
+	SigV4  *sigV4AuthConfig  `yaml:"sigv4,omitempty"`
+}
+
+type oauth2AuthConfig struct {
+	GrantType      string            `yaml:"grantType,omitempty"`
+	TokenURL       string            `yaml:"tokenURL,omitempty"`
+	Issuer         string            `yaml:"issuer,omitempty"`
+	ClientID       string            `yaml:"clientID,omitempty"`
+	ClientSecret   string            `yaml:"clientSecret,omitempty"`
+	Scopes         []string          `yaml:"scopes,omitempty"`
+	Username       string            `yaml:"username,omitempty"`
+	Password       string            `yaml:"password,omitempty"`
+	RefreshToken   string            `yaml:"refreshToken,omitempty"`
+	EndpointParams map[string]string `yaml:"endpointParams,omitempty"`
+	AuthStyle      string            `yaml:"authStyle,omitempty"`
+}
+
+type basicAuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type sigV4AuthConfig struct {
+	Region          string `yaml:"region"`
+	Service         string `yaml:"service"`
+	AccessKeyID     string `yaml:"accessKeyID,omitempty"`
+	SecretAccessKey string `yaml:"secretAccessKey,omitempty"`
+	SessionToken    string `yaml:"sessionToken,omitempty"`
+}
+
 type grpcRunnerConfig struct {
 	Addr        string   `yaml:"addr"`
+	Protocol    string   `yaml:"protocol,omitempty"`
 	TLS         *bool    `yaml:"tls,omitempty"`
 	CACert      string   `yaml:"cacert,omitempty"`
 	Cert        string   `yaml:"cert,omitempty"`
@@ -44,11 +87,22 @@ type grpcRunnerConfig struct {
 	SkipVerify  bool     `yaml:"skipVerify,omitempty"`
 	ImportPaths []string `yaml:"importPaths,omitempty"`
 	Protos      []string `yaml:"protos,omitempty"`
+	Protosets   []string `yaml:"protosets,omitempty"`
 	BufDirs     []string `yaml:"bufDirs,omitempty"`
 	BufLocks    []string `yaml:"bufLocks,omitempty"`
 	BufConfigs  []string `yaml:"bufConfigs,omitempty"`
 	BufModules  []string `yaml:"bufModules,omitempty"`
+	Proxy       string   `yaml:"proxy,omitempty"`
 	Trace       traceConfig
+	// Compression - compressor name to compress request messages ( e.g. gzip )
+	Compression string `yaml:"compression,omitempty"`
+	// MaxSendMsgSize - max size of request messages ( e.g. 4MB )
+	MaxSendMsgSize string `yaml:"maxSendMsgSize,omitempty"`
+	// MaxRecvMsgSize - max size of response messages ( e.g. 4MB )
+	MaxRecvMsgSize string               `yaml:"maxRecvMsgSize,omitempty"`
+	Keepalive      *grpcKeepaliveConfig `yaml:"keepalive,omitempty"`
+	// Timeout - default timeout ( deadline ) of each RPC. `timeout:` of the step overrides it.
+	Timeout string `yaml:"timeout,omitempty"`
 
 	cacert []byte
 	cert   []byte
@@ -83,13 +137,74 @@ type includeRunnerConfig struct {
 	Params map[string]any `yaml:"params,omitempty"`
 }
 
+type graphqlRunnerConfig struct {
+	Endpoint            string `yaml:"graphql"`
+	Schema              string `yaml:"schema,omitempty"`
+	SkipValidateRequest bool   `yaml:"skipValidateRequest,omitempty"`
+	CACert              string `yaml:"cacert,omitempty"`
+	Cert                string `yaml:"cert,omitempty"`
+	Key                 string `yaml:"key,omitempty"`
+	SkipVerify          bool   `yaml:"skipVerify,omitempty"`
+	Timeout             string `yaml:"timeout,omitempty"`
+	UseCookie           *bool  `yaml:"useCookie,omitempty"`
+}
+
+type wsRunnerConfig struct {
+	Endpoint   string            `yaml:"endpoint"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	CACert     string            `yaml:"cacert,omitempty"`
+	Cert       string            `yaml:"cert,omitempty"`
+	Key        string            `yaml:"key,omitempty"`
+	SkipVerify bool              `yaml:"skipVerify,omitempty"`
+	Timeout    string            `yaml:"timeout,omitempty"`
+}
+
+type serverRunnerConfig struct {
+	Addr   string         `yaml:"server"`
+	Routes []*serverRoute `yaml:"routes,omitempty"`
+}
+
+type serverRoute struct {
+	Method   string               `yaml:"method,omitempty"`
+	Path     string               `yaml:"path"`
+	Match    string               `yaml:"match,omitempty"`
+	Response *serverRouteResponse `yaml:"response,omitempty"`
+}
+
+type serverRouteResponse struct {
+	Status  any               `yaml:"status,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Body    any               `yaml:"body,omitempty"`
+}
+
+type grpcServerRunnerConfig struct {
+	Addr        string         `yaml:"grpcServer"`
+	Cert        string         `yaml:"cert,omitempty"`
+	Key         string         `yaml:"key,omitempty"`
+	Reflection  *bool          `yaml:"reflection,omitempty"`
+	ImportPaths []string       `yaml:"importPaths,omitempty"`
+	Protos      []string       `yaml:"protos,omitempty"`
+	BufDirs     []string       `yaml:"bufDirs,omitempty"`
+	BufLocks    []string       `yaml:"bufLocks,omitempty"`
+	BufConfigs  []string       `yaml:"bufConfigs,omitempty"`
+	BufModules  []string       `yaml:"bufModules,omitempty"`
+	Methods     map[string]any `yaml:"methods,omitempty"`
+}
+
 type cdpRunnerConfig struct {
+	Remote string         `yaml:"remote"`
 	Flags  map[string]any `yaml:"flags,omitempty"`
-	Remote string         `yaml:"-"`
+	Proxy  string         `yaml:"proxy,omitempty"`
 }
 
 type httpRunnerOption func(*httpRunnerConfig) error
 
+type grpcKeepaliveConfig struct {
+	Time                string `yaml:"time,omitempty"`
+	Timeout             string `yaml:"timeout,omitempty"`
+	PermitWithoutStream bool   `yaml:"permitWithoutStream,omitempty"`
+}
+
 type grpcRunnerOption func(*grpcRunnerConfig) error
 
 type dbRunnerOption func(*dbRunnerConfig) error
@@ -98,6 +213,14 @@ type sshRunnerOption func(*sshRunnerConfig) error
 
 type cdpRunnerOption func(*cdpRunnerConfig) error
 
+type graphqlRunnerOption func(*graphqlRunnerConfig) error
+
+type wsRunnerOption func(*wsRunnerConfig) error
+
+type serverRunnerOption func(*serverRunnerConfig) error
+
+type grpcServerRunnerOption func(*grpcServerRunnerConfig) error
+
 func (c *sshRunnerConfig) validate() error {
 	if c.Host == "" && c.Hostname == "" {
 		return fmt.Errorf("host or hostname is required")
@@ -240,6 +363,287 @@ func HTTPTrace(trace bool) httpRunnerOption {
 	}
 }
 
+// HTTPBasicAuth sets the credentials of Basic authentication.
+func HTTPBasicAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{Basic: &basicAuthConfig{Username: username, Password: password}}
+		return nil
+	}
+}
+
+// HTTPDigestAuth sets the credentials of Digest authentication.
+func HTTPDigestAuth(username, password string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{Digest: &basicAuthConfig{Username: username, Password: password}}
+		return nil
+	}
+}
+
+// HTTPOAuth2ClientCredentials sets the OAuth 2.0 client credentials grant to acquire the access token.
+func HTTPOAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{OAuth2: &oauth2AuthConfig{
+			GrantType:    oauth2GrantTypeClientCredentials,
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Scopes:       scopes,
+		}}
+		return nil
+	}
+}
+
+// HTTPSigV4 sets the region and the service to sign requests with AWS Signature Version 4.
+// If accessKeyID is empty, the credentials are read from the environment variables ( AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN ).
+func HTTPSigV4(region, service, accessKeyID, secretAccessKey, sessionToken string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Auth = &httpAuthConfig{SigV4: &sigV4AuthConfig{
+			Region:          region,
+			Service:         service,
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+		}}
+		return nil
+	}
+}
+
+// HTTPProxy sets the outbound proxy of the HTTP runner.
+func HTTPProxy(proxy string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		c.Proxy = proxy
+		return nil
+	}
+}
+
+// GraphQLSchema sets the schema (SDL or the result of the introspection query) of the GraphQL runner.
+func GraphQLSchema(path string) graphqlRunnerOption {
+	return func(c *graphqlRunnerConfig) error {
+		c.Schema = path
+		return nil
+	}
+}
+
+func GraphQLSkipValidateRequest(skip bool) graphqlRunnerOption {
+	return func(c *graphqlRunnerConfig) error {
+		c.SkipValidateRequest = skip
+		return nil
+	}
+}
+
+func GraphQLTimeout(timeout string) graphqlRunnerOption {
+	return func(c *graphqlRunnerConfig) error {
+		c.Timeout = timeout
+		return nil
+	}
+}
+
+// WSHeader sets the header of the WebSocket opening handshake.
+func WSHeader(key, value string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		if c.Headers == nil {
+			c.Headers = map[string]string{}
+		}
+		c.Headers[key] = value
+		return nil
+	}
+}
+
+func WSCACert(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.CACert = path
+		return nil
+	}
+}
+
+func WSCert(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Cert = path
+		return nil
+	}
+}
+
+func WSKey(path string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Key = path
+		return nil
+	}
+}
+
+func WSSkipVerify(skip bool) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.SkipVerify = skip
+		return nil
+	}
+}
+
+func WSTimeout(timeout string) wsRunnerOption {
+	return func(c *wsRunnerConfig) error {
+		c.Timeout = timeout
+		return nil
+	}
+}
+
+// ServerRoute adds the route that responds to requests matching method and path.
+// path can be a pattern of path.Match.
+func ServerRoute(method, path string, status int, headers map[string]string, body any) serverRunnerOption {
+	return func(c *serverRunnerConfig) error {
+		c.Routes = append(c.Routes, &serverRoute{
+			Method: method,
+			Path:   path,
+			Response: &serverRouteResponse{
+				Status:  status,
+				Headers: headers,
+				Body:    body,
+			},
+		})
+		return nil
+	}
+}
+
+// ServerRouteWithMatch adds the route that responds to requests matching method, path and the match condition.
+func ServerRouteWithMatch(method, path, match string, status int, headers map[string]string, body any) serverRunnerOption {
+	return func(c *serverRunnerConfig) error {
+		c.Routes = append(c.Routes, &serverRoute{
+			Method: method,
+			Path:   path,
+			Match:  match,
+			Response: &serverRouteResponse{
+				Status:  status,
+				Headers: headers,
+				Body:    body,
+			},
+		})
+		return nil
+	}
+}
+
+// GRPCServerProtos appends protos of the gRPC mock server.
+func GRPCServerProtos(protos ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.Protos = unique(append(c.Protos, protos...))
+		return nil
+	}
+}
+
+// GRPCServerImportPaths appends import paths of the gRPC mock server.
+func GRPCServerImportPaths(paths ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.ImportPaths = unique(append(c.ImportPaths, paths...))
+		return nil
+	}
+}
+
+func GRPCServerBufDir(dirs ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.BufDirs = unique(append(c.BufDirs, dirs...))
+		return nil
+	}
+}
+
+func GRPCServerBufLock(locks ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.BufLocks = unique(append(c.BufLocks, locks...))
+		return nil
+	}
+}
+
+func GRPCServerBufConfig(configs ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.BufConfigs = unique(append(c.BufConfigs, configs...))
+		return nil
+	}
+}
+
+func GRPCServerBufModule(modules ...string) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.BufModules = unique(append(c.BufModules, modules...))
+		return nil
+	}
+}
+
+// GRPCServerReflection enables or disables server reflection of the gRPC mock server (default: enabled).
+func GRPCServerReflection(enable bool) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		c.Reflection = &enable
+		return nil
+	}
+}
+
+// GRPCServerMethod appends the response of method (e.g. `grpctest.GrpcTestService/Hello`).
+// response has the same structure as the response in the runbook (`match:`, `headers:`, `trailers:`, `status:`, `message:` and `messages:`).
+func GRPCServerMethod(method string, response map[string]any) grpcServerRunnerOption {
+	return func(c *grpcServerRunnerConfig) error {
+		if c.Methods == nil {
+			c.Methods = map[string]any{}
+		}
+		switch v := c.Methods[method].(type) {
+		case nil:
+			c.Methods[method] = []any{response}
+		case []any:
+			c.Methods[method] = append(v, response)
+		default:
+			c.Methods[method] = []any{v, response}
+		}
+		return nil
+	}
+}
+
+// Protocol set the protocol to invoke RPCs ( grpc, grpcweb or connect ).
+func Protocol(protocol string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		if !validGRPCProtocol(protocol) {
+			return fmt.Errorf("invalid protocol: %s", protocol)
+		}
+		c.Protocol = protocol
+		return nil
+	}
+}
+
+// Compression set the compressor name to compress request messages ( e.g. gzip ).
+func Compression(name string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Compression = name
+		return nil
+	}
+}
+
+// MaxSendMsgSize set the max size of request messages ( e.g. 4MB ).
+func MaxSendMsgSize(size string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.MaxSendMsgSize = size
+		return nil
+	}
+}
+
+// MaxRecvMsgSize set the max size of response messages ( e.g. 4MB ).
+func MaxRecvMsgSize(size string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.MaxRecvMsgSize = size
+		return nil
+	}
+}
+
+// Keepalive set the keepalive parameters of the connection.
+func Keepalive(time, timeout string, permitWithoutStream bool) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Keepalive = &grpcKeepaliveConfig{
+			Time:                time,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+		return nil
+	}
+}
+
+// GRPCTimeout set the default timeout ( deadline ) of each RPC.
+func GRPCTimeout(timeout string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Timeout = timeout
+		return nil
+	}
+}
+
 func TLS(useTLS bool) grpcRunnerOption {
 	return func(c *grpcRunnerConfig) error {
 		c.TLS = &useTLS
@@ -297,6 +701,14 @@ func Protos(protos []string) grpcRunnerOption {
 	}
 }
 
+// Protosets append protoset files ( FileDescriptorSet ).
+func Protosets(protosets []string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Protosets = unique(append(c.Protosets, protosets...))
+		return nil
+	}
+}
+
 // ImportPaths set import paths.
 func ImportPaths(paths []string) grpcRunnerOption {
 	return func(c *grpcRunnerConfig) error {
@@ -340,6 +752,14 @@ func BufModule(modules ...string) grpcRunnerOption {
 	}
 }
 
+// GRPCProxy sets the outbound proxy of the gRPC runner.
+func GRPCProxy(proxy string) grpcRunnerOption {
+	return func(c *grpcRunnerConfig) error {
+		c.Proxy = proxy
+		return nil
+	}
+}
+
 func DBTrace(trace bool) dbRunnerOption {
 	return func(c *dbRunnerConfig) error {
 		c.Trace = &trace
@@ -418,6 +838,14 @@ func CDPFlag(flag string, tf any) cdpRunnerOption {
 	}
 }
 
+// CDPProxy sets the outbound proxy of Chrome.
+func CDPProxy(proxy string) cdpRunnerOption {
+	return func(c *cdpRunnerConfig) error {
+		c.Proxy = proxy
+		return nil
+	}
+}
+
 func (t *traceConfig) UnmarshalYAML(b []byte) error {
 	if enable, err := strconv.ParseBool(strings.TrimSpace(string(b))); err == nil {
 		t.Enable = &enable
@@ -41,7 +41,7 @@ func (rnr *runnerRunner) Run(ctx context.Context, s *step) error {
 	return nil
 }
 
-func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error {
+func (rnr *runnerRunner) run(ctx context.Context, d map[string]any, s *step) error {
 	o := s.parent
 	bk := newBook()
 	bk.path = o.bookPath
@@ -53,6 +53,7 @@ func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error
 		if _, ok := o.httpRunners[k]; ok {
 			return fmt.Errorf("http runner key %s is already exists", k)
 		}
+		r.cassettes = o.cassettes
 		o.httpRunners[k] = r
 	}
 	for k, r := range bk.dbRunners {
@@ -65,6 +66,8 @@ func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error
 		if _, ok := o.grpcRunners[k]; ok {
 			return fmt.Errorf("grpc runner key %s is already exists", k)
 		}
+		r.cassettes = o.cassettes
+		r.descriptors = o.grpcDescriptors
 		o.grpcRunners[k] = r
 	}
 	for k, r := range bk.cdpRunners {
@@ -79,6 +82,40 @@ func (rnr *runnerRunner) run(_ context.Context, d map[string]any, s *step) error
 		}
 		o.sshRunners[k] = r
 	}
+	for k, r := range bk.graphqlRunners {
+		if _, ok := o.graphqlRunners[k]; ok {
+			return fmt.Errorf("graphql runner key %s is already exists", k)
+		}
+		r.httpRunner.cassettes = o.cassettes
+		o.graphqlRunners[k] = r
+	}
+	for k, r := range bk.wsRunners {
+		if _, ok := o.wsRunners[k]; ok {
+			return fmt.Errorf("websocket runner key %s is already exists", k)
+		}
+		r.operatorID = o.id
+		o.wsRunners[k] = r
+	}
+	for k, r := range bk.serverRunners {
+		if _, ok := o.serverRunners[k]; ok {
+			return fmt.Errorf("server runner key %s is already exists", k)
+		}
+		r.operatorID = o.id
+		if err := r.start(ctx, o); err != nil {
+			return err
+		}
+		o.serverRunners[k] = r
+	}
+	for k, r := range bk.grpcServerRunners {
+		if _, ok := o.grpcServerRunners[k]; ok {
+			return fmt.Errorf("gRPC server runner key %s is already exists", k)
+		}
+		r.operatorID = o.id
+		if err := r.start(ctx, o); err != nil {
+			return err
+		}
+		o.grpcServerRunners[k] = r
+	}
 	o.record(s.idx, map[string]any{})
 	return nil
 }
@@ -0,0 +1,375 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+	"github.com/spf13/cast"
+)
+
+const (
+	serverStoreRequestsKey = "requests"
+	serverStoreURLKey      = "url"
+)
+
+const (
+	serverRequestStoreMethodKey  = "method"
+	serverRequestStorePathKey    = "path"
+	serverRequestStoreQueryKey   = "query"
+	serverRequestStoreHeaderKey  = "headers"
+	serverRequestStoreBodyKey    = "body"
+	serverRequestStoreRawBodyKey = "rawBody"
+)
+
+// serverEnvRequestKey is the key of the inbound request in the environment for route matching and response templating.
+const serverEnvRequestKey = "request"
+
+const defaultServerWaitTimeout = 10 * time.Second
+
+type serverRunner struct {
+	name       string
+	addr       string
+	routes     []*serverRoute
+	server     *http.Server
+	url        string
+	requests   []map[string]any
+	env        map[string]any
+	received   chan struct{}
+	operatorID string
+	mu         sync.Mutex
+}
+
+type serverRequest struct {
+	wait  *serverWait
+	clear bool
+}
+
+type serverWait struct {
+	until   string
+	count   int
+	timeout time.Duration
+}
+
+func newServerRunner(name, addr string) (*serverRunner, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid server address: %s: %w", addr, err)
+	}
+	return &serverRunner{
+		name: name,
+		addr: addr,
+	}, nil
+}
+
+func (rnr *serverRunner) Renew() error {
+	return rnr.Close()
+}
+
+func (rnr *serverRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server == nil {
+		return nil
+	}
+	err := rnr.server.Close()
+	rnr.server = nil
+	rnr.url = ""
+	return err
+}
+
+func (rnr *serverRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.serverRequest, s)
+	if err != nil {
+		return err
+	}
+	r, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid server request: %v", e)
+	}
+	req, err := parseServerRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *serverRunner) run(ctx context.Context, r *serverRequest, s *step) error {
+	o := s.parent
+	if err := rnr.start(ctx, o); err != nil {
+		return err
+	}
+	if r.wait != nil {
+		if err := waitRequests(ctx, r.wait, o, rnr.snapshot); err != nil {
+			return err
+		}
+	}
+	rnr.mu.Lock()
+	d := rnr.toMap()
+	if r.clear {
+		rnr.requests = nil
+	}
+	rnr.mu.Unlock()
+	o.record(s.idx, d)
+	return nil
+}
+
+// start starts listening if the server is not running yet.
+func (rnr *serverRunner) start(ctx context.Context, o *operator) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.server != nil {
+		return nil
+	}
+	ln, err := net.Listen("tcp", rnr.addr)
+	if err != nil {
+		return err
+	}
+	rnr.server = &http.Server{
+		Handler:           rnr,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	rnr.url = fmt.Sprintf("http://%s", ln.Addr().String())
+	rnr.requests = nil
+	rnr.received = make(chan struct{}, 1)
+	go func(srv *http.Server) {
+		_ = srv.Serve(ln)
+	}(rnr.server)
+	if err := donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Close()
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// snapshot returns the recorded values and the channel notified when a request is received.
+func (rnr *serverRunner) snapshot() (map[string]any, <-chan struct{}) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	return rnr.toMap(), rnr.received
+}
+
+// waitRequests waits until the requests recorded by the stub server satisfy w.
+func waitRequests(ctx context.Context, w *serverWait, o *operator, snapshot func() (map[string]any, <-chan struct{})) error {
+	timeout := w.timeout
+	if timeout == 0 {
+		timeout = defaultServerWaitTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		d, received := snapshot()
+		if w.until == "" {
+			if len(d[serverStoreRequestsKey].([]any)) >= w.count {
+				return nil
+			}
+		} else {
+			sm := o.store.ToMap()
+			sm[store.RootKeyCurrent] = d
+			tf, err := expr.EvalCond(w.until, sm)
+			if err != nil {
+				return err
+			}
+			if tf {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			if w.until != "" {
+				return fmt.Errorf("timeout waiting for requests matching %q", w.until)
+			}
+			return fmt.Errorf("timeout waiting for %d requests", w.count)
+		case <-received:
+		}
+	}
+}
+
+// setStore sets the values used for route matching and response templating.
+// The store itself is not shared because it is not safe for concurrent use.
+func (rnr *serverRunner) setStore(sm map[string]any) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	rnr.env = sm
+}
+
+func (rnr *serverRunner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rm, err := serverRequestToMap(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rnr.mu.Lock()
+	rnr.requests = append(rnr.requests, rm)
+	env := map[string]any{}
+	for k, v := range rnr.env {
+		env[k] = v
+	}
+	routes := rnr.routes
+	select {
+	case rnr.received <- struct{}{}:
+	default:
+	}
+	rnr.mu.Unlock()
+	env[serverEnvRequestKey] = rm
+
+	for _, route := range routes {
+		ok, err := route.matches(req, env)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			continue
+		}
+		if err := route.respond(w, env); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	http.NotFound(w, req)
+}
+
+func (rnr *serverRunner) toMap() map[string]any {
+	requests := make([]any, 0, len(rnr.requests))
+	for _, r := range rnr.requests {
+		requests = append(requests, r)
+	}
+	return map[string]any{
+		serverStoreRequestsKey: requests,
+		serverStoreURLKey:      rnr.url,
+	}
+}
+
+func (rnr *serverRunner) applyConfig(c *serverRunnerConfig) error {
+	for _, r := range c.Routes {
+		if r.Path == "" {
+			return errors.New("path is required for server route")
+		}
+		if _, err := path.Match(r.Path, "/"); err != nil {
+			return fmt.Errorf("invalid server route path: %s: %w", r.Path, err)
+		}
+		rnr.routes = append(rnr.routes, r)
+	}
+	return nil
+}
+
+func (r *serverRoute) matches(req *http.Request, env map[string]any) (bool, error) {
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false, nil
+	}
+	if r.Path != req.URL.Path {
+		ok, err := path.Match(r.Path, req.URL.Path)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if r.Match == "" {
+		return true, nil
+	}
+	return expr.EvalCond(r.Match, env)
+}
+
+func (r *serverRoute) respond(w http.ResponseWriter, env map[string]any) error {
+	res := r.Response
+	if res == nil {
+		res = &serverRouteResponse{}
+	}
+	headers := map[string]any{}
+	for k, v := range res.Headers {
+		headers[k] = v
+	}
+	e, err := expr.EvalExpand(map[string]any{
+		"status":  res.Status,
+		"headers": headers,
+		"body":    res.Body,
+	}, env)
+	if err != nil {
+		return err
+	}
+	m, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid server response: %v", e)
+	}
+	status := http.StatusOK
+	if m["status"] != nil {
+		status, err = cast.ToIntE(m["status"])
+		if err != nil {
+			return fmt.Errorf("invalid server response status: %v: %w", m["status"], err)
+		}
+	}
+	h := w.Header()
+	if hm, ok := m["headers"].(map[string]any); ok {
+		for k, v := range hm {
+			h.Set(k, cast.ToString(v))
+		}
+	}
+	var b []byte
+	switch v := m["body"].(type) {
+	case nil:
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		b, err = json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if h.Get("Content-Type") == "" {
+			h.Set("Content-Type", MediaTypeApplicationJSON)
+		}
+	}
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
+
+func serverRequestToMap(req *http.Request) (map[string]any, error) {
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer req.Body.Close()
+	var body any
+	switch {
+	case len(b) == 0:
+	case strings.Contains(req.Header.Get("Content-Type"), "json"):
+		_ = json.Unmarshal(b, &body)
+	case strings.HasPrefix(req.Header.Get("Content-Type"), MediaTypeApplicationFormUrlencoded):
+		q, err := url.ParseQuery(string(b))
+		if err == nil {
+			body = q
+		}
+	}
+	return map[string]any{
+		serverRequestStoreMethodKey:  req.Method,
+		serverRequestStorePathKey:    req.URL.Path,
+		serverRequestStoreQueryKey:   req.URL.Query(),
+		serverRequestStoreHeaderKey:  req.Header,
+		serverRequestStoreBodyKey:    body,
+		serverRequestStoreRawBodyKey: string(b),
+	}, nil
+}
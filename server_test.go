@@ -0,0 +1,88 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/donegroup"
+)
+
+func TestServerRunner(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/server.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+			r, ok := o.serverRunners["stub"]
+			if !ok {
+				t.Fatal("server runner not found")
+			}
+			if r.server != nil {
+				t.Error("server runner should be closed")
+			}
+		})
+	}
+}
+
+func TestServerRunnerWait(t *testing.T) {
+	tests := []struct {
+		wait    *serverWait
+		wantErr bool
+	}{
+		{&serverWait{count: 2, timeout: 5 * time.Second}, false},
+		{&serverWait{until: `len(current.requests) == 2 && current.requests[1].path == "/b"`, timeout: 5 * time.Second}, false},
+		{&serverWait{count: 3, timeout: 500 * time.Millisecond}, true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			ctx, cancel := donegroup.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			o, err := New(ServerRunner("stub", "127.0.0.1:0", ServerRoute(http.MethodPost, "/*", http.StatusAccepted, nil, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, ok := o.serverRunners["stub"]
+			if !ok {
+				t.Fatal("server runner not found")
+			}
+			t.Cleanup(func() {
+				_ = r.Close()
+			})
+			if err := r.start(ctx, o); err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				for _, p := range []string{"/a", "/b"} {
+					time.Sleep(100 * time.Millisecond)
+					res, err := http.Post(r.url+p, "text/plain", strings.NewReader("hello"))
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					_ = res.Body.Close()
+					if res.StatusCode != http.StatusAccepted {
+						t.Errorf("got %v want %v", res.StatusCode, http.StatusAccepted)
+					}
+				}
+			}()
+			s := newStep(0, "stepKey", o, nil)
+			err = r.run(ctx, &serverRequest{wait: tt.wait}, s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
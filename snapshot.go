@@ -0,0 +1,134 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/internal/builtin"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+)
+
+const snapshotRunnerKey = "snapshot"
+
+// snapshotDir is the directory name of snapshot files created next to the runbook.
+const snapshotDir = "__snapshots__"
+
+type snapshotRunner struct{}
+
+type snapshotRequest struct {
+	expr    string
+	file    string
+	ignores []string
+}
+
+type snapshotMismatchError struct {
+	file string
+	diff string
+}
+
+func newSnapshotMismatchError(file, diff string) *snapshotMismatchError {
+	return &snapshotMismatchError{
+		file: file,
+		diff: diff,
+	}
+}
+
+func (se *snapshotMismatchError) Error() string {
+	diff := sprintMultilinef("  %s\n", "%s", strings.TrimRight(se.diff, "\n"))
+	return fmt.Sprintf("snapshot does not match: %s\n\nDiff:\n%s", se.file, diff)
+}
+
+func newSnapshotRunner() *snapshotRunner {
+	return &snapshotRunner{}
+}
+
+func (rnr *snapshotRunner) Run(ctx context.Context, s *step, first bool) error {
+	r := s.snapshotRequest
+	o := s.parent
+	sm := o.store.ToMap()
+	sm[store.RootKeyIncluded] = o.included
+	if first {
+		if !s.deferred {
+			sm[store.RootKeyPrevious] = o.store.Latest()
+		}
+	} else {
+		if !s.deferred {
+			sm[store.RootKeyPrevious] = o.store.Previous()
+		}
+		sm[store.RootKeyCurrent] = o.store.Latest()
+	}
+	v, err := expr.Eval(r.expr, sm)
+	if err != nil {
+		return err
+	}
+	p := r.file
+	if p == "" {
+		base := filepath.Base(o.bookPath)
+		p = filepath.Join(snapshotDir, fmt.Sprintf("%s.%s.json", strings.TrimSuffix(base, filepath.Ext(base)), s.key))
+	} else {
+		e, err := expr.EvalExpand(p, sm)
+		if err != nil {
+			return err
+		}
+		pp, ok := e.(string)
+		if !ok {
+			return fmt.Errorf("invalid snapshot file: %v", e)
+		}
+		p = pp
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(filepath.Dir(o.bookPath), p)
+	}
+	if err := rnr.run(ctx, p, v, r.ignores, s, first); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *snapshotRunner) run(_ context.Context, p string, v any, ignores []string, s *step, first bool) error {
+	o := s.parent
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	got = append(got, '\n')
+	b, err := os.ReadFile(p)
+	switch {
+	case o.updateSnapshots:
+		// Create or update the snapshot file
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, got, 0600); err != nil {
+			return err
+		}
+		o.Debugf("Write snapshot to %s\n", p)
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("snapshot not found, run with --update-snapshots: %s", p)
+	case err != nil:
+		return err
+	default:
+		var want any
+		if err := json.Unmarshal(b, &want); err != nil {
+			return fmt.Errorf("invalid snapshot file: %s: %w", p, err)
+		}
+		diff, err := builtin.Diff(want, v, ignores)
+		if err != nil {
+			return err
+		}
+		if diff != "" {
+			return newSnapshotMismatchError(p, diff)
+		}
+	}
+	if first {
+		o.record(s.idx, nil)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRunner(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	b, err := os.ReadFile("testdata/book/snapshot.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := filepath.Join(dir, "snapshot.yml")
+	if err := os.WriteFile(book, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	userSnapshot := filepath.Join(dir, snapshotDir, "snapshot.user.json")
+	nameSnapshot := filepath.Join(dir, snapshotDir, "snapshot.name.json")
+
+	tests := []struct {
+		name    string
+		user    map[string]any
+		update  bool
+		wantErr []string
+		want    string
+	}{
+		{"not found", map[string]any{"id": 1, "name": "alice", "createdAt": "2024-01-01T00:00:00Z"}, false, []string{"snapshot not found, run with --update-snapshots", userSnapshot}, ""},
+		{"create", map[string]any{"id": 1, "name": "alice", "createdAt": "2024-01-01T00:00:00Z"}, true, nil, "\"alice\"\n"},
+		{"ignore paths", map[string]any{"id": 1, "name": "alice", "createdAt": "2024-12-31T00:00:00Z"}, false, nil, "\"alice\"\n"},
+		{"mismatch", map[string]any{"id": 1, "name": "bob", "createdAt": "2024-01-01T00:00:00Z"}, false, []string{"snapshot does not match", `string("alice")`, `string("bob")`}, "\"alice\"\n"},
+		{"update", map[string]any{"id": 1, "name": "bob", "createdAt": "2024-01-01T00:00:00Z"}, true, nil, "\"bob\"\n"},
+		{"updated", map[string]any{"id": 1, "name": "bob", "createdAt": "2024-01-01T00:00:00Z"}, false, nil, "\"bob\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New(Book(book), Scopes(ScopeAllowReadParent), Var("user", tt.user), UpdateSnapshots(tt.update))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatal("want error")
+				}
+				for _, w := range tt.wantErr {
+					if !strings.Contains(err.Error(), w) {
+						t.Errorf("got %s, want %q", err.Error(), w)
+					}
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if _, err := os.Stat(userSnapshot); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("got %v, want no snapshot file", err)
+				}
+				return
+			}
+			if _, err := os.Stat(userSnapshot); err != nil {
+				t.Error(err)
+			}
+			got, err := os.ReadFile(nameSnapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,29 +15,39 @@ type step struct {
 	force     bool // forceed run per step
 	loop      *Loop
 	// loopIndex - Index of the loop is dynamically recorded at runtime
-	loopIndex        *int
-	httpRunner       *httpRunner
-	httpRequest      map[string]any
-	dbRunner         *dbRunner
-	dbQuery          map[string]any
-	grpcRunner       *grpcRunner
-	grpcRequest      map[string]any
-	cdpRunner        *cdpRunner
-	cdpActions       map[string]any
-	sshRunner        *sshRunner
-	sshCommand       map[string]any
-	execRunner       *execRunner
-	execCommand      map[string]any
-	testRunner       *testRunner
-	testCond         string
-	dumpRunner       *dumpRunner
-	dumpRequest      *dumpRequest
-	bindRunner       *bindRunner
-	bindCond         map[string]any
-	includeRunner    *includeRunner
-	includeConfig    *includeConfig
-	runnerRunner     *runnerRunner
-	runnerDefinition map[string]any
+	loopIndex         *int
+	httpRunner        *httpRunner
+	httpRequest       map[string]any
+	dbRunner          *dbRunner
+	dbQuery           map[string]any
+	grpcRunner        *grpcRunner
+	grpcRequest       map[string]any
+	cdpRunner         *cdpRunner
+	cdpActions        map[string]any
+	sshRunner         *sshRunner
+	sshCommand        map[string]any
+	graphqlRunner     *graphqlRunner
+	graphqlRequest    map[string]any
+	wsRunner          *wsRunner
+	wsRequest         map[string]any
+	serverRunner      *serverRunner
+	serverRequest     map[string]any
+	grpcServerRunner  *grpcServerRunner
+	grpcServerRequest map[string]any
+	execRunner        *execRunner
+	execCommand       map[string]any
+	testRunner        *testRunner
+	testCond          string
+	dumpRunner        *dumpRunner
+	dumpRequest       *dumpRequest
+	bindRunner        *bindRunner
+	bindCond          map[string]any
+	snapshotRunner    *snapshotRunner
+	snapshotRequest   *snapshotRequest
+	includeRunner     *includeRunner
+	includeConfig     *includeConfig
+	runnerRunner      *runnerRunner
+	runnerDefinition  map[string]any
 
 	// runner values not yet detected.
 	runnerValues map[string]any
@@ -84,6 +94,8 @@ func (s *step) generateTrail() Trail {
 	switch {
 	case s.httpRunner != nil && s.httpRequest != nil:
 		tr.StepRunnerType = RunnerTypeHTTP
+	case s.graphqlRunner != nil && s.graphqlRequest != nil:
+		tr.StepRunnerType = RunnerTypeGraphQL
 	case s.dbRunner != nil && s.dbQuery != nil:
 		tr.StepRunnerType = RunnerTypeDB
 	case s.grpcRunner != nil && s.grpcRequest != nil:
@@ -92,6 +104,12 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeCDP
 	case s.sshRunner != nil && s.sshCommand != nil:
 		tr.StepRunnerType = RunnerTypeSSH
+	case s.wsRunner != nil && s.wsRequest != nil:
+		tr.StepRunnerType = RunnerTypeWS
+	case s.serverRunner != nil && s.serverRequest != nil:
+		tr.StepRunnerType = RunnerTypeServer
+	case s.grpcServerRunner != nil && s.grpcServerRequest != nil:
+		tr.StepRunnerType = RunnerTypeGRPCServer
 	case s.execRunner != nil && s.execCommand != nil:
 		tr.StepRunnerType = RunnerTypeExec
 	case s.includeRunner != nil && s.includeConfig != nil:
@@ -100,6 +118,8 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeDump
 	case s.bindRunner != nil && s.bindCond != nil:
 		tr.StepRunnerType = RunnerTypeBind
+	case s.snapshotRunner != nil && s.snapshotRequest != nil:
+		tr.StepRunnerType = RunnerTypeSnapshot
 	case s.testRunner != nil && s.testCond != "":
 		tr.StepRunnerType = RunnerTypeTest
 	}
@@ -108,7 +128,7 @@ func (s *step) generateTrail() Trail {
 }
 
 // runbookID returns id of the root runbook.
-func (s *step) runbookID() string { //nolint:unused
+func (s *step) runbookID() string {
 	return s.trails().runbookID()
 }
 
@@ -156,6 +176,10 @@ func (s *step) notYetDetectedRunner() bool {
 		s.grpcRunner == nil &&
 		s.cdpRunner == nil &&
 		s.sshRunner == nil &&
+		s.graphqlRunner == nil &&
+		s.wsRunner == nil &&
+		s.serverRunner == nil &&
+		s.grpcServerRunner == nil &&
 		s.execRunner == nil &&
 		len(s.runnerValues) > 0
 }
@@ -0,0 +1,52 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// WSServer returns a WebSocket server that echoes back the received messages.
+// When the server receives a text message "count:N", it sends the messages "1" to "N".
+func WSServer(t testing.TB) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _, err := ws.UpgradeHTTP(r, w)
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				b, op, err := wsutil.ReadClientData(conn)
+				if err != nil {
+					return
+				}
+				if op == ws.OpText && strings.HasPrefix(string(b), "count:") {
+					n, err := strconv.Atoi(strings.TrimPrefix(string(b), "count:"))
+					if err != nil {
+						return
+					}
+					for i := 1; i <= n; i++ {
+						if err := wsutil.WriteServerMessage(conn, ws.OpText, []byte(fmt.Sprintf("%d", i))); err != nil {
+							return
+						}
+					}
+					continue
+				}
+				if err := wsutil.WriteServerMessage(conn, op, b); err != nil {
+					return
+				}
+			}
+		}()
+	}))
+	t.Cleanup(func() {
+		ts.Close()
+	})
+	return ts
+}
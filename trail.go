@@ -18,16 +18,21 @@ const (
 type RunnerType string
 
 const (
-	RunnerTypeHTTP    RunnerType = "http"
-	RunnerTypeDB      RunnerType = "db"
-	RunnerTypeGRPC    RunnerType = "grpc"
-	RunnerTypeCDP     RunnerType = "cdp"
-	RunnerTypeSSH     RunnerType = "ssh"
-	RunnerTypeExec    RunnerType = "exec"
-	RunnerTypeTest    RunnerType = "test"
-	RunnerTypeDump    RunnerType = "dump"
-	RunnerTypeInclude RunnerType = "include"
-	RunnerTypeBind    RunnerType = "bind"
+	RunnerTypeHTTP       RunnerType = "http"
+	RunnerTypeDB         RunnerType = "db"
+	RunnerTypeGRPC       RunnerType = "grpc"
+	RunnerTypeCDP        RunnerType = "cdp"
+	RunnerTypeSSH        RunnerType = "ssh"
+	RunnerTypeExec       RunnerType = "exec"
+	RunnerTypeTest       RunnerType = "test"
+	RunnerTypeDump       RunnerType = "dump"
+	RunnerTypeInclude    RunnerType = "include"
+	RunnerTypeBind       RunnerType = "bind"
+	RunnerTypeGraphQL    RunnerType = "graphql"
+	RunnerTypeWS         RunnerType = "ws"
+	RunnerTypeServer     RunnerType = "server"
+	RunnerTypeGRPCServer RunnerType = "grpcServer"
+	RunnerTypeSnapshot   RunnerType = "snapshot"
 )
 
 // Trail - The trail of elements in the runbook at runtime.
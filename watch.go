@@ -0,0 +1,464 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/fsnotify/fsnotify"
+	"github.com/goccy/go-yaml"
+)
+
+// watchDebounce is the time to wait for subsequent file changes before rerunning runbooks.
+const watchDebounce = 200 * time.Millisecond
+
+// Watch runs the runbooks, and then reruns the runbooks affected by file changes until the context is canceled.
+// The runbooks, runbooks they include or need, `json://` and `yaml://` var files, and the files ( e.g. overlays and underlays ) are watched.
+// fn is called after each run with the error of loading or running the runbooks, and Result() returns the result of the latest run.
+// Watch returns the error returned by fn. Errors of the watcher are also passed to fn.
+// Runners whose definitions are not changed and method descriptors of gRPC runners are reused across runs.
+// Reused runners keep their connections ( e.g. gRPC connections and DB connections ) across runs, and runners are closed when Watch returns.
+func (opn *operatorN) Watch(ctx context.Context, files []string, fn func(err error) error) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = w.Close()
+	}()
+	opn.keepRunners = true
+	d := newGRPCDescriptors()
+	for _, op := range opn.om {
+		for _, r := range op.grpcRunners {
+			if r.descriptors == nil {
+				r.descriptors = d
+			}
+		}
+	}
+	wr := newWarmRunners(opn.ops)
+	defer func() {
+		wr.close()
+		for _, op := range opn.ops {
+			if _, ok := wr.ops[op.bookPath]; !ok {
+				op.Close(true)
+			}
+		}
+	}()
+	opts := append(slices.Clone(opn.opts), withGRPCDescriptors(d), withWarmRunners(wr))
+	rerun := func(p string) error {
+		nopn, err := Load(p, opts...)
+		if err != nil {
+			return fn(err)
+		}
+		nopn.keepRunners = true
+		wr.keep(nopn.ops)
+		err = nopn.RunN(ctx)
+		opn.mu.Lock()
+		opn.results = []*runNResult{nopn.Result()}
+		opn.mu.Unlock()
+		return fn(err)
+	}
+
+	watched := map[string]struct{}{}
+	g, err := newWatchGraph(opn.pathp, files)
+	if err != nil {
+		return err
+	}
+	g.watch(w, watched)
+	if err := fn(opn.RunN(ctx)); err != nil {
+		return err
+	}
+
+	changed := map[string]struct{}{}
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			if err := fn(err); err != nil {
+				return err
+			}
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			changed[filepath.Clean(ev.Name)] = struct{}{}
+			debounce = time.After(watchDebounce)
+		case <-debounce:
+			debounce = nil
+			// Rebuild the graph because runbooks may have been created or their dependencies may have been changed.
+			g, err = newWatchGraph(opn.pathp, files)
+			if err != nil {
+				if err := fn(err); err != nil {
+					return err
+				}
+				continue
+			}
+			g.watch(w, watched)
+			affected := g.affected(changed)
+			clear(changed)
+			if len(affected) == 0 {
+				continue
+			}
+			if err := rerun(strings.Join(affected, string(filepath.ListSeparator))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// warmRunners holds the runners of the latest loaded runbooks to reuse them when the runbooks are rerun.
+type warmRunners struct {
+	ops    map[string]*operator      // map[runbook path]operator
+	defs   map[string]map[string]any // map[runbook path]runner definitions of the operator
+	loaded map[string]map[string]any // map[runbook path]runner definitions of the runbook being loaded
+	mu     sync.Mutex
+}
+
+func newWarmRunners(ops []*operator) *warmRunners {
+	w := &warmRunners{
+		ops:    map[string]*operator{},
+		defs:   map[string]map[string]any{},
+		loaded: map[string]map[string]any{},
+	}
+	for _, op := range ops {
+		f, err := os.Open(op.bookPath)
+		if err != nil {
+			continue
+		}
+		bk, err := parseBook(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		w.ops[op.bookPath] = op
+		w.defs[op.bookPath] = bk.runners
+	}
+	return w
+}
+
+// keep replaces the runners to be reused with the runners of the loaded operators.
+// Runners of the previous operators that are not reused are closed.
+func (w *warmRunners) keep(ops []*operator) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, op := range ops {
+		defs, ok := w.loaded[op.bookPath]
+		if !ok {
+			continue
+		}
+		if prev, ok := w.ops[op.bookPath]; ok && prev != op {
+			closeUnusedRunners(prev.grpcRunners, op.grpcRunners)
+			closeUnusedRunners(prev.dbRunners, op.dbRunners)
+			closeUnusedRunners(prev.sshRunners, op.sshRunners)
+			closeUnusedRunners(prev.wsRunners, op.wsRunners)
+			closeUnusedRunners(prev.serverRunners, op.serverRunners)
+			closeUnusedRunners(prev.grpcServerRunners, op.grpcServerRunners)
+			closeUnusedRunners(prev.cdpRunners, op.cdpRunners)
+		}
+		w.ops[op.bookPath] = op
+		w.defs[op.bookPath] = defs
+	}
+	clear(w.loaded)
+}
+
+// close closes the runners of the operators.
+func (w *warmRunners) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, op := range w.ops {
+		op.Close(true)
+	}
+}
+
+func closeUnusedRunners[T interface {
+	comparable
+	Close() error
+}](prev, next map[string]T) {
+	used := map[T]struct{}{}
+	for _, r := range next {
+		used[r] = struct{}{}
+	}
+	for _, r := range prev {
+		if _, ok := used[r]; !ok {
+			_ = r.Close()
+		}
+	}
+}
+
+// withWarmRunners reuses the runners of the previous run whose definitions are not changed.
+// CDP runners are not reused in the same way as included runbooks.
+func withWarmRunners(w *warmRunners) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if bk.path == "" {
+			return nil
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.loaded[bk.path] = bk.runners
+		op, ok := w.ops[bk.path]
+		if !ok {
+			return nil
+		}
+		defs := w.defs[bk.path]
+		for k, v := range bk.runners {
+			if !reflect.DeepEqual(v, defs[k]) {
+				continue
+			}
+			var opt Option
+			if r, ok := op.httpRunners[k]; ok {
+				opt = reuseHTTPRunner(k, r)
+			}
+			if r, ok := op.dbRunners[k]; ok {
+				opt = reuseDBRunner(k, r)
+			}
+			if r, ok := op.grpcRunners[k]; ok {
+				opt = reuseGrpcRunner(k, r)
+			}
+			if r, ok := op.sshRunners[k]; ok {
+				opt = reuseSSHRunner(k, r)
+			}
+			if r, ok := op.graphqlRunners[k]; ok {
+				opt = reuseGraphQLRunner(k, r)
+			}
+			if r, ok := op.wsRunners[k]; ok {
+				opt = reuseWSRunner(k, r)
+			}
+			if r, ok := op.serverRunners[k]; ok {
+				opt = reuseServerRunner(k, r)
+			}
+			if r, ok := op.grpcServerRunners[k]; ok {
+				opt = reuseGRPCServerRunner(k, r)
+			}
+			if opt == nil {
+				continue
+			}
+			if err := opt(bk); err != nil {
+				return err
+			}
+			delete(bk.runnerErrs, k)
+		}
+		return nil
+	}
+}
+
+// watchGraph is a dependency graph of runbooks and the local files they depend on.
+type watchGraph struct {
+	runbooks map[string]string   // map[absolute path of runbook]path to load
+	deps     map[string][]string // map[absolute path of file][]absolute path of runbook depending on the file
+	patterns map[string][]string // map[glob pattern of var files][]absolute path of runbook depending on the files
+	dirs     map[string]struct{} // directories to be watched
+}
+
+func newWatchGraph(pathp string, files []string) (*watchGraph, error) {
+	g := &watchGraph{
+		runbooks: map[string]string{},
+		deps:     map[string][]string{},
+		patterns: map[string][]string{},
+		dirs:     map[string]struct{}{},
+	}
+	// Watch base directories of path patterns for created runbooks
+	for _, pp := range splitPathList(pathp) {
+		if hasRemotePrefix(pp) {
+			continue
+		}
+		base, _ := doublestar.SplitPattern(filepath.ToSlash(strings.TrimPrefix(pp, prefixFile)))
+		if fi, err := os.Stat(base); err == nil && !fi.IsDir() {
+			base = filepath.Dir(base)
+		}
+		if ab, err := filepath.Abs(base); err == nil {
+			g.dirs[ab] = struct{}{}
+		}
+	}
+	var abs []string
+	for _, f := range files {
+		af, err := filepath.Abs(f)
+		if err != nil {
+			return nil, err
+		}
+		abs = append(abs, af)
+	}
+	paths, err := fetchPaths(pathp)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paths {
+		ap, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		g.runbooks[ap] = p
+		d := &runbookDeps{
+			files:    map[string]struct{}{},
+			patterns: map[string]struct{}{},
+		}
+		d.collect(ap)
+		for _, f := range abs {
+			d.files[f] = struct{}{}
+		}
+		for f := range d.files {
+			g.deps[f] = append(g.deps[f], ap)
+			g.dirs[filepath.Dir(f)] = struct{}{}
+		}
+		for pt := range d.patterns {
+			g.patterns[pt] = append(g.patterns[pt], ap)
+			base, _ := doublestar.SplitPattern(filepath.ToSlash(pt))
+			g.dirs[filepath.FromSlash(base)] = struct{}{}
+		}
+	}
+	return g, nil
+}
+
+// watch adds the directories of the graph that are not watched yet to the watcher.
+func (g *watchGraph) watch(w *fsnotify.Watcher, watched map[string]struct{}) {
+	for d := range g.dirs {
+		if _, ok := watched[d]; ok {
+			continue
+		}
+		// Directories that do not exist yet are retried when the graph is rebuilt
+		if err := w.Add(d); err != nil {
+			continue
+		}
+		watched[d] = struct{}{}
+	}
+}
+
+// affected returns the paths of the runbooks affected by the changed files.
+func (g *watchGraph) affected(changed map[string]struct{}) []string {
+	rbs := map[string]struct{}{}
+	for f := range changed {
+		for _, rb := range g.deps[f] {
+			rbs[rb] = struct{}{}
+		}
+		for pt, rs := range g.patterns {
+			if ok, _ := doublestar.PathMatch(pt, f); !ok {
+				continue
+			}
+			for _, rb := range rs {
+				rbs[rb] = struct{}{}
+			}
+		}
+	}
+	var affected []string
+	for rb := range rbs {
+		p, ok := g.runbooks[rb]
+		if !ok {
+			continue
+		}
+		affected = append(affected, p)
+	}
+	sort.Strings(affected)
+	return affected
+}
+
+// runbookDeps collects local files that a runbook depends on.
+type runbookDeps struct {
+	files    map[string]struct{}
+	patterns map[string]struct{}
+}
+
+// collect collects the runbook itself, runbooks it includes or needs and var files recursively.
+// Runbooks that cannot be read or parsed ( e.g. while editing ) are collected without their dependencies.
+func (d *runbookDeps) collect(p string) {
+	if _, ok := d.files[p]; ok {
+		return
+	}
+	d.files[p] = struct{}{}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return
+	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return
+	}
+	root := filepath.Dir(p)
+	d.collectVars(raw["vars"], root)
+	if needs, ok := raw["needs"].(map[string]any); ok {
+		for _, v := range needs {
+			d.collectRunbook(v, root)
+		}
+	}
+	var steps []any
+	switch v := raw["steps"].(type) {
+	case []any:
+		steps = v
+	case map[string]any:
+		for _, s := range v {
+			steps = append(steps, s)
+		}
+	}
+	for _, s := range steps {
+		sm, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch v := sm[includeRunnerKey].(type) {
+		case string:
+			d.collectRunbook(v, root)
+		case map[string]any:
+			d.collectRunbook(v["path"], root)
+			d.collectVars(v["vars"], root)
+		}
+	}
+}
+
+func (d *runbookDeps) collectRunbook(v any, root string) {
+	p, ok := v.(string)
+	if !ok || hasRemotePrefix(p) {
+		return
+	}
+	p = strings.TrimPrefix(p, prefixFile)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(root, p)
+	}
+	d.collect(filepath.Clean(p))
+}
+
+// collectVars collects var files using the same schemes as evaluateSchema.
+func (d *runbookDeps) collectVars(v any, root string) {
+	vars, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	for _, vv := range vars {
+		s, ok := vv.(string)
+		if !ok {
+			continue
+		}
+		for _, e := range evaluators {
+			if !strings.HasPrefix(s, e.scheme) {
+				continue
+			}
+			p := s[len(e.scheme):]
+			if strings.Contains(p, "://") || strings.Contains(p, "{{") {
+				continue
+			}
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(root, p)
+			}
+			if strings.Contains(p, multiple) {
+				d.patterns[p] = struct{}{}
+				continue
+			}
+			d.files[filepath.Clean(p)] = struct{}{}
+		}
+	}
+}
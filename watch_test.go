@@ -0,0 +1,212 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatchGraphAffected(t *testing.T) {
+	pathp := strings.Join([]string{
+		"testdata/book/multiple_include_main.yml",
+		"testdata/book/vars_external.yml",
+	}, string(filepath.ListSeparator))
+	tests := []struct {
+		files   []string
+		changed []string
+		want    []string
+	}{
+		{
+			nil,
+			[]string{"testdata/book/multiple_include_main.yml"},
+			[]string{"testdata/book/multiple_include_main.yml"},
+		},
+		{
+			nil,
+			[]string{"testdata/book/multiple_include_b.yml"},
+			[]string{"testdata/book/multiple_include_main.yml"},
+		},
+		{
+			nil,
+			[]string{"testdata/vars_array.json"},
+			[]string{"testdata/book/vars_external.yml"},
+		},
+		{
+			nil,
+			[]string{"testdata/book/always_success.yml"},
+			nil,
+		},
+		{
+			[]string{"testdata/book/always_success.yml"},
+			[]string{"testdata/book/always_success.yml"},
+			[]string{"testdata/book/multiple_include_main.yml", "testdata/book/vars_external.yml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.changed, ","), func(t *testing.T) {
+			g, err := newWatchGraph(pathp, tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed := map[string]struct{}{}
+			for _, c := range tt.changed {
+				ac, err := filepath.Abs(c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				changed[ac] = struct{}{}
+			}
+			got := g.affected(changed)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWarmRunners(t *testing.T) {
+	const book = `desc: Warm runners
+runners:
+  req: http://127.0.0.1:8080
+  greq:
+    addr: 127.0.0.1:8081
+    tls: false
+steps:
+  -
+    test: true
+`
+	tests := []struct {
+		name        string
+		changed     string
+		wantReqSame bool
+	}{
+		{"steps changed", strings.Replace(book, "test: true", "test: 1 == 1", 1), true},
+		{"runner changed", strings.Replace(book, "127.0.0.1:8080", "127.0.0.1:8082", 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "book.yml")
+			if err := os.WriteFile(p, []byte(book), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			opn, err := Load(p, Scopes(ScopeAllowReadParent))
+			if err != nil {
+				t.Fatal(err)
+			}
+			prev := opn.ops[0]
+			w := newWarmRunners(opn.ops)
+			if err := os.WriteFile(p, []byte(tt.changed), os.ModePerm); err != nil {
+				t.Fatal(err)
+			}
+			nopn, err := Load(p, Scopes(ScopeAllowReadParent), withWarmRunners(w))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.keep(nopn.ops)
+			op := nopn.ops[0]
+			if got := op.httpRunners["req"] == prev.httpRunners["req"]; got != tt.wantReqSame {
+				t.Errorf("got %v\nwant %v", got, tt.wantReqSame)
+			}
+			if op.grpcRunners["greq"] != prev.grpcRunners["greq"] {
+				t.Error("want to reuse the gRPC runner whose definition is not changed")
+			}
+			if w.ops[p] != op {
+				t.Error("want to keep the loaded operator")
+			}
+		})
+	}
+}
+
+func TestWatchReplacesResult(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "book.yml")
+	if err := os.WriteFile(p, []byte("desc: Watch\nsteps:\n  -\n    test: true\n"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	opn, err := Load(p, Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	runs := 0
+	if err := opn.Watch(ctx, nil, func(err error) error {
+		if err != nil {
+			return err
+		}
+		runs++
+		if runs == 3 {
+			cancel()
+			return nil
+		}
+		// Touch the runbook to rerun it
+		return os.WriteFile(p, []byte("desc: Watch\nsteps:\n  -\n    test: true\n"), os.ModePerm)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 3 {
+		t.Fatalf("got %v\nwant %v", runs, 3)
+	}
+	if got := len(opn.results); got != 1 {
+		t.Errorf("got %v\nwant %v", got, 1)
+	}
+	if got := opn.Result().Total.Load(); got != 1 {
+		t.Errorf("got %v\nwant %v", got, 1)
+	}
+}
+
+func TestWatchKeepsConnections(t *testing.T) {
+	// The in-memory database lives only while the connection is open
+	const book = `desc: Watch with DB
+runners:
+  db:
+    dsn: "sqlite3://:memory:"
+steps:
+  -
+    db:
+      query: |
+        CREATE TABLE IF NOT EXISTS runs (id INTEGER PRIMARY KEY AUTOINCREMENT);
+        INSERT INTO runs DEFAULT VALUES;
+  -
+    db:
+      query: SELECT COUNT(*) AS c FROM runs;
+    test: current.rows[0].c == %d
+`
+	p := filepath.Join(t.TempDir(), "book.yml")
+	if err := os.WriteFile(p, []byte(fmt.Sprintf(book, 1)), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	opn, err := Load(p, Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	runs := 0
+	if err := opn.Watch(ctx, nil, func(err error) error {
+		if err != nil {
+			return err
+		}
+		runs++
+		if opn.Result().HasFailure() {
+			cancel()
+			return fmt.Errorf("run %d failed: %v", runs, opn.Result().RunResults[0].Err)
+		}
+		if runs == 2 {
+			cancel()
+			return nil
+		}
+		// Change the steps ( not the runner ) to rerun the runbook with the same DB connection
+		return os.WriteFile(p, []byte(fmt.Sprintf(book, 2)), os.ModePerm)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 2 {
+		t.Fatalf("got %v\nwant %v", runs, 2)
+	}
+}
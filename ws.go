@@ -0,0 +1,350 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/duration"
+	"github.com/k1LoW/runn/internal/expr"
+	"github.com/k1LoW/runn/internal/store"
+	"github.com/k1LoW/runn/version"
+)
+
+const (
+	wsStoreMessagesKey = "messages"
+	wsStoreMessageKey  = "message"
+	wsStoreResponseKey = "res"
+)
+
+const (
+	wsMessageStoreTypeKey = "type"
+	wsMessageStoreDataKey = "data"
+	wsMessageStoreBodyKey = "body"
+)
+
+const (
+	wsOpSend    = "send"
+	wsOpReceive = "receive"
+	wsOpClose   = "close"
+)
+
+const defaultWSReceiveTimeout = 10 * time.Second
+
+// WSMessageType is the type of WebSocket data frame.
+type WSMessageType string
+
+const (
+	WSMessageText   WSMessageType = "text"
+	WSMessageBinary WSMessageType = "binary"
+)
+
+// WSMessage is a WebSocket message sent or received by the WebSocket runner.
+type WSMessage struct {
+	Type WSMessageType
+	Data []byte
+}
+
+type wsRunner struct {
+	name       string
+	endpoint   *url.URL
+	headers    http.Header
+	cacert     []byte
+	cert       []byte
+	key        []byte
+	skipVerify bool
+	timeout    time.Duration
+	hostRules  hostRules
+	conn       net.Conn
+	rw         io.ReadWriter
+	operatorID string
+	mu         sync.Mutex
+}
+
+type wsRequest struct {
+	send    *WSMessage
+	receive *wsReceive
+	close   *wsClose
+}
+
+type wsReceive struct {
+	until   string
+	count   int
+	timeout time.Duration
+}
+
+type wsClose struct {
+	code   int
+	reason string
+}
+
+func newWSRunner(name, endpoint string) (*wsRunner, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid websocket endpoint: %s", endpoint)
+	}
+	return &wsRunner{
+		name:     name,
+		endpoint: u,
+		headers:  http.Header{},
+		timeout:  30 * time.Second,
+	}, nil
+}
+
+func (rnr *wsRunner) Renew() error {
+	return rnr.Close()
+}
+
+func (rnr *wsRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.conn == nil {
+		return nil
+	}
+	err := rnr.conn.Close()
+	rnr.conn = nil
+	rnr.rw = nil
+	return err
+}
+
+func (rnr *wsRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	e, err := o.expandBeforeRecord(s.wsRequest, s)
+	if err != nil {
+		return err
+	}
+	r, ok := e.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid websocket request: %v", e)
+	}
+	req, err := parseWSRequest(r)
+	if err != nil {
+		return err
+	}
+	if err := rnr.run(ctx, req, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rnr *wsRunner) run(ctx context.Context, r *wsRequest, s *step) error {
+	o := s.parent
+	if err := rnr.connect(ctx, o); err != nil {
+		return err
+	}
+	o.capturers.captureWSStart(rnr.name)
+	defer o.capturers.captureWSEnd(rnr.name)
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+
+	messages := []any{}
+	d := map[string]any{
+		wsStoreMessagesKey: messages,
+		wsStoreMessageKey:  nil,
+	}
+	if r.send != nil {
+		o.capturers.captureWSSendMessage(rnr.name, r.send)
+		op := ws.OpText
+		if r.send.Type == WSMessageBinary {
+			op = ws.OpBinary
+		}
+		if err := wsutil.WriteClientMessage(rnr.rw, op, r.send.Data); err != nil {
+			return err
+		}
+	}
+	if r.receive != nil {
+		timeout := r.receive.timeout
+		if timeout == 0 {
+			timeout = defaultWSReceiveTimeout
+		}
+		deadline := time.Now().Add(timeout)
+		if err := rnr.conn.SetReadDeadline(deadline); err != nil {
+			return err
+		}
+		for {
+			b, op, err := wsutil.ReadServerData(rnr.rw)
+			if err != nil {
+				var cerr wsutil.ClosedError
+				switch {
+				case errors.As(err, &cerr):
+					_ = rnr.conn.Close()
+					rnr.conn = nil
+					rnr.rw = nil
+					return fmt.Errorf("websocket connection closed by server: %d %s", cerr.Code, cerr.Reason)
+				case errors.Is(err, os.ErrDeadlineExceeded):
+					if r.receive.until != "" {
+						return fmt.Errorf("timeout waiting for message matching %q", r.receive.until)
+					}
+					return fmt.Errorf("timeout waiting for %d messages", r.receive.count)
+				}
+				return err
+			}
+			m := &WSMessage{Type: WSMessageText, Data: b}
+			if op == ws.OpBinary {
+				m.Type = WSMessageBinary
+			}
+			o.capturers.captureWSReceiveMessage(rnr.name, m)
+			mm := m.toMap()
+			messages = append(messages, mm)
+			d[wsStoreMessagesKey] = messages
+			d[wsStoreMessageKey] = mm
+			if r.receive.until == "" {
+				if len(messages) >= r.receive.count {
+					break
+				}
+				continue
+			}
+			sm := o.store.ToMap()
+			sm[store.RootKeyCurrent] = map[string]any{
+				wsStoreResponseKey: d,
+			}
+			tf, err := expr.EvalCond(r.receive.until, sm)
+			if err != nil {
+				return err
+			}
+			if tf {
+				break
+			}
+		}
+		if err := rnr.conn.SetReadDeadline(time.Time{}); err != nil {
+			return err
+		}
+	}
+	if r.close != nil {
+		o.capturers.captureWSClose(rnr.name, r.close.code, r.close.reason)
+		body := ws.NewCloseFrameBody(ws.StatusCode(r.close.code), r.close.reason) //nolint:gosec
+		if err := wsutil.WriteClientMessage(rnr.rw, ws.OpClose, body); err != nil {
+			return err
+		}
+		_ = rnr.conn.Close()
+		rnr.conn = nil
+		rnr.rw = nil
+	}
+
+	o.record(s.idx, map[string]any{
+		wsStoreResponseKey: d,
+	})
+	return nil
+}
+
+func (rnr *wsRunner) connect(ctx context.Context, o *operator) error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.conn != nil {
+		return nil
+	}
+	h := rnr.headers.Clone()
+	if h.Get("User-Agent") == "" {
+		h.Set("User-Agent", fmt.Sprintf("runn/%s", version.Version))
+	}
+	d := ws.Dialer{
+		Header:  ws.HandshakeHeaderHTTP(h),
+		Timeout: rnr.timeout,
+	}
+	if len(rnr.hostRules) > 0 {
+		d.NetDial = rnr.hostRules.dialContextFunc()
+	}
+	if rnr.endpoint.Scheme == "wss" {
+		tlsc := &tls.Config{MinVersion: tls.VersionTLS12}
+		if len(rnr.cert) != 0 && len(rnr.key) != 0 {
+			certificate, err := tls.X509KeyPair(rnr.cert, rnr.key)
+			if err != nil {
+				return err
+			}
+			tlsc.Certificates = []tls.Certificate{certificate}
+		}
+		if rnr.skipVerify {
+			//#nosec G402
+			tlsc.InsecureSkipVerify = true
+		} else if len(rnr.cacert) != 0 {
+			certpool, err := x509.SystemCertPool()
+			if err != nil {
+				// FIXME for Windows
+				// ref: https://github.com/golang/go/issues/18609
+				certpool = x509.NewCertPool()
+			}
+			if ok := certpool.AppendCertsFromPEM(rnr.cacert); !ok {
+				return errors.New("failed to append cacert")
+			}
+			tlsc.RootCAs = certpool
+		}
+		d.TLSConfig = tlsc
+	}
+	conn, br, _, err := d.Dial(ctx, rnr.endpoint.String())
+	if err != nil {
+		return err
+	}
+	rnr.conn = conn
+	rnr.rw = conn
+	if br != nil {
+		// Data sent by the server immediately after the handshake may be buffered.
+		rnr.rw = struct {
+			io.Reader
+			io.Writer
+		}{io.MultiReader(br, conn), conn}
+	}
+	if err := donegroup.Cleanup(ctx, func() error {
+		// In the case of Reused runners, leave the cleanup to the main cleanup
+		if o.id != rnr.operatorID {
+			return nil
+		}
+		return rnr.Close()
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *WSMessage) toMap() map[string]any {
+	mm := map[string]any{
+		wsMessageStoreTypeKey: string(m.Type),
+		wsMessageStoreBodyKey: nil,
+	}
+	switch m.Type {
+	case WSMessageText:
+		mm[wsMessageStoreDataKey] = string(m.Data)
+		var v any
+		if err := json.Unmarshal(m.Data, &v); err == nil {
+			mm[wsMessageStoreBodyKey] = v
+		}
+	default:
+		mm[wsMessageStoreDataKey] = m.Data
+	}
+	return mm
+}
+
+// applyConfig applies wsRunnerConfig to the runner.
+func (rnr *wsRunner) applyConfig(c *wsRunnerConfig, root string) error {
+	for k, v := range c.Headers {
+		rnr.headers.Set(k, v)
+	}
+	var err error
+	rnr.cacert, rnr.cert, rnr.key, err = readTLSFiles(root, c.CACert, c.Cert, c.Key)
+	if err != nil {
+		return err
+	}
+	rnr.skipVerify = c.SkipVerify
+	if c.Timeout != "" {
+		rnr.timeout, err = duration.Parse(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout in WSRunnerConfig is invalid: %w", err)
+		}
+	}
+	return nil
+}
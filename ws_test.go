@@ -0,0 +1,102 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/donegroup"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestWSRunner(t *testing.T) {
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/ws.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			ts := testutil.WSServer(t)
+			t.Setenv("TEST_WS_ENDPOINT", strings.Replace(ts.URL, "http://", "ws://", 1))
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWSRunnerReceiveTimeout(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ts := testutil.WSServer(t)
+	endpoint := strings.Replace(ts.URL, "http://", "ws://", 1)
+	o, err := New(WSRunner("ws", endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := o.wsRunners["ws"]
+	if !ok {
+		t.Fatal("ws runner not found")
+	}
+	s := newStep(0, "stepKey", o, nil)
+	req := &wsRequest{
+		send: &WSMessage{Type: WSMessageText, Data: []byte("count:2")},
+		receive: &wsReceive{
+			until:   `current.res.message.data == "3"`,
+			timeout: 100 * time.Millisecond,
+		},
+	}
+	t.Cleanup(func() {
+		_ = r.Close()
+	})
+	if err := r.run(ctx, req, s); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestWSDebugger(t *testing.T) {
+	ctx, cancel := donegroup.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ts := testutil.WSServer(t)
+	endpoint := strings.Replace(ts.URL, "http://", "ws://", 1)
+	out := new(bytes.Buffer)
+	o, err := New(WSRunner("ws", endpoint), Capture(NewDebugger(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newStep(0, "stepKey", o, nil)
+	req := &wsRequest{
+		send:    &WSMessage{Type: WSMessageText, Data: []byte("hello")},
+		receive: &wsReceive{count: 1},
+		close:   &wsClose{code: 1000},
+	}
+	if err := o.wsRunners["ws"].run(ctx, req, s); err != nil {
+		t.Fatal(err)
+	}
+	want := `>>>>>START WebSocket>>>>>
+-----START WebSocket SEND MESSAGE-----
+type: text
+data: hello
+-----END WebSocket SEND MESSAGE-----
+-----START WebSocket RECEIVE MESSAGE-----
+type: text
+data: hello
+-----END WebSocket RECEIVE MESSAGE-----
+-----START WebSocket CLOSE-----
+code: 1000
+reason: 
+-----END WebSocket CLOSE-----
+<<<<<END WebSocket<<<<<
+`
+	if got := out.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
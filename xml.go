@@ -0,0 +1,262 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// xmlAttrPrefix - The prefix of keys for attributes ( e.g. `@id`, `@xmlns:soap` ).
+	xmlAttrPrefix = "@"
+	// xmlTextKey - The key for the text of the element that has attributes or child elements.
+	xmlTextKey = "#text"
+	// xmlChildrenKey - The key for the list of child elements that are encoded in the order of the list.
+	xmlChildrenKey = "#children"
+)
+
+// encodeXML encodes the map to XML.
+// The map must have the only one root element. Keys prefixed with `@` are attributes, `#text` is the text of the element,
+// and a list is encoded as repeated elements. Since the order of the keys of the map is not kept,
+// child elements are encoded in the sorted order of their names. `#children` is the list of single-key maps
+// ( e.g. `[{soap:Header: ...}, {soap:Body: ...}]` ) to encode child elements in the order of the list.
+func encodeXML(v any) ([]byte, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid XML body: %v", v)
+	}
+	if len(m) != 1 {
+		return nil, fmt.Errorf("XML body must have only one root element: %v", v)
+	}
+	buf := new(bytes.Buffer)
+	_, _ = buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	for name, vv := range m {
+		if strings.HasPrefix(name, xmlAttrPrefix) || name == xmlTextKey {
+			return nil, fmt.Errorf("invalid XML root element: %s", name)
+		}
+		if _, ok := vv.([]any); ok {
+			return nil, fmt.Errorf("XML body must have only one root element: %v", v)
+		}
+		if err := encodeXMLElement(enc, name, vv); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, v any) error {
+	if vv, ok := v.([]any); ok {
+		for _, e := range vv {
+			if err := encodeXMLElement(enc, name, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch vv := v.(type) {
+	case nil:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+	case map[string]any:
+		var keys []string
+		for k := range vv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var children []string
+		for _, k := range keys {
+			if !strings.HasPrefix(k, xmlAttrPrefix) {
+				children = append(children, k)
+				continue
+			}
+			s, err := cast.ToStringE(vv[k])
+			if err != nil {
+				return fmt.Errorf("invalid XML attribute %s: %w", k, err)
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(k, xmlAttrPrefix)}, Value: s})
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, k := range children {
+			if k == xmlChildrenKey {
+				if err := encodeXMLChildren(enc, name, vv[k]); err != nil {
+					return err
+				}
+				continue
+			}
+			if k == xmlTextKey {
+				s, err := cast.ToStringE(vv[k])
+				if err != nil {
+					return fmt.Errorf("invalid XML text of %s: %w", name, err)
+				}
+				if err := enc.EncodeToken(xml.CharData(s)); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encodeXMLElement(enc, k, vv[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		s, err := cast.ToStringE(vv)
+		if err != nil {
+			return fmt.Errorf("invalid XML element %s: %w", name, err)
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := enc.EncodeToken(xml.CharData(s)); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// encodeXMLChildren encodes the child elements of `#children` in the order of the list.
+func encodeXMLChildren(enc *xml.Encoder, name string, v any) error {
+	l, ok := v.([]any)
+	if !ok {
+		return fmt.Errorf("invalid XML children of %s: %v", name, v)
+	}
+	for _, e := range l {
+		m, ok := e.(map[string]any)
+		if !ok || len(m) != 1 {
+			return fmt.Errorf("invalid XML children of %s: each child must be a map with only one element: %v", name, e)
+		}
+		for k, vv := range m {
+			if strings.HasPrefix(k, xmlAttrPrefix) || k == xmlTextKey || k == xmlChildrenKey {
+				return fmt.Errorf("invalid XML child element of %s: %s", name, k)
+			}
+			if err := encodeXMLElement(enc, k, vv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children map[string]any
+	text     strings.Builder
+}
+
+// value returns the value of the element.
+// The element that has neither attributes nor child elements is the text, otherwise it is the map.
+func (n *xmlNode) value() any {
+	if len(n.attrs) == 0 && len(n.children) == 0 {
+		return n.text.String()
+	}
+	m := map[string]any{}
+	for _, a := range n.attrs {
+		m[xmlAttrPrefix+xmlName(a.Name)] = a.Value
+	}
+	for k, v := range n.children {
+		m[k] = v
+	}
+	if t := strings.TrimSpace(n.text.String()); t != "" {
+		m[xmlTextKey] = t
+	}
+	return m
+}
+
+func (n *xmlNode) appendChild(name string, v any) {
+	if n.children == nil {
+		n.children = map[string]any{}
+	}
+	e, ok := n.children[name]
+	if !ok {
+		n.children[name] = v
+		return
+	}
+	if l, ok := e.([]any); ok {
+		n.children[name] = append(l, v)
+		return
+	}
+	n.children[name] = []any{e, v}
+}
+
+// decodeXML decodes XML to the map in the same format as encodeXML.
+// The prefixes of namespaces are kept in the keys ( e.g. `soap:Envelope` ), and all values are strings.
+func decodeXML(b []byte) (map[string]any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	dec.CharsetReader = charset.NewReaderLabel
+	var (
+		stack []*xmlNode
+		root  map[string]any
+	)
+	for {
+		tok, err := dec.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, errors.New("invalid XML: multiple root elements")
+			}
+			stack = append(stack, &xmlNode{name: xmlName(t.Name), attrs: t.Attr})
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("invalid XML: unexpected end element %s", xmlName(t.Name))
+			}
+			n := stack[len(stack)-1]
+			if n.name != xmlName(t.Name) {
+				return nil, fmt.Errorf("invalid XML: element %s closed by %s", n.name, xmlName(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				root = map[string]any{n.name: n.value()}
+				continue
+			}
+			stack[len(stack)-1].appendChild(n.name, n.value())
+		case xml.CharData:
+			if len(stack) > 0 {
+				_, _ = stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("invalid XML: element %s is not closed", stack[len(stack)-1].name)
+	}
+	if root == nil {
+		return nil, errors.New("invalid XML: no root element")
+	}
+	return root, nil
+}
+
+// isXMLContentType returns whether the Content-Type is XML ( application/xml, text/xml and application/*+xml ).
+func isXMLContentType(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == MediaTypeApplicationXML || mt == MediaTypeTextXML || strings.HasSuffix(mt, "+xml")
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
@@ -0,0 +1,248 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncodeXML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			`
+user:
+  name: alice
+  age: 20`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<user><age>20</age><name>alice</name></user>`,
+			false,
+		},
+		{
+			`
+soap:Envelope:
+  '@xmlns:soap': http://schemas.xmlsoap.org/soap/envelope/
+  soap:Body:
+    GetUser:
+      '@xmlns': http://example.com/users
+      id: 1`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><GetUser xmlns="http://example.com/users"><id>1</id></GetUser></soap:Body></soap:Envelope>`,
+			false,
+		},
+		{
+			`
+items:
+  item:
+    - '@id': 1
+      '#text': apple
+    - '@id': 2
+      '#text': banana
+  empty:`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<items><empty></empty><item id="1">apple</item><item id="2">banana</item></items>`,
+			false,
+		},
+		{
+			`
+soap:Envelope:
+  '@xmlns:soap': http://schemas.xmlsoap.org/soap/envelope/
+  '#children':
+    - soap:Header:
+        Auth:
+          token: secret
+    - soap:Body:
+        GetUser:
+          id: 1`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Header><Auth><token>secret</token></Auth></soap:Header><soap:Body><GetUser><id>1</id></GetUser></soap:Body></soap:Envelope>`,
+			false,
+		},
+		{
+			`
+list:
+  '#children':
+    - b: 1
+    - a: 2
+    - b: 3`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<list><b>1</b><a>2</a><b>3</b></list>`,
+			false,
+		},
+		{
+			`
+list:
+  '#children':
+    - a: 1
+      b: 2`,
+			"",
+			true,
+		},
+		{
+			`
+list:
+  '#children':
+    a: 1`,
+			"",
+			true,
+		},
+		{
+			`
+message: 'a < b & c'`,
+			`<?xml version="1.0" encoding="UTF-8"?>
+<message>a &lt; b &amp; c</message>`,
+			false,
+		},
+		{
+			`
+user: alice
+group: admin`,
+			"",
+			true,
+		},
+		{
+			`
+- alice
+- bob`,
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var v any
+			if err := yaml.Unmarshal([]byte(tt.in), &v); err != nil {
+				t.Fatal(err)
+			}
+			got, err := encodeXML(v)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+				return
+			}
+			if diff := cmp.Diff(string(got), tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			`<?xml version="1.0" encoding="UTF-8"?>
+<user>
+  <name>alice</name>
+  <age>20</age>
+</user>`,
+			map[string]any{
+				"user": map[string]any{
+					"name": "alice",
+					"age":  "20",
+				},
+			},
+			false,
+		},
+		{
+			`<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Body>
+    <GetUserResponse xmlns="http://example.com/users">
+      <user id="1" active="true">alice</user>
+    </GetUserResponse>
+  </soap:Body>
+</soap:Envelope>`,
+			map[string]any{
+				"soap:Envelope": map[string]any{
+					"@xmlns:soap": "http://schemas.xmlsoap.org/soap/envelope/",
+					"soap:Body": map[string]any{
+						"GetUserResponse": map[string]any{
+							"@xmlns": "http://example.com/users",
+							"user": map[string]any{
+								"@id":     "1",
+								"@active": "true",
+								"#text":   "alice",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			`<items><item>apple</item><item>banana</item><item><![CDATA[<cherry>]]></item><empty/></items>`,
+			map[string]any{
+				"items": map[string]any{
+					"item":  []any{"apple", "banana", "<cherry>"},
+					"empty": "",
+				},
+			},
+			false,
+		},
+		{
+			`<user><name>alice</user>`,
+			nil,
+			true,
+		},
+		{
+			`<user/><user/>`,
+			nil,
+			true,
+		},
+		{
+			`not xml`,
+			nil,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := decodeXML([]byte(tt.in))
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestIsXMLContentType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"application/xml", true},
+		{"text/xml; charset=utf-8", true},
+		{"application/soap+xml; charset=utf-8", true},
+		{"application/json", false},
+		{"text/html", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isXMLContentType(tt.in); got != tt.want {
+			t.Errorf("%s: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}